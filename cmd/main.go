@@ -9,6 +9,7 @@ import (
 
 	"github.com/hiamthach108/simplerank/pkg/logger"
 	"github.com/hiamthach108/simplerank/presentation/http"
+	"github.com/hiamthach108/simplerank/presentation/job"
 	"github.com/hiamthach108/simplerank/presentation/rstream"
 	"github.com/hiamthach108/simplerank/presentation/socket"
 	"go.uber.org/fx"
@@ -21,10 +22,13 @@ func main() {
 			config.NewAppConfig,
 			logger.NewLogger,
 			cache.NewAppCache,
+			cache.NewLocalCache,
 			database.NewDbClient,
 			database.NewClickHouseDbClient,
 			http.NewHttpServer,
 			rstream.NewSubscriber,
+			job.NewElector,
+			job.NewRunner,
 			socket.NewHub,
 			fx.Annotate(
 				func(hub *socket.Hub) socket.IBroadcaster {
@@ -36,14 +40,29 @@ func main() {
 			// Services
 			service.NewLeaderBoardSvc,
 			service.NewHistorySvc,
+			service.NewNotificationSvc,
+			service.NewMilestoneSvc,
+			service.NewTenantSvc,
+			service.NewOutboxSvc,
 
 			// Repositories
 			repository.NewLeaderboardRepository,
 			repository.NewHistoryRepository,
+			repository.NewNotificationPreferenceRepository,
+			repository.NewMilestoneRepository,
+			repository.NewEntryRatingRepository,
+			repository.NewTemplateRepository,
+			repository.NewTenantRepository,
+			repository.NewOutboxRepository,
 		),
+		// Registered first so the cache is closed after everything using it has stopped
+		fx.Invoke(func(lc fx.Lifecycle, c cache.ICache) {
+			lc.Append(fx.StopHook(c.Close))
+		}),
 		fx.Invoke(http.RegisterHooks),
 		fx.Invoke(rstream.RegisterHooks),
 		fx.Invoke(socket.RegisterHooks),
+		fx.Invoke(job.RegisterHooks),
 	)
 
 	app.Run()
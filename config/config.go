@@ -21,13 +21,102 @@ type AppConfig struct {
 		Level string `env:"LOG_LEVEL"`
 	}
 
+	Leaderboard struct {
+		MaxOvertakenNotifications int `env:"LEADERBOARD_MAX_OVERTAKEN_NOTIFICATIONS"`
+	}
+
+	// Tenant holds the quotas of tenants without their own; 0 means unlimited.
+	Tenant struct {
+		MaxLeaderboards          int `env:"TENANT_MAX_LEADERBOARDS"`
+		MaxEntriesPerLeaderboard int `env:"TENANT_MAX_ENTRIES_PER_LEADERBOARD"`
+		MaxSubmissionsPerSec     int `env:"TENANT_MAX_SUBMISSIONS_PER_SEC"`
+	}
+
+	// Auth guards tenant administration, which requires AdminAPIKey and is disabled without it.
+	// Other requests authenticate with API keys issued to tenants.
+	Auth struct {
+		AdminAPIKey string `env:"AUTH_ADMIN_API_KEY"`
+	}
+
 	Cache struct {
+		// Driver selects the cache implementation: "redis" (default) or "memory" for a single node.
+		Driver               string `env:"CACHE_DRIVER"`
 		DefaultExpireTimeSec int    `env:"CACHE_DEFAULT_EXPIRE_TIME_SEC"`
 		CleanupIntervalHour  int    `env:"CACHE_CLEANUP_INTERVAL_HOUR"`
 		RedisHost            string `env:"REDIS_HOST"`
 		RedisPort            string `env:"REDIS_PORT"`
 		RedisPassword        string `env:"REDIS_PASSWORD"`
 		RedisDB              int    `env:"REDIS_DB"`
+		// RedisMode selects "standalone" (default), "sentinel" or "cluster". Sentinel and cluster
+		// nodes are listed comma-separated in RedisAddrs.
+		RedisMode       string `env:"REDIS_MODE"`
+		RedisAddrs      string `env:"REDIS_ADDRS"`
+		RedisMasterName string `env:"REDIS_MASTER_NAME"`
+		// OperationTimeoutMs bounds each cache operation; 0 leaves them bounded by the caller only.
+		OperationTimeoutMs int `env:"CACHE_OPERATION_TIMEOUT_MS"`
+		// Codec encodes the values stored with Set, like Stream.Codec; JSON when empty. Strings,
+		// numbers and booleans are stored as is.
+		Codec string `env:"CACHE_CODEC"`
+	}
+
+	// LocalCache sizes the optional in-process cache for hot reads; 0 entries disables it.
+	LocalCache struct {
+		MaxEntries int `env:"LOCAL_CACHE_MAX_ENTRIES"`
+		TTLMs      int `env:"LOCAL_CACHE_TTL_MS"`
+	}
+
+	// Stream configures how messages are written to streams. Codecs maps streams to codecs as
+	// comma-separated stream=codec pairs; other streams use Codec, or JSON when it is empty.
+	// Codecs are json, msgpack and protobuf, which only writes messages with a generated message.
+	//
+	// A message whose handler fails is retried MaxRetries times, RetryBackoffMs apart and doubling,
+	// before it is moved to the stream's dead-letter stream. Messages left pending for ClaimIdleMs
+	// by a consumer are claimed when subscribing. Zero values use the defaults.
+	//
+	// Retention bounds streams on publish, as a maximum length ("100000") or age ("24h");
+	// Retentions overrides it per stream as stream=retention pairs. Entries a consumer group still
+	// needs are never trimmed. Empty keeps every entry.
+	//
+	// ConsumerName identifies this instance in consumer groups, defaulting to the host name and
+	// process ID; Workers consumers share each subscription. UpdatePartitions splits
+	// leaderboard_updates by leaderboard so updates of a board stay in order: each partition is
+	// consumed by the single instance holding its lease. Every instance competes for every
+	// partition unless InstanceCount is set, in which case the instance numbered InstanceIndex
+	// only competes for its share of them.
+	Stream struct {
+		Codec            string `env:"STREAM_CODEC"`
+		Codecs           string `env:"STREAM_CODECS"`
+		MaxRetries       int    `env:"STREAM_MAX_RETRIES"`
+		RetryBackoffMs   int    `env:"STREAM_RETRY_BACKOFF_MS"`
+		ClaimIdleMs      int    `env:"STREAM_CLAIM_IDLE_MS"`
+		Retention        string `env:"STREAM_RETENTION"`
+		Retentions       string `env:"STREAM_RETENTIONS"`
+		ConsumerName     string `env:"STREAM_CONSUMER_NAME"`
+		Workers          int    `env:"STREAM_WORKERS"`
+		UpdatePartitions int    `env:"STREAM_UPDATE_PARTITIONS"`
+		InstanceIndex    int    `env:"STREAM_INSTANCE_INDEX"`
+		InstanceCount    int    `env:"STREAM_INSTANCE_COUNT"`
+	}
+
+	// Outbox holds stream events until they are published; the leader relays up to BatchSize of
+	// them every RelayIntervalMs. Zero values use the defaults.
+	Outbox struct {
+		BatchSize       int `env:"OUTBOX_BATCH_SIZE"`
+		RelayIntervalMs int `env:"OUTBOX_RELAY_INTERVAL_MS"`
+	}
+
+	// Leader configures the election of the instance running singleton jobs. The leader holds a
+	// lock expiring after LockTTLMs unless renewed, so another instance takes over within about
+	// that long after the leader dies. Zero uses the default.
+	Leader struct {
+		LockTTLMs int `env:"LEADER_LOCK_TTL_MS"`
+	}
+
+	// History batches score history records written to ClickHouse: up to BatchSize records are
+	// inserted at once, after buffering for at most FlushIntervalMs. Zero values use the defaults.
+	History struct {
+		BatchSize       int `env:"HISTORY_BATCH_SIZE"`
+		FlushIntervalMs int `env:"HISTORY_FLUSH_INTERVAL_MS"`
 	}
 
 	Postgres struct {
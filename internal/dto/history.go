@@ -5,7 +5,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hiamthach108/simplerank/internal/dto/streampb"
 	"github.com/hiamthach108/simplerank/internal/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/datatypes"
 )
 
@@ -32,17 +36,73 @@ type CreateHistoryReq struct {
 	EntryID       string  `json:"entryId" binding:"required"`
 	Score         float64 `json:"score" binding:"required"`
 	Metadata      any     `json:"metadata"`
+	// OccurredAt is when the score changed, so updates relayed late are recorded at that time.
+	// Messages without it are recorded when consumed.
+	OccurredAt time.Time `json:"occurredAt,omitzero"`
+}
+
+// HistorySchemaVersion is the schema version CreateHistoryReq is published to the updates stream
+// with. Bump it when the shape changes and keep the history consumer reading older versions.
+const HistorySchemaVersion = 1
+
+func (CreateHistoryReq) SchemaVersion() int {
+	return HistorySchemaVersion
+}
+
+// MarshalProto converts the request into the message the Protobuf codec writes to the stream.
+func (r CreateHistoryReq) MarshalProto() (proto.Message, error) {
+	event := &streampb.HistoryEvent{
+		LeaderboardId: r.LeaderboardID,
+		EntryId:       r.EntryID,
+		Score:         r.Score,
+	}
+	if r.Metadata != nil {
+		metadata, err := structpb.NewValue(r.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		event.Metadata = metadata
+	}
+	if !r.OccurredAt.IsZero() {
+		event.OccurredAt = timestamppb.New(r.OccurredAt)
+	}
+	return event, nil
+}
+
+func (r *CreateHistoryReq) NewProto() proto.Message {
+	return &streampb.HistoryEvent{}
+}
+
+// UnmarshalProto fills the request from a message read with the Protobuf codec.
+func (r *CreateHistoryReq) UnmarshalProto(message proto.Message) error {
+	event := message.(*streampb.HistoryEvent)
+	*r = CreateHistoryReq{
+		LeaderboardID: event.GetLeaderboardId(),
+		EntryID:       event.GetEntryId(),
+		Score:         event.GetScore(),
+	}
+	if event.Metadata != nil {
+		r.Metadata = event.Metadata.AsInterface()
+	}
+	if event.OccurredAt != nil {
+		r.OccurredAt = event.OccurredAt.AsTime()
+	}
+	return nil
 }
 
 func (r *CreateHistoryReq) ToModel() *model.History {
 	uid, _ := uuid.NewV6()
 
+	createdAt := r.OccurredAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
 	m := &model.History{
 		LeaderboardID: r.LeaderboardID,
 		EntryID:       r.EntryID,
 		Score:         r.Score,
 		BaseModel: model.BaseModel{
-			CreatedAt: time.Now(),
+			CreatedAt: createdAt,
 			UpdatedAt: time.Now(),
 			ID:        uid.String(),
 		},
@@ -58,10 +118,14 @@ func (r *CreateHistoryReq) ToModel() *model.History {
 
 type ListHistoriesReq struct {
 	PaginationReq
-	LeaderboardID string     `form:"leaderboardId" binding:"required"`
-	EntryID       *string    `form:"entryId"`
-	FromScore     *float64   `form:"fromScore"`
-	ToScore       *float64   `form:"toScore"`
-	FromDate      *time.Time `form:"fromDate"`
-	ToDate        *time.Time `form:"toDate"`
+	LeaderboardID string     `form:"leaderboardId" query:"leaderboardId" binding:"required"`
+	EntryID       *string    `form:"entryId" query:"entryId"`
+	FromScore     *float64   `form:"fromScore" query:"fromScore"`
+	ToScore       *float64   `form:"toScore" query:"toScore"`
+	FromDate      *time.Time `form:"fromDate" query:"fromDate"`
+	ToDate        *time.Time `form:"toDate" query:"toDate"`
+	// MetadataKey filters records whose metadata contains the key; combined with
+	// MetadataValue it matches records where metadata[key] equals the value.
+	MetadataKey   *string `form:"metadataKey" query:"metadataKey"`
+	MetadataValue *string `form:"metadataValue" query:"metadataValue"`
 }
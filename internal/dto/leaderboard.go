@@ -1,59 +1,135 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/datatypes"
 )
 
 type UpdateEntryScore struct {
-	EntryID string  `json:"entryId" binding:"required"`
-	Score   float64 `json:"score" binding:"required"`
+	EntryID  string         `json:"entryId" binding:"required"`
+	Score    float64        `json:"score" binding:"required"`
+	Metadata map[string]any `json:"metadata"`
 }
 
 type CreateLeaderboardReq struct {
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description"`
-	ExpiredAt   time.Time `json:"expiredAt" binding:"required"`
+	Name             string                `json:"name" binding:"required"`
+	Slug             string                `json:"slug"`
+	Description      string                `json:"description"`
+	Type             model.LeaderboardType `json:"type"`
+	UpdatePolicy     model.UpdatePolicy    `json:"updatePolicy"`
+	DecayHalfLifeSec int64                 `json:"decayHalfLifeSec"`
+	RatingSystem     model.RatingSystem    `json:"ratingSystem"`
+	Shards           int                   `json:"shards"`
+	ApproximateRank  bool                  `json:"approximateRank"`
+	ExpiredAt        time.Time             `json:"expiredAt" binding:"required"`
+	NotifyOvertaken  bool                  `json:"notifyOvertaken"`
+	Milestones       []model.MilestoneRule `json:"milestones"`
+	Tags             []string              `json:"tags"`
+	Metadata         map[string]any        `json:"metadata"`
 }
 
 type LeaderboardDto struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	ExpiredAt   time.Time `json:"expiredAt"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	TopEntries  any       `json:"topEntries,omitempty"`
+	ID               string                  `json:"id"`
+	Slug             string                  `json:"slug,omitempty"`
+	Name             string                  `json:"name"`
+	Description      string                  `json:"description"`
+	Type             model.LeaderboardType   `json:"type"`
+	UpdatePolicy     model.UpdatePolicy      `json:"updatePolicy,omitempty"`
+	DecayHalfLifeSec int64                   `json:"decayHalfLifeSec,omitempty"`
+	RatingSystem     model.RatingSystem      `json:"ratingSystem,omitempty"`
+	Shards           int                     `json:"shards,omitempty"`
+	ApproximateRank  bool                    `json:"approximateRank"`
+	Status           model.LeaderboardStatus `json:"status"`
+	ArchivedAt       *time.Time              `json:"archivedAt,omitempty"`
+	ExpiredAt        time.Time               `json:"expiredAt"`
+	NotifyOvertaken  bool                    `json:"notifyOvertaken"`
+	Milestones       []model.MilestoneRule   `json:"milestones,omitempty"`
+	Tags             []string                `json:"tags"`
+	Metadata         map[string]any          `json:"metadata,omitempty"`
+	CreatedAt        time.Time               `json:"createdAt"`
+	UpdatedAt        time.Time               `json:"updatedAt"`
+	TopEntries       any                     `json:"topEntries,omitempty"`
 }
 
 func (d *LeaderboardDto) ToModel() *model.Leaderboard {
 	return &model.Leaderboard{
 		BaseModel: model.BaseModel{
 			ID:        d.ID,
+			Metadata:  metadataToJSON(d.Metadata),
 			CreatedAt: d.CreatedAt,
 			UpdatedAt: d.UpdatedAt,
 		},
-		Name:        d.Name,
-		Description: d.Description,
-		ExpiredAt:   d.ExpiredAt,
+		Slug:             slugPtr(d.Slug),
+		Name:             d.Name,
+		Description:      d.Description,
+		Type:             d.Type,
+		UpdatePolicy:     d.UpdatePolicy,
+		DecayHalfLifeSec: d.DecayHalfLifeSec,
+		RatingSystem:     d.RatingSystem,
+		Shards:           d.Shards,
+		ApproximateRank:  d.ApproximateRank,
+		ExpiredAt:        d.ExpiredAt,
+		NotifyOvertaken:  d.NotifyOvertaken,
+		Milestones:       d.Milestones,
+		Tags:             d.Tags,
 	}
 }
 
 func (d *LeaderboardDto) FromModel(m *model.Leaderboard) {
 	d.ID = m.ID
+	if m.Slug != nil {
+		d.Slug = *m.Slug
+	}
 	d.Name = m.Name
 	d.Description = m.Description
+	d.Type = m.Type
+	d.UpdatePolicy = m.UpdatePolicy
+	d.DecayHalfLifeSec = m.DecayHalfLifeSec
+	d.RatingSystem = m.RatingSystem
+	d.Shards = m.Shards
+	d.ApproximateRank = m.ApproximateRank
+	d.Status = m.Status(time.Now())
+	d.ArchivedAt = m.ArchivedAt
 	d.ExpiredAt = m.ExpiredAt
+	d.NotifyOvertaken = m.NotifyOvertaken
+	d.Milestones = m.Milestones
+	d.Tags = m.Tags
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+	d.Metadata = metadataFromJSON(m.Metadata)
 	d.CreatedAt = m.CreatedAt
 	d.UpdatedAt = m.UpdatedAt
 }
 
+// EntryRankDto is an entry's standing on a leaderboard. Approximate ranks are estimated from a
+// score histogram, so Rank and Percentile may be off by the entries sharing the entry's bucket.
+type EntryRankDto struct {
+	EntryID string  `json:"entryId"`
+	Rank    int64   `json:"rank"`
+	Score   float64 `json:"score"`
+	Total   int64   `json:"total"`
+	// Percentile is the share of the leaderboard at or above the entry, e.g. 2.4 for the top 2.4%.
+	Percentile  float64 `json:"percentile"`
+	Approximate bool    `json:"approximate"`
+}
+
 type UpdateLeaderboardReq struct {
-	ID          string     `json:"id"`
-	Name        *string    `json:"name"`
-	Description *string    `json:"description"`
-	ExpiredAt   *time.Time `json:"expiredAt"`
+	ID              string                 `json:"id"`
+	Name            *string                `json:"name"`
+	Description     *string                `json:"description"`
+	ExpiredAt       *time.Time             `json:"expiredAt"`
+	NotifyOvertaken *bool                  `json:"notifyOvertaken"`
+	Milestones      *[]model.MilestoneRule `json:"milestones"`
+	Archived        *bool                  `json:"archived"`
+	// Tags and Metadata replace the existing values when present.
+	Tags     *[]string       `json:"tags"`
+	Metadata *map[string]any `json:"metadata"`
+	// Slug renames the leaderboard's slug and keeps the previous one as an alias; empty removes it.
+	Slug *string `json:"slug"`
 }
 
 func (r *UpdateLeaderboardReq) ToModel() (u *model.Leaderboard, fields []string) {
@@ -70,5 +146,104 @@ func (r *UpdateLeaderboardReq) ToModel() (u *model.Leaderboard, fields []string)
 		u.ExpiredAt = *r.ExpiredAt
 		fields = append(fields, "expired_at")
 	}
+	if r.NotifyOvertaken != nil {
+		u.NotifyOvertaken = *r.NotifyOvertaken
+		fields = append(fields, "notify_overtaken")
+	}
+	if r.Milestones != nil {
+		u.Milestones = *r.Milestones
+		fields = append(fields, "milestones")
+	}
+	if r.Archived != nil {
+		if *r.Archived {
+			now := time.Now()
+			u.ArchivedAt = &now
+		}
+		fields = append(fields, "archived_at")
+	}
+	if r.Tags != nil {
+		u.Tags = *r.Tags
+		fields = append(fields, "tags")
+	}
+	if r.Metadata != nil {
+		u.Metadata = metadataToJSON(*r.Metadata)
+		fields = append(fields, "metadata")
+	}
 	return u, fields
 }
+
+// LeaderboardRef identifies a leaderboard resolved from its ID, slug or a previous slug.
+type LeaderboardRef struct {
+	ID   string
+	Slug string
+	// IsAlias is set when the leaderboard was resolved from a previous slug.
+	IsAlias bool
+}
+
+// Canonical returns the preferred identifier of the leaderboard.
+func (r *LeaderboardRef) Canonical() string {
+	if r.Slug != "" {
+		return r.Slug
+	}
+	return r.ID
+}
+
+const (
+	// LeaderboardStatusAll disables the status filter when listing leaderboards.
+	LeaderboardStatusAll = "all"
+)
+
+type ListLeaderboardsReq struct {
+	PaginationReq
+	Search *string `query:"search"`
+	// Status is one of active, expired, archived or all; it defaults to active.
+	Status string   `query:"status"`
+	Tags   []string `query:"tags"`
+	// Metadata is a JSON object the leaderboard metadata must contain, e.g. {"mode":"ranked","region":"EU"}.
+	Metadata    *string    `query:"metadata"`
+	CreatedFrom *time.Time `query:"createdFrom"`
+	CreatedTo   *time.Time `query:"createdTo"`
+	// SortBy is one of name, createdAt, updatedAt or expiredAt; it defaults to createdAt.
+	SortBy string `query:"sortBy"`
+	// SortOrder is asc or desc; it defaults to desc.
+	SortOrder string `query:"sortOrder"`
+}
+
+func slugPtr(slug string) *string {
+	if slug == "" {
+		return nil
+	}
+	return &slug
+}
+
+func metadataToJSON(metadata map[string]any) datatypes.JSON {
+	if metadata == nil {
+		return nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil
+	}
+	return datatypes.JSON(data)
+}
+
+func metadataFromJSON(data datatypes.JSON) map[string]any {
+	if len(data) == 0 {
+		return nil
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil
+	}
+	return metadata
+}
+
+type CloneLeaderboardReq struct {
+	// Name, Slug and ExpiredAt are set on the clone. Name defaults to the source's, and ExpiredAt
+	// to now plus the lifetime the source was created with.
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	ExpiredAt time.Time `json:"expiredAt"`
+	// CopyEntries also copies the source's current entries and their scores or ratings.
+	CopyEntries bool `json:"copyEntries"`
+}
@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/dto/streampb"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MilestoneEvent is published when an entry reaches a leaderboard milestone.
+type MilestoneEvent struct {
+	LeaderboardID string              `json:"leaderboardId"`
+	EntryID       string              `json:"entryId"`
+	Milestone     string              `json:"milestone"`
+	Type          model.MilestoneType `json:"type"`
+	Value         float64             `json:"value"`
+	Rank          int64               `json:"rank"`
+	Score         float64             `json:"score"`
+	AchievedAt    time.Time           `json:"achievedAt"`
+}
+
+// MilestoneSchemaVersion is the schema version MilestoneEvent is published to the milestones
+// stream with.
+const MilestoneSchemaVersion = 1
+
+func (MilestoneEvent) SchemaVersion() int {
+	return MilestoneSchemaVersion
+}
+
+// MarshalProto converts the event into the message the Protobuf codec writes to the stream.
+func (e MilestoneEvent) MarshalProto() (proto.Message, error) {
+	return &streampb.MilestoneEvent{
+		LeaderboardId: e.LeaderboardID,
+		EntryId:       e.EntryID,
+		Milestone:     e.Milestone,
+		Type:          string(e.Type),
+		Value:         e.Value,
+		Rank:          e.Rank,
+		Score:         e.Score,
+		AchievedAt:    timestamppb.New(e.AchievedAt),
+	}, nil
+}
+
+func (e *MilestoneEvent) NewProto() proto.Message {
+	return &streampb.MilestoneEvent{}
+}
+
+// UnmarshalProto fills the event from a message read with the Protobuf codec.
+func (e *MilestoneEvent) UnmarshalProto(message proto.Message) error {
+	event := message.(*streampb.MilestoneEvent)
+	*e = MilestoneEvent{
+		LeaderboardID: event.GetLeaderboardId(),
+		EntryID:       event.GetEntryId(),
+		Milestone:     event.GetMilestone(),
+		Type:          model.MilestoneType(event.GetType()),
+		Value:         event.GetValue(),
+		Rank:          event.GetRank(),
+		Score:         event.GetScore(),
+		AchievedAt:    event.GetAchievedAt().AsTime(),
+	}
+	return nil
+}
+
+func (MilestoneEvent) FromModel(m *model.Milestone, rule model.MilestoneRule) MilestoneEvent {
+	return MilestoneEvent{
+		LeaderboardID: m.LeaderboardID,
+		EntryID:       m.EntryID,
+		Milestone:     m.Key,
+		Type:          rule.Type,
+		Value:         rule.Value,
+		Rank:          m.Rank,
+		Score:         m.Score,
+		AchievedAt:    m.CreatedAt,
+	}
+}
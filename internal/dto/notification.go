@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+)
+
+type NotificationPreferenceDto struct {
+	UserID        string    `json:"userId"`
+	LeaderboardID string    `json:"leaderboardId,omitempty"`
+	MuteOvertaken bool      `json:"muteOvertaken"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (NotificationPreferenceDto) FromModel(m *model.NotificationPreference) NotificationPreferenceDto {
+	return NotificationPreferenceDto{
+		UserID:        m.UserID,
+		LeaderboardID: m.LeaderboardID,
+		MuteOvertaken: m.MuteOvertaken,
+		UpdatedAt:     m.UpdatedAt,
+	}
+}
+
+type UpdateNotificationPreferenceReq struct {
+	// LeaderboardID scopes the preference to one leaderboard; empty applies to all leaderboards.
+	LeaderboardID string `json:"leaderboardId"`
+	MuteOvertaken bool   `json:"muteOvertaken"`
+}
+
+func (r *UpdateNotificationPreferenceReq) ToModel(userID string) *model.NotificationPreference {
+	return &model.NotificationPreference{
+		UserID:        userID,
+		LeaderboardID: r.LeaderboardID,
+		MuteOvertaken: r.MuteOvertaken,
+	}
+}
+
+// OvertakenNotification is pushed to an entry that was passed by another entry's score update.
+type OvertakenNotification struct {
+	LeaderboardID string  `json:"leaderboardId"`
+	OvertakenBy   string  `json:"overtakenBy"`
+	Score         float64 `json:"score"`
+	YourScore     float64 `json:"yourScore"`
+	YourRank      int64   `json:"yourRank"`
+}
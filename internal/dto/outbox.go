@@ -0,0 +1,8 @@
+package dto
+
+// OutboxStats reports the stream events waiting in the outbox for monitoring.
+type OutboxStats struct {
+	Depth int64 `json:"depth"`
+	// OldestAgeSeconds is how long the oldest waiting event has waited, 0 when none waits.
+	OldestAgeSeconds float64 `json:"oldestAgeSeconds"`
+}
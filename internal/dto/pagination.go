@@ -1,9 +1,35 @@
 package dto
 
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
 type PaginationReq struct {
-	Page     int     `form:"page" json:"page" binding:"gte=1"`
-	PageSize int     `form:"pageSize" json:"pageSize" binding:"gte=1,lte=100"`
-	Cursor   *string `form:"cursor" json:"cursor"`
+	Page     int     `form:"page" query:"page" json:"page" binding:"gte=1"`
+	PageSize int     `form:"pageSize" query:"pageSize" json:"pageSize" binding:"gte=1,lte=100"`
+	Cursor   *string `form:"cursor" query:"cursor" json:"cursor"`
+}
+
+// Normalize applies defaults to missing or out-of-range pagination values.
+func (p *PaginationReq) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p PaginationReq) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
 }
 
 type PaginationResp[T any] struct {
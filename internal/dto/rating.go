@@ -0,0 +1,42 @@
+package dto
+
+// MatchPlacement is the finishing place (1-based) of an entry in a match; equal places are draws.
+type MatchPlacement struct {
+	EntryID string `json:"entryId"`
+	Place   int    `json:"place"`
+}
+
+type SubmitMatchResultReq struct {
+	// Winner and Loser describe a two-player match; Draw marks it as tied.
+	Winner string `json:"winner"`
+	Loser  string `json:"loser"`
+	Draw   bool   `json:"draw"`
+	// Placements describe a multi-player match and take precedence over Winner and Loser.
+	Placements []MatchPlacement `json:"placements"`
+	Metadata   map[string]any   `json:"metadata"`
+}
+
+// GetPlacements returns the match result as placements, whichever form it was submitted in.
+func (r *SubmitMatchResultReq) GetPlacements() []MatchPlacement {
+	if len(r.Placements) > 0 {
+		return r.Placements
+	}
+
+	loserPlace := 2
+	if r.Draw {
+		loserPlace = 1
+	}
+	return []MatchPlacement{
+		{EntryID: r.Winner, Place: 1},
+		{EntryID: r.Loser, Place: loserPlace},
+	}
+}
+
+type RatingChangeDto struct {
+	EntryID        string  `json:"entryId"`
+	Place          int     `json:"place"`
+	PreviousRating float64 `json:"previousRating"`
+	Rating         float64 `json:"rating"`
+	Delta          float64 `json:"delta"`
+	Deviation      float64 `json:"deviation,omitempty"`
+}
@@ -0,0 +1,280 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: internal/dto/streampb/stream.proto
+
+package streampb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// HistoryEvent is an entry's new score, published to the leaderboard updates stream.
+type HistoryEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaderboardId string                 `protobuf:"bytes,1,opt,name=leaderboard_id,json=leaderboardId,proto3" json:"leaderboard_id,omitempty"`
+	EntryId       string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	Score         float64                `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	Metadata      *structpb.Value        `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryEvent) Reset() {
+	*x = HistoryEvent{}
+	mi := &file_internal_dto_streampb_stream_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEvent) ProtoMessage() {}
+
+func (x *HistoryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_dto_streampb_stream_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEvent.ProtoReflect.Descriptor instead.
+func (*HistoryEvent) Descriptor() ([]byte, []int) {
+	return file_internal_dto_streampb_stream_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HistoryEvent) GetLeaderboardId() string {
+	if x != nil {
+		return x.LeaderboardId
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *HistoryEvent) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *HistoryEvent) GetMetadata() *structpb.Value {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *HistoryEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+// MilestoneEvent is published to the leaderboard milestones stream when an entry reaches a
+// milestone.
+type MilestoneEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaderboardId string                 `protobuf:"bytes,1,opt,name=leaderboard_id,json=leaderboardId,proto3" json:"leaderboard_id,omitempty"`
+	EntryId       string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	Milestone     string                 `protobuf:"bytes,3,opt,name=milestone,proto3" json:"milestone,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Value         float64                `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`
+	Rank          int64                  `protobuf:"varint,6,opt,name=rank,proto3" json:"rank,omitempty"`
+	Score         float64                `protobuf:"fixed64,7,opt,name=score,proto3" json:"score,omitempty"`
+	AchievedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=achieved_at,json=achievedAt,proto3" json:"achieved_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MilestoneEvent) Reset() {
+	*x = MilestoneEvent{}
+	mi := &file_internal_dto_streampb_stream_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MilestoneEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MilestoneEvent) ProtoMessage() {}
+
+func (x *MilestoneEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_dto_streampb_stream_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MilestoneEvent.ProtoReflect.Descriptor instead.
+func (*MilestoneEvent) Descriptor() ([]byte, []int) {
+	return file_internal_dto_streampb_stream_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MilestoneEvent) GetLeaderboardId() string {
+	if x != nil {
+		return x.LeaderboardId
+	}
+	return ""
+}
+
+func (x *MilestoneEvent) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *MilestoneEvent) GetMilestone() string {
+	if x != nil {
+		return x.Milestone
+	}
+	return ""
+}
+
+func (x *MilestoneEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MilestoneEvent) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *MilestoneEvent) GetRank() int64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *MilestoneEvent) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *MilestoneEvent) GetAchievedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AchievedAt
+	}
+	return nil
+}
+
+var File_internal_dto_streampb_stream_proto protoreflect.FileDescriptor
+
+const file_internal_dto_streampb_stream_proto_rawDesc = "" +
+	"\n" +
+	"\"internal/dto/streampb/stream.proto\x12\x14simplerank.stream.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd7\x01\n" +
+	"\fHistoryEvent\x12%\n" +
+	"\x0eleaderboard_id\x18\x01 \x01(\tR\rleaderboardId\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x01R\x05score\x122\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\bmetadata\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"\x81\x02\n" +
+	"\x0eMilestoneEvent\x12%\n" +
+	"\x0eleaderboard_id\x18\x01 \x01(\tR\rleaderboardId\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12\x1c\n" +
+	"\tmilestone\x18\x03 \x01(\tR\tmilestone\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\x01R\x05value\x12\x12\n" +
+	"\x04rank\x18\x06 \x01(\x03R\x04rank\x12\x14\n" +
+	"\x05score\x18\a \x01(\x01R\x05score\x12;\n" +
+	"\vachieved_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"achievedAtB:Z8github.com/hiamthach108/simplerank/internal/dto/streampbb\x06proto3"
+
+var (
+	file_internal_dto_streampb_stream_proto_rawDescOnce sync.Once
+	file_internal_dto_streampb_stream_proto_rawDescData []byte
+)
+
+func file_internal_dto_streampb_stream_proto_rawDescGZIP() []byte {
+	file_internal_dto_streampb_stream_proto_rawDescOnce.Do(func() {
+		file_internal_dto_streampb_stream_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_dto_streampb_stream_proto_rawDesc), len(file_internal_dto_streampb_stream_proto_rawDesc)))
+	})
+	return file_internal_dto_streampb_stream_proto_rawDescData
+}
+
+var file_internal_dto_streampb_stream_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_internal_dto_streampb_stream_proto_goTypes = []any{
+	(*HistoryEvent)(nil),          // 0: simplerank.stream.v1.HistoryEvent
+	(*MilestoneEvent)(nil),        // 1: simplerank.stream.v1.MilestoneEvent
+	(*structpb.Value)(nil),        // 2: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_internal_dto_streampb_stream_proto_depIdxs = []int32{
+	2, // 0: simplerank.stream.v1.HistoryEvent.metadata:type_name -> google.protobuf.Value
+	3, // 1: simplerank.stream.v1.HistoryEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	3, // 2: simplerank.stream.v1.MilestoneEvent.achieved_at:type_name -> google.protobuf.Timestamp
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_internal_dto_streampb_stream_proto_init() }
+func file_internal_dto_streampb_stream_proto_init() {
+	if File_internal_dto_streampb_stream_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_dto_streampb_stream_proto_rawDesc), len(file_internal_dto_streampb_stream_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_dto_streampb_stream_proto_goTypes,
+		DependencyIndexes: file_internal_dto_streampb_stream_proto_depIdxs,
+		MessageInfos:      file_internal_dto_streampb_stream_proto_msgTypes,
+	}.Build()
+	File_internal_dto_streampb_stream_proto = out.File
+	file_internal_dto_streampb_stream_proto_goTypes = nil
+	file_internal_dto_streampb_stream_proto_depIdxs = nil
+}
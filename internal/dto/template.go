@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+)
+
+type CreateTemplateReq struct {
+	Name             string                `json:"name" binding:"required"`
+	NamePattern      string                `json:"namePattern" binding:"required"`
+	Description      string                `json:"description"`
+	Type             model.LeaderboardType `json:"type"`
+	UpdatePolicy     model.UpdatePolicy    `json:"updatePolicy"`
+	DecayHalfLifeSec int64                 `json:"decayHalfLifeSec"`
+	RatingSystem     model.RatingSystem    `json:"ratingSystem"`
+	NotifyOvertaken  bool                  `json:"notifyOvertaken"`
+	Milestones       []model.MilestoneRule `json:"milestones"`
+	Tags             []string              `json:"tags"`
+	Metadata         map[string]any        `json:"metadata"`
+}
+
+type TemplateDto struct {
+	ID               string                `json:"id"`
+	Name             string                `json:"name"`
+	NamePattern      string                `json:"namePattern"`
+	Description      string                `json:"description"`
+	Type             model.LeaderboardType `json:"type"`
+	UpdatePolicy     model.UpdatePolicy    `json:"updatePolicy,omitempty"`
+	DecayHalfLifeSec int64                 `json:"decayHalfLifeSec,omitempty"`
+	RatingSystem     model.RatingSystem    `json:"ratingSystem,omitempty"`
+	NotifyOvertaken  bool                  `json:"notifyOvertaken"`
+	Milestones       []model.MilestoneRule `json:"milestones,omitempty"`
+	Tags             []string              `json:"tags"`
+	Metadata         map[string]any        `json:"metadata,omitempty"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        time.Time             `json:"updatedAt"`
+}
+
+func (d *TemplateDto) FromModel(m *model.LeaderboardTemplate) {
+	d.ID = m.ID
+	d.Name = m.Name
+	d.NamePattern = m.NamePattern
+	d.Description = m.Description
+	d.Type = m.Type
+	d.UpdatePolicy = m.UpdatePolicy
+	d.DecayHalfLifeSec = m.DecayHalfLifeSec
+	d.RatingSystem = m.RatingSystem
+	d.NotifyOvertaken = m.NotifyOvertaken
+	d.Milestones = m.Milestones
+	d.Tags = m.Tags
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+	d.Metadata = metadataFromJSON(m.Metadata)
+	d.CreatedAt = m.CreatedAt
+	d.UpdatedAt = m.UpdatedAt
+}
+
+// TemplateInstance describes one leaderboard to create from a template.
+type TemplateInstance struct {
+	// Variables fill the {key} placeholders of the template's name pattern.
+	Variables map[string]string `json:"variables"`
+	Slug      string            `json:"slug"`
+	ExpiredAt time.Time         `json:"expiredAt" binding:"required"`
+}
+
+type InstantiateTemplateReq struct {
+	Leaderboards []TemplateInstance `json:"leaderboards" binding:"required"`
+}
+
+type ListTemplatesReq struct {
+	PaginationReq
+	Search *string `query:"search"`
+}
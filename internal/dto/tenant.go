@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+)
+
+// TenantDto describes a tenant with its effective quotas; a quota of 0 means unlimited.
+type TenantDto struct {
+	ID                       string `json:"id"`
+	Name                     string `json:"name,omitempty"`
+	MaxLeaderboards          int    `json:"maxLeaderboards"`
+	MaxEntriesPerLeaderboard int    `json:"maxEntriesPerLeaderboard"`
+	MaxSubmissionsPerSec     int    `json:"maxSubmissionsPerSec"`
+}
+
+// UpdateTenantReq overrides the tenant's name and quotas; absent fields keep their current value.
+type UpdateTenantReq struct {
+	Name                     *string `json:"name"`
+	MaxLeaderboards          *int    `json:"maxLeaderboards"`
+	MaxEntriesPerLeaderboard *int    `json:"maxEntriesPerLeaderboard"`
+	MaxSubmissionsPerSec     *int    `json:"maxSubmissionsPerSec"`
+}
+
+func (r *UpdateTenantReq) Apply(m *model.Tenant) {
+	if r.Name != nil {
+		m.Name = *r.Name
+	}
+	if r.MaxLeaderboards != nil {
+		m.MaxLeaderboards = r.MaxLeaderboards
+	}
+	if r.MaxEntriesPerLeaderboard != nil {
+		m.MaxEntriesPerLeaderboard = r.MaxEntriesPerLeaderboard
+	}
+	if r.MaxSubmissionsPerSec != nil {
+		m.MaxSubmissionsPerSec = r.MaxSubmissionsPerSec
+	}
+}
+
+// TenantAPIKeyDto describes an API key of a tenant. Key is only returned when the key is issued.
+type TenantAPIKeyDto struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenantId"`
+	Key       string    `json:"key,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,15 +1,118 @@
 package model
 
-import "time"
+import (
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type LeaderboardType string
+
+const (
+	// LeaderboardTypeStandard applies submitted scores by the leaderboard's update policy.
+	LeaderboardTypeStandard LeaderboardType = "standard"
+	// LeaderboardTypeTrending accumulates submitted points that decay exponentially over time.
+	LeaderboardTypeTrending LeaderboardType = "trending"
+	// LeaderboardTypeRating ranks entries by a rating computed from submitted match results.
+	LeaderboardTypeRating LeaderboardType = "rating"
+)
+
+// UpdatePolicy decides how a submitted score changes an entry's score on a standard leaderboard.
+type UpdatePolicy string
+
+const (
+	// UpdatePolicyLatest replaces the entry's score with the submitted one.
+	UpdatePolicyLatest UpdatePolicy = "latest"
+	// UpdatePolicyBest keeps the highest score submitted for the entry.
+	UpdatePolicyBest UpdatePolicy = "best"
+	// UpdatePolicyIncrement adds the submitted score to the entry's score.
+	UpdatePolicyIncrement UpdatePolicy = "increment"
+)
+
+type LeaderboardStatus string
+
+const (
+	LeaderboardStatusActive   LeaderboardStatus = "active"
+	LeaderboardStatusExpired  LeaderboardStatus = "expired"
+	LeaderboardStatusArchived LeaderboardStatus = "archived"
+)
 
 type Leaderboard struct {
 	BaseModel
-	Name        string    `gorm:"type:varchar(255);not null"`
-	Description string    `gorm:"type:text"`
-	ExpiredAt   time.Time `gorm:"not null;index"`
-	IsAscending bool      `gorm:"not null;default:false"`
+	TenantID    string                      `gorm:"type:varchar(36);not null;default:'default';index;uniqueIndex:idx_leaderboards_tenant_slug,priority:1"`
+	Name        string                      `gorm:"type:varchar(255);not null"`
+	Slug        *string                     `gorm:"type:varchar(128);uniqueIndex:idx_leaderboards_tenant_slug,priority:2"`
+	Description string                      `gorm:"type:text"`
+	Type        LeaderboardType             `gorm:"type:varchar(32);not null;default:'standard'"`
+	ExpiredAt   time.Time                   `gorm:"not null;index"`
+	IsAscending bool                        `gorm:"not null;default:false"`
+	ArchivedAt  *time.Time                  `gorm:"index"`
+	Tags        datatypes.JSONSlice[string] `gorm:"type:jsonb;index:idx_leaderboards_tags,type:gin"`
+	// NotifyOvertaken opts the leaderboard into "you were overtaken" notifications.
+	NotifyOvertaken bool                               `gorm:"not null;default:false"`
+	Milestones      datatypes.JSONSlice[MilestoneRule] `gorm:"type:jsonb"`
+	// UpdatePolicy applies submitted scores on standard leaderboards.
+	UpdatePolicy UpdatePolicy `gorm:"type:varchar(16);not null;default:'latest'"`
+	// DecayHalfLifeSec is the half-life of submitted points on trending leaderboards.
+	DecayHalfLifeSec int64 `gorm:"not null;default:0"`
+	// DecayEpoch seeds the reference time stored scores of trending leaderboards are normalized
+	// against. Rebases move the current epoch, which is kept next to the scores in the cache.
+	DecayEpoch time.Time
+	// RatingSystem is the algorithm used to rate entries on rating leaderboards.
+	RatingSystem RatingSystem `gorm:"type:varchar(16)"`
+	// Shards is the number of sorted sets the entries are hash-partitioned over; 0 and 1 keep
+	// them in a single sorted set.
+	Shards int `gorm:"not null;default:0"`
+	// ApproximateRank ranks entries from a score histogram instead of exactly.
+	ApproximateRank bool `gorm:"not null;default:false"`
+	// Version is incremented on every change so stale cached copies never overwrite newer ones.
+	Version int64 `gorm:"not null;default:1"`
 }
 
 func (Leaderboard) TableName() string {
 	return "leaderboards"
 }
+
+// LeaderboardSlugAlias keeps a previous slug of a leaderboard resolvable after a rename.
+type LeaderboardSlugAlias struct {
+	BaseModel
+	TenantID      string `gorm:"type:varchar(36);not null;default:'default';uniqueIndex:idx_leaderboard_slug_aliases_tenant_slug,priority:1"`
+	Slug          string `gorm:"type:varchar(128);not null;uniqueIndex:idx_leaderboard_slug_aliases_tenant_slug,priority:2"`
+	LeaderboardID string `gorm:"type:varchar(36);not null;index"`
+}
+
+func (LeaderboardSlugAlias) TableName() string {
+	return "leaderboard_slug_aliases"
+}
+
+// Status derives the lifecycle status of the leaderboard at the given time.
+func (l *Leaderboard) Status(at time.Time) LeaderboardStatus {
+	if l.ArchivedAt != nil {
+		return LeaderboardStatusArchived
+	}
+	if !l.ExpiredAt.After(at) {
+		return LeaderboardStatusExpired
+	}
+	return LeaderboardStatusActive
+}
+
+// IsDecaying reports whether scores on the leaderboard decay over time.
+func (l *Leaderboard) IsDecaying() bool {
+	return l.Type == LeaderboardTypeTrending && l.DecayHalfLifeSec > 0
+}
+
+// DecayExponent returns the number of half-lives elapsed between the decay epoch and at.
+func (l *Leaderboard) DecayExponent(at time.Time) float64 {
+	if !l.IsDecaying() {
+		return 0
+	}
+	return at.Sub(l.DecayEpoch).Seconds() / float64(l.DecayHalfLifeSec)
+}
+
+// DecayGrowth returns the factor converting current points at the given time into stored units.
+// Stored scores are current scores multiplied by this factor, so every member shares the same
+// scale and sorted set ordering stays correct without rewriting members as time passes.
+func (l *Leaderboard) DecayGrowth(at time.Time) float64 {
+	return math.Exp2(l.DecayExponent(at))
+}
@@ -0,0 +1,59 @@
+package model
+
+import "fmt"
+
+type MilestoneType string
+
+const (
+	// MilestoneTypeRank fires when an entry enters the top N (a value of 1 means becoming #1).
+	MilestoneTypeRank MilestoneType = "rank"
+	// MilestoneTypeScore fires when an entry's score reaches the threshold.
+	MilestoneTypeScore MilestoneType = "score"
+)
+
+// MilestoneRule is a milestone configured on a leaderboard.
+type MilestoneRule struct {
+	Type  MilestoneType `json:"type"`
+	Value float64       `json:"value"`
+}
+
+// Key uniquely identifies the rule within a leaderboard.
+func (r MilestoneRule) Key() string {
+	return fmt.Sprintf("%s:%g", r.Type, r.Value)
+}
+
+// Reached reports whether an entry with the given 1-based rank and score satisfies the rule.
+func (r MilestoneRule) Reached(rank int64, score float64) bool {
+	switch r.Type {
+	case MilestoneTypeRank:
+		return rank > 0 && rank <= int64(r.Value)
+	case MilestoneTypeScore:
+		return score >= r.Value
+	}
+	return false
+}
+
+// Valid reports whether the rule has a known type and a usable value.
+func (r MilestoneRule) Valid() bool {
+	switch r.Type {
+	case MilestoneTypeRank:
+		return r.Value >= 1 && r.Value == float64(int64(r.Value))
+	case MilestoneTypeScore:
+		return true
+	}
+	return false
+}
+
+// Milestone records that an entry reached a milestone, so each one fires only once per entry.
+type Milestone struct {
+	BaseModel
+	LeaderboardID string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_milestones_entry_key"`
+	EntryID       string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_milestones_entry_key"`
+	Key           string  `gorm:"type:varchar(64);not null;uniqueIndex:idx_milestones_entry_key"`
+	Rank          int64   `gorm:"not null"`
+	Score         float64 `gorm:"type:double precision"`
+}
+
+func (Milestone) TableName() string {
+	return "milestones"
+}
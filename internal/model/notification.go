@@ -0,0 +1,14 @@
+package model
+
+type NotificationPreference struct {
+	BaseModel
+	TenantID string `gorm:"type:varchar(36);not null;default:'default';uniqueIndex:idx_notification_preferences_tenant_user_leaderboard"`
+	UserID   string `gorm:"type:varchar(36);not null;uniqueIndex:idx_notification_preferences_tenant_user_leaderboard"`
+	// LeaderboardID scopes the preference to one leaderboard; empty applies to all leaderboards.
+	LeaderboardID string `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_notification_preferences_tenant_user_leaderboard"`
+	MuteOvertaken bool   `gorm:"not null;default:false"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent is a stream event waiting for the outbox relay to publish it. Events are relayed in
+// Seq order, once no event with a lower Seq can commit anymore.
+type OutboxEvent struct {
+	Seq       uint64         `gorm:"primaryKey;autoIncrement"`
+	Stream    string         `gorm:"type:varchar(255);not null"`
+	Payload   datatypes.JSON `gorm:"type:jsonb;not null"`
+	Attempts  int            `gorm:"not null;default:0"`
+	LastError string         `gorm:"type:text"`
+	CreatedAt time.Time      `gorm:"autoCreateTime;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxFence holds the highest fencing token of a leadership that relayed the outbox, so a
+// leader that lost its leadership without noticing cannot relay it anymore.
+type OutboxFence struct {
+	Name  string `gorm:"primaryKey;type:varchar(64)"`
+	Token int64  `gorm:"not null"`
+}
+
+func (OutboxFence) TableName() string {
+	return "outbox_fences"
+}
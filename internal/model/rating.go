@@ -0,0 +1,23 @@
+package model
+
+type RatingSystem string
+
+const (
+	RatingSystemElo     RatingSystem = "elo"
+	RatingSystemGlicko2 RatingSystem = "glicko2"
+)
+
+// EntryRating is the rating state of an entry on a rating leaderboard.
+type EntryRating struct {
+	BaseModel
+	LeaderboardID string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_entry_ratings_entry"`
+	EntryID       string  `gorm:"type:varchar(36);not null;uniqueIndex:idx_entry_ratings_entry"`
+	Rating        float64 `gorm:"type:double precision;not null"`
+	Deviation     float64 `gorm:"type:double precision;not null"`
+	Volatility    float64 `gorm:"type:double precision;not null"`
+	Matches       int     `gorm:"not null;default:0"`
+}
+
+func (EntryRating) TableName() string {
+	return "entry_ratings"
+}
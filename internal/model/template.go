@@ -0,0 +1,26 @@
+package model
+
+import "gorm.io/datatypes"
+
+// LeaderboardTemplate captures the configuration shared by recurring leaderboards, such as the
+// boards recreated every season. Its metadata is copied to every instantiated leaderboard.
+type LeaderboardTemplate struct {
+	BaseModel
+	TenantID string `gorm:"type:varchar(36);not null;default:'default';uniqueIndex:idx_leaderboard_templates_tenant_name,priority:1"`
+	Name     string `gorm:"type:varchar(255);not null;uniqueIndex:idx_leaderboard_templates_tenant_name,priority:2"`
+	// NamePattern builds the names of instantiated leaderboards; {key} placeholders are replaced
+	// with the variables given at instantiation.
+	NamePattern      string                             `gorm:"type:varchar(255);not null"`
+	Description      string                             `gorm:"type:text"`
+	Type             LeaderboardType                    `gorm:"type:varchar(32);not null;default:'standard'"`
+	UpdatePolicy     UpdatePolicy                       `gorm:"type:varchar(16);not null;default:'latest'"`
+	DecayHalfLifeSec int64                              `gorm:"not null;default:0"`
+	RatingSystem     RatingSystem                       `gorm:"type:varchar(16)"`
+	NotifyOvertaken  bool                               `gorm:"not null;default:false"`
+	Milestones       datatypes.JSONSlice[MilestoneRule] `gorm:"type:jsonb"`
+	Tags             datatypes.JSONSlice[string]        `gorm:"type:jsonb"`
+}
+
+func (LeaderboardTemplate) TableName() string {
+	return "leaderboard_templates"
+}
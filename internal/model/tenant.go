@@ -0,0 +1,26 @@
+package model
+
+// Tenant is a studio or project sharing the deployment. Its ID is the tenant ID callers send;
+// nil quotas fall back to the configured defaults and a quota of 0 means unlimited.
+type Tenant struct {
+	BaseModel
+	Name                     string `gorm:"type:varchar(255)"`
+	MaxLeaderboards          *int
+	MaxEntriesPerLeaderboard *int
+	MaxSubmissionsPerSec     *int
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// TenantAPIKey authenticates callers as a tenant. Only the SHA-256 hash of the key is stored.
+type TenantAPIKey struct {
+	BaseModel
+	TenantID string `gorm:"type:varchar(36);not null;index"`
+	KeyHash  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+}
+
+func (TenantAPIKey) TableName() string {
+	return "tenant_api_keys"
+}
@@ -2,14 +2,25 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"gorm.io/gorm"
 )
 
+// ErrQuotaExceeded is returned when a write would exceed the limit it was given.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// lockScope holds a transaction-scoped lock on a named scope, serializing the transactions that
+// check a limit of the scope until they end, whether or not any row of it exists yet.
+func lockScope(tx *gorm.DB, scope string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", scope).Error
+}
+
 type IRepository[T any] interface {
 	FindAll(ctx context.Context) ([]T, error)
 	FindOneById(ctx context.Context, id string) *T
 	FindByIds(ctx context.Context, ids []string) ([]T, error)
+	FindPage(ctx context.Context, q PageQuery) ([]T, int64, error)
 	Create(ctx context.Context, model *T) (*T, error)
 	BulkCreate(ctx context.Context, inputs []T) error
 	Update(ctx context.Context, id string, value T, field ...string) error
@@ -27,5 +27,43 @@ func NewHistoryRepository(dbClient *ch.DB) IHistoryRepository {
 
 // GetList retrieves a list of history records based on the provided request filters.
 func (r *HistoryRepository) GetList(ctx context.Context, req dto.ListHistoriesReq) ([]model.History, int64, error) {
-	return []model.History{}, 0, nil
+	qb := r.NewQueryBuilder(ctx).Where("leaderboard_id = ?", req.LeaderboardID)
+
+	if req.EntryID != nil {
+		qb.Where("entry_id = ?", *req.EntryID)
+	}
+	if req.FromScore != nil {
+		qb.Where("score >= ?", *req.FromScore)
+	}
+	if req.ToScore != nil {
+		qb.Where("score <= ?", *req.ToScore)
+	}
+	if req.FromDate != nil {
+		qb.Where("created_at >= ?", *req.FromDate)
+	}
+	if req.ToDate != nil {
+		qb.Where("created_at <= ?", *req.ToDate)
+	}
+	if req.MetadataKey != nil {
+		if req.MetadataValue != nil {
+			qb.Where("JSONExtractString(metadata, ?) = ?", *req.MetadataKey, *req.MetadataValue)
+		} else {
+			qb.Where("JSONHas(metadata, ?)", *req.MetadataKey)
+		}
+	}
+
+	total, err := qb.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	histories, err := qb.OrderBy("created_at DESC").
+		Limit(req.PageSize).
+		Offset(req.Offset()).
+		Execute(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return histories, total, nil
 }
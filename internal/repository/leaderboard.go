@@ -1,12 +1,31 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
 	"github.com/hiamthach108/simplerank/internal/model"
 	"gorm.io/gorm"
 )
 
+// leaderboardSortColumns maps the sortable fields of the list API to their columns.
+var leaderboardSortColumns = map[string]string{
+	"name":      "name",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"expiredAt": "expired_at",
+}
+
 type ILeaderboardRepository interface {
 	IRepository[model.Leaderboard]
+	GetList(ctx context.Context, tenantID string, req dto.ListLeaderboardsReq) ([]model.Leaderboard, int64, error)
+	CreateAll(ctx context.Context, tenantID string, leaderboards []model.Leaderboard, limit int) error
+	FindOneBySlug(ctx context.Context, tenantID string, slug string) *model.Leaderboard
+	FindSlugAlias(ctx context.Context, tenantID string, slug string) *model.LeaderboardSlugAlias
+	ChangeSlug(ctx context.Context, tenantID string, id string, oldSlug *string, newSlug *string) error
 }
 
 type leaderboardRepository struct {
@@ -18,3 +37,126 @@ func NewLeaderboardRepository(dbClient *gorm.DB) ILeaderboardRepository {
 		Repository: Repository[model.Leaderboard]{dbClient: dbClient},
 	}
 }
+
+// Update saves the selected fields of a leaderboard and bumps its version.
+func (r *leaderboardRepository) Update(ctx context.Context, id string, value model.Leaderboard, field ...string) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&value).Where("id = ?", id).Select(field).Omit("version").Updates(value).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Leaderboard{}).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1")).Error
+	})
+}
+
+// GetList retrieves a page of a tenant's leaderboards matching the provided request filters.
+func (r *leaderboardRepository) GetList(ctx context.Context, tenantID string, req dto.ListLeaderboardsReq) ([]model.Leaderboard, int64, error) {
+	scopes := []Scope{where("tenant_id = ?", tenantID)}
+
+	now := time.Now()
+	switch model.LeaderboardStatus(req.Status) {
+	case model.LeaderboardStatusActive:
+		scopes = append(scopes, where("archived_at IS NULL AND expired_at > ?", now))
+	case model.LeaderboardStatusExpired:
+		scopes = append(scopes, where("archived_at IS NULL AND expired_at <= ?", now))
+	case model.LeaderboardStatusArchived:
+		scopes = append(scopes, where("archived_at IS NOT NULL"))
+	}
+
+	if req.Search != nil && *req.Search != "" {
+		scopes = append(scopes, search(*req.Search, "name", "description"))
+	}
+	if len(req.Tags) > 0 {
+		tags, err := json.Marshal(req.Tags)
+		if err != nil {
+			return nil, 0, err
+		}
+		scopes = append(scopes, where("tags @> ?::jsonb", string(tags)))
+	}
+	if req.Metadata != nil {
+		scopes = append(scopes, where("metadata @> ?::jsonb", *req.Metadata))
+	}
+	scopes = append(scopes, between("created_at", req.CreatedFrom, req.CreatedTo))
+
+	column, ok := leaderboardSortColumns[req.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := "DESC"
+	if strings.EqualFold(req.SortOrder, "asc") {
+		direction = "ASC"
+	}
+
+	return r.FindPage(ctx, PageQuery{
+		Scopes: scopes,
+		// Tie-break on id so pages stay stable when sort values repeat
+		Order:  column + " " + direction + ", id " + direction,
+		Offset: req.Offset(),
+		Limit:  req.PageSize,
+	})
+}
+
+// CreateAll creates leaderboards of a tenant in one transaction, failing with ErrQuotaExceeded
+// when the tenant would then own more than limit leaderboards; 0 means unlimited. Creations of a
+// tenant are serialized while the limit is checked, so concurrent ones cannot overshoot it.
+func (r *leaderboardRepository) CreateAll(ctx context.Context, tenantID string, leaderboards []model.Leaderboard, limit int) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if limit > 0 {
+			if err := lockScope(tx, "leaderboards:"+tenantID); err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&model.Leaderboard{}).Where("tenant_id = ?", tenantID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count+int64(len(leaderboards)) > int64(limit) {
+				return ErrQuotaExceeded
+			}
+		}
+		return tx.Create(&leaderboards).Error
+	})
+}
+
+// FindOneBySlug retrieves the tenant's leaderboard currently using the slug.
+func (r *leaderboardRepository) FindOneBySlug(ctx context.Context, tenantID string, slug string) *model.Leaderboard {
+	var result model.Leaderboard
+	if err := r.dbClient.WithContext(ctx).First(&result, "tenant_id = ? AND slug = ?", tenantID, slug).Error; err != nil {
+		return nil
+	}
+	return &result
+}
+
+// FindSlugAlias retrieves the alias left behind when a leaderboard stopped using the slug.
+func (r *leaderboardRepository) FindSlugAlias(ctx context.Context, tenantID string, slug string) *model.LeaderboardSlugAlias {
+	var result model.LeaderboardSlugAlias
+	if err := r.dbClient.WithContext(ctx).First(&result, "tenant_id = ? AND slug = ?", tenantID, slug).Error; err != nil {
+		return nil
+	}
+	return &result
+}
+
+// ChangeSlug replaces the slug of a leaderboard and keeps the old slug as an alias of it.
+func (r *leaderboardRepository) ChangeSlug(ctx context.Context, tenantID string, id string, oldSlug *string, newSlug *string) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if newSlug != nil {
+			// Taking back a previous slug turns its alias into the current slug again
+			err := tx.Unscoped().
+				Where("slug = ? AND leaderboard_id = ?", *newSlug, id).
+				Delete(&model.LeaderboardSlugAlias{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&model.Leaderboard{}).
+			Where("id = ?", id).
+			Updates(map[string]any{"slug": newSlug, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+
+		if oldSlug == nil {
+			return nil
+		}
+		return tx.Create(&model.LeaderboardSlugAlias{TenantID: tenantID, Slug: *oldSlug, LeaderboardID: id}).Error
+	})
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IMilestoneRepository interface {
+	IRepository[model.Milestone]
+	CreateIfNotExists(ctx context.Context, milestone *model.Milestone) (bool, error)
+}
+
+type milestoneRepository struct {
+	Repository[model.Milestone]
+}
+
+func NewMilestoneRepository(dbClient *gorm.DB) IMilestoneRepository {
+	return &milestoneRepository{
+		Repository: Repository[model.Milestone]{dbClient: dbClient},
+	}
+}
+
+// CreateIfNotExists records the milestone and reports whether it was newly created.
+func (r *milestoneRepository) CreateIfNotExists(ctx context.Context, milestone *model.Milestone) (bool, error) {
+	result := r.dbClient.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(milestone)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
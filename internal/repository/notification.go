@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type INotificationPreferenceRepository interface {
+	IRepository[model.NotificationPreference]
+	Upsert(ctx context.Context, pref *model.NotificationPreference) (*model.NotificationPreference, error)
+	FindByUser(ctx context.Context, tenantID string, userID string) ([]model.NotificationPreference, error)
+	FindMutedUserIDs(ctx context.Context, tenantID string, leaderboardID string, userIDs []string) ([]string, error)
+}
+
+type notificationPreferenceRepository struct {
+	Repository[model.NotificationPreference]
+}
+
+func NewNotificationPreferenceRepository(dbClient *gorm.DB) INotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		Repository: Repository[model.NotificationPreference]{dbClient: dbClient},
+	}
+}
+
+// Upsert creates the preference or updates the existing one for the same tenant, user and leaderboard.
+func (r *notificationPreferenceRepository) Upsert(ctx context.Context, pref *model.NotificationPreference) (*model.NotificationPreference, error) {
+	err := r.dbClient.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}, {Name: "leaderboard_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mute_overtaken", "updated_at"}),
+	}).Create(pref).Error
+	if err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+// FindByUser retrieves every preference stored for a user of a tenant.
+func (r *notificationPreferenceRepository) FindByUser(ctx context.Context, tenantID string, userID string) ([]model.NotificationPreference, error) {
+	var results []model.NotificationPreference
+	if err := r.dbClient.WithContext(ctx).Find(&results, "tenant_id = ? AND user_id = ?", tenantID, userID).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// FindMutedUserIDs returns the subset of userIDs that muted overtaken notifications
+// for the given leaderboard, either specifically or globally.
+func (r *notificationPreferenceRepository) FindMutedUserIDs(ctx context.Context, tenantID string, leaderboardID string, userIDs []string) ([]string, error) {
+	var muted []string
+	if len(userIDs) == 0 {
+		return muted, nil
+	}
+
+	err := r.dbClient.WithContext(ctx).
+		Model(&model.NotificationPreference{}).
+		Where("tenant_id = ? AND user_id IN (?) AND mute_overtaken = ?", tenantID, userIDs, true).
+		Where("leaderboard_id = ? OR leaderboard_id = ''", leaderboardID).
+		Distinct().
+		Pluck("user_id", &muted).Error
+	if err != nil {
+		return nil, err
+	}
+	return muted, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IOutboxRepository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) error
+	RunFenced(ctx context.Context, token int64, fn func(tx IOutboxRepository) error) error
+	Horizon(ctx context.Context) (OutboxHorizon, error)
+	Settled(ctx context.Context, horizon OutboxHorizon) (bool, error)
+	FindOldest(ctx context.Context, maxSeq uint64, limit int) ([]model.OutboxEvent, error)
+	DeleteBySeqs(ctx context.Context, seqs []uint64) error
+	MarkFailed(ctx context.Context, seq uint64, reason string) error
+	Stats(ctx context.Context) (count int64, oldest *time.Time, err error)
+}
+
+// ErrFenced is returned when relaying the outbox with the fencing token of a leadership older
+// than one that already relayed it.
+var ErrFenced = errors.New("fencing token is stale")
+
+// outboxRelayFence names the fence of the outbox relay.
+const outboxRelayFence = "relay"
+
+// OutboxHorizon bounds the events added to the outbox up to some point: their Seq is at most
+// Seq, and the transactions adding them got their IDs below XMax.
+type OutboxHorizon struct {
+	Seq  uint64
+	XMax uint64
+}
+
+type outboxRepository struct {
+	dbClient *gorm.DB
+}
+
+func NewOutboxRepository(dbClient *gorm.DB) IOutboxRepository {
+	return &outboxRepository{dbClient: dbClient}
+}
+
+// Create adds an event to the outbox. Its transaction gets an ID before the event gets a Seq,
+// which Horizon relies on.
+func (r *outboxRepository) Create(ctx context.Context, event *model.OutboxEvent) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_current_xact_id()").Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// Horizon returns the horizon of the events added so far. Every transaction holding a Seq up
+// to the horizon's got its ID before taking it, so below the horizon's XMax.
+func (r *outboxRepository) Horizon(ctx context.Context) (OutboxHorizon, error) {
+	var horizon OutboxHorizon
+	db := r.dbClient.WithContext(ctx)
+	// The sequence is read before the snapshot is taken, so a transaction taking a Seq in between
+	// is still below XMax
+	err := db.Raw("SELECT COALESCE(pg_sequence_last_value(pg_get_serial_sequence('outbox_events', 'seq')), 0)").
+		Scan(&horizon.Seq).Error
+	if err != nil {
+		return OutboxHorizon{}, err
+	}
+	err = db.Raw("SELECT pg_snapshot_xmax(pg_current_snapshot())::text::bigint").Scan(&horizon.XMax).Error
+	if err != nil {
+		return OutboxHorizon{}, err
+	}
+	return horizon, nil
+}
+
+// Settled reports whether every transaction that could add an event up to the horizon ended,
+// so no event up to its Seq can commit anymore.
+func (r *outboxRepository) Settled(ctx context.Context, horizon OutboxHorizon) (bool, error) {
+	var settled bool
+	err := r.dbClient.WithContext(ctx).
+		Raw("SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint >= ?", horizon.XMax).
+		Scan(&settled).Error
+	return settled, err
+}
+
+// RunFenced runs fn with a repository bound to a transaction holding the relay fence with the
+// fencing token of the caller's leadership, so relays run one at a time and events keep their
+// order. It fails with ErrFenced without running fn when a later leadership relayed the outbox.
+func (r *outboxRepository) RunFenced(ctx context.Context, token int64, fn func(tx IOutboxRepository) error) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row stays locked until the transaction ends, and is only written by tokens at least
+		// as large as the one it holds
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"token"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "outbox_fences.token <= excluded.token"},
+			}},
+		}).Create(&model.OutboxFence{Name: outboxRelayFence, Token: token})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFenced
+		}
+		return fn(NewOutboxRepository(tx))
+	})
+}
+
+// FindOldest retrieves up to limit events with a Seq up to maxSeq, in Seq order.
+func (r *outboxRepository) FindOldest(ctx context.Context, maxSeq uint64, limit int) ([]model.OutboxEvent, error) {
+	var results []model.OutboxEvent
+	err := r.dbClient.WithContext(ctx).Where("seq <= ?", maxSeq).Order("seq").Limit(limit).Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *outboxRepository) DeleteBySeqs(ctx context.Context, seqs []uint64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	return r.dbClient.WithContext(ctx).Delete(&model.OutboxEvent{}, "seq IN (?)", seqs).Error
+}
+
+// MarkFailed counts a failed attempt to relay an event.
+func (r *outboxRepository) MarkFailed(ctx context.Context, seq uint64, reason string) error {
+	return r.dbClient.WithContext(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("seq = ?", seq).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": reason,
+		}).Error
+}
+
+// Stats returns the number of events waiting and when the oldest of them was added, nil when
+// there is none.
+func (r *outboxRepository) Stats(ctx context.Context) (count int64, oldest *time.Time, err error) {
+	var result struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err = r.dbClient.WithContext(ctx).
+		Model(&model.OutboxEvent{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Scan(&result).Error
+	if err != nil {
+		return 0, nil, err
+	}
+	return result.Count, result.Oldest, nil
+}
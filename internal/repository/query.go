@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scope narrows a query, typically with a WHERE clause.
+type Scope func(db *gorm.DB) *gorm.DB
+
+// PageQuery describes a filtered, sorted and paginated lookup.
+type PageQuery struct {
+	Scopes []Scope
+	Order  string
+	Offset int
+	Limit  int
+}
+
+// FindPage retrieves one page of the rows matching the query scopes along with the total match count.
+func (r *Repository[T]) FindPage(ctx context.Context, q PageQuery) ([]T, int64, error) {
+	scopes := make([]func(*gorm.DB) *gorm.DB, len(q.Scopes))
+	for i, scope := range q.Scopes {
+		scopes[i] = scope
+	}
+	base := func() *gorm.DB {
+		return r.dbClient.WithContext(ctx).Model(new(T)).Scopes(scopes...)
+	}
+
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := base()
+	if q.Order != "" {
+		query = query.Order(q.Order)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+
+	var results []T
+	if err := query.Find(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// where filters rows with a raw condition.
+func where(query string, args ...any) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// search filters rows where any of the columns contains the term, case-insensitively.
+func search(term string, columns ...string) Scope {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	pattern := "%" + replacer.Replace(term) + "%"
+
+	return func(db *gorm.DB) *gorm.DB {
+		conditions := make([]string, len(columns))
+		args := make([]any, len(columns))
+		for i, column := range columns {
+			conditions[i] = column + " ILIKE ?"
+			args[i] = pattern
+		}
+		return db.Where(strings.Join(conditions, " OR "), args...)
+	}
+}
+
+// between filters rows whose time column falls within the optional bounds (inclusive).
+func between(column string, from *time.Time, to *time.Time) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if from != nil {
+			db = db.Where(column+" >= ?", *from)
+		}
+		if to != nil {
+			db = db.Where(column+" <= ?", *to)
+		}
+		return db
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IEntryRatingRepository interface {
+	IRepository[model.EntryRating]
+	RunInTx(ctx context.Context, fn func(tx IEntryRatingRepository) error) error
+	CreateMissing(ctx context.Context, leaderboardID string, ratings []model.EntryRating, limit int) error
+	FindForUpdate(ctx context.Context, leaderboardID string, entryIDs []string) ([]model.EntryRating, error)
+	SaveAll(ctx context.Context, ratings []model.EntryRating) error
+	CopyAll(ctx context.Context, fromLeaderboardID string, toLeaderboardID string) error
+	Outbox() IOutboxRepository
+}
+
+const copyBatchSize = 1000
+
+type entryRatingRepository struct {
+	Repository[model.EntryRating]
+}
+
+func NewEntryRatingRepository(dbClient *gorm.DB) IEntryRatingRepository {
+	return &entryRatingRepository{
+		Repository: Repository[model.EntryRating]{dbClient: dbClient},
+	}
+}
+
+// RunInTx runs fn with a repository bound to a single database transaction.
+func (r *entryRatingRepository) RunInTx(ctx context.Context, fn func(tx IEntryRatingRepository) error) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewEntryRatingRepository(tx))
+	})
+}
+
+// Outbox returns the outbox repository sharing this repository's transaction, if any.
+func (r *entryRatingRepository) Outbox() IOutboxRepository {
+	return NewOutboxRepository(r.dbClient)
+}
+
+// CreateMissing inserts the ratings of a leaderboard whose entries have none yet, leaving
+// existing ones untouched. Inserts racing another transaction wait for it instead of failing.
+//
+// With a positive limit it fails with ErrQuotaExceeded when the leaderboard would then rate more
+// than limit entries. The check holds a lock on the leaderboard until the transaction ends, so it
+// must run in RunInTx for concurrent matches not to overshoot the limit.
+func (r *entryRatingRepository) CreateMissing(ctx context.Context, leaderboardID string, ratings []model.EntryRating, limit int) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	db := r.dbClient.WithContext(ctx)
+	if limit > 0 {
+		if err := lockScope(db, "entry_ratings:"+leaderboardID); err != nil {
+			return err
+		}
+
+		entryIDs := make([]string, len(ratings))
+		for i, rating := range ratings {
+			entryIDs[i] = rating.EntryID
+		}
+		var total, existing int64
+		if err := db.Model(&model.EntryRating{}).Where("leaderboard_id = ?", leaderboardID).Count(&total).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&model.EntryRating{}).Where("leaderboard_id = ? AND entry_id IN (?)", leaderboardID, entryIDs).Count(&existing).Error; err != nil {
+			return err
+		}
+		if total+int64(len(ratings))-existing > int64(limit) {
+			return ErrQuotaExceeded
+		}
+	}
+
+	return db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "leaderboard_id"}, {Name: "entry_id"}},
+			DoNothing: true,
+		}).
+		Create(&ratings).Error
+}
+
+// FindForUpdate retrieves the ratings of the given entries and locks them until the transaction ends.
+func (r *entryRatingRepository) FindForUpdate(ctx context.Context, leaderboardID string, entryIDs []string) ([]model.EntryRating, error) {
+	var results []model.EntryRating
+	err := r.dbClient.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("leaderboard_id = ? AND entry_id IN (?)", leaderboardID, entryIDs).
+		Order("entry_id").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SaveAll inserts new ratings and updates existing ones.
+func (r *entryRatingRepository) SaveAll(ctx context.Context, ratings []model.EntryRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+	return r.dbClient.WithContext(ctx).Save(&ratings).Error
+}
+
+// CopyAll copies every rating of one leaderboard to another in a single transaction.
+func (r *entryRatingRepository) CopyAll(ctx context.Context, fromLeaderboardID string, toLeaderboardID string) error {
+	return r.dbClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var batch []model.EntryRating
+		result := tx.Where("leaderboard_id = ?", fromLeaderboardID).
+			FindInBatches(&batch, copyBatchSize, func(_ *gorm.DB, _ int) error {
+				copies := make([]model.EntryRating, len(batch))
+				for i, r := range batch {
+					copies[i] = model.EntryRating{
+						LeaderboardID: toLeaderboardID,
+						EntryID:       r.EntryID,
+						Rating:        r.Rating,
+						Deviation:     r.Deviation,
+						Volatility:    r.Volatility,
+						Matches:       r.Matches,
+					}
+				}
+				return tx.Create(&copies).Error
+			})
+		return result.Error
+	})
+}
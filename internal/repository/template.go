@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+)
+
+type ITemplateRepository interface {
+	IRepository[model.LeaderboardTemplate]
+	GetList(ctx context.Context, tenantID string, req dto.ListTemplatesReq) ([]model.LeaderboardTemplate, int64, error)
+}
+
+type templateRepository struct {
+	Repository[model.LeaderboardTemplate]
+}
+
+func NewTemplateRepository(dbClient *gorm.DB) ITemplateRepository {
+	return &templateRepository{
+		Repository: Repository[model.LeaderboardTemplate]{dbClient: dbClient},
+	}
+}
+
+// GetList retrieves a page of a tenant's templates ordered by name.
+func (r *templateRepository) GetList(ctx context.Context, tenantID string, req dto.ListTemplatesReq) ([]model.LeaderboardTemplate, int64, error) {
+	scopes := []Scope{where("tenant_id = ?", tenantID)}
+	if req.Search != nil && *req.Search != "" {
+		scopes = append(scopes, search(*req.Search, "name", "description"))
+	}
+
+	return r.FindPage(ctx, PageQuery{
+		Scopes: scopes,
+		Order:  "name ASC, id ASC",
+		Offset: req.Offset(),
+		Limit:  req.PageSize,
+	})
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ITenantRepository interface {
+	IRepository[model.Tenant]
+	Upsert(ctx context.Context, tenant *model.Tenant) (*model.Tenant, error)
+	CreateAPIKey(ctx context.Context, key *model.TenantAPIKey) error
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.TenantAPIKey, error)
+	FindAPIKey(ctx context.Context, tenantID string, id string) *model.TenantAPIKey
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+type tenantRepository struct {
+	Repository[model.Tenant]
+}
+
+func NewTenantRepository(dbClient *gorm.DB) ITenantRepository {
+	return &tenantRepository{
+		Repository: Repository[model.Tenant]{dbClient: dbClient},
+	}
+}
+
+// Upsert creates the tenant or replaces the name and quotas of the existing one.
+func (r *tenantRepository) Upsert(ctx context.Context, tenant *model.Tenant) (*model.Tenant, error) {
+	err := r.dbClient.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "max_leaderboards", "max_entries_per_leaderboard", "max_submissions_per_sec", "updated_at",
+		}),
+	}).Create(tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func (r *tenantRepository) CreateAPIKey(ctx context.Context, key *model.TenantAPIKey) error {
+	return r.dbClient.WithContext(ctx).Create(key).Error
+}
+
+// FindAPIKeyByHash retrieves the API key with the given hash, failing with
+// gorm.ErrRecordNotFound when there is none.
+func (r *tenantRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.TenantAPIKey, error) {
+	var result model.TenantAPIKey
+	if err := r.dbClient.WithContext(ctx).First(&result, "key_hash = ?", keyHash).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindAPIKey retrieves an API key of a tenant.
+func (r *tenantRepository) FindAPIKey(ctx context.Context, tenantID string, id string) *model.TenantAPIKey {
+	var result model.TenantAPIKey
+	if err := r.dbClient.WithContext(ctx).First(&result, "tenant_id = ? AND id = ?", tenantID, id).Error; err != nil {
+		return nil
+	}
+	return &result
+}
+
+// DeleteAPIKey removes an API key for good, so its hash cannot authenticate again.
+func (r *tenantRepository) DeleteAPIKey(ctx context.Context, id string) error {
+	return r.dbClient.WithContext(ctx).Unscoped().Delete(&model.TenantAPIKey{}, "id = ?", id).Error
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+)
+
+const entryCopyBatchSize = 1000
+
+// CloneLeaderboard creates a leaderboard with the configuration of an existing one and,
+// optionally, a copy of its current entries.
+func (s *LeaderBoardSvc) CloneLeaderboard(ctx context.Context, leaderboardID string, req dto.CloneLeaderboardReq) (*dto.LeaderboardDto, error) {
+	source, err := s.getCacheLeaderboard(ctx, leaderboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceDto dto.LeaderboardDto
+	sourceDto.FromModel(source)
+
+	create := dto.CreateLeaderboardReq{
+		Name:             sourceDto.Name,
+		Slug:             req.Slug,
+		Description:      sourceDto.Description,
+		Type:             sourceDto.Type,
+		UpdatePolicy:     sourceDto.UpdatePolicy,
+		DecayHalfLifeSec: sourceDto.DecayHalfLifeSec,
+		RatingSystem:     sourceDto.RatingSystem,
+		Shards:           sourceDto.Shards,
+		ApproximateRank:  sourceDto.ApproximateRank,
+		NotifyOvertaken:  sourceDto.NotifyOvertaken,
+		Milestones:       sourceDto.Milestones,
+		Tags:             sourceDto.Tags,
+		Metadata:         sourceDto.Metadata,
+	}
+	if req.Name != "" {
+		create.Name = req.Name
+	}
+	if !req.ExpiredAt.IsZero() {
+		create.ExpiredAt = req.ExpiredAt
+	} else {
+		// The clone runs as long as the source was set to, counted from now, so cloning an
+		// expired leaderboard does not yield one that is already expired
+		create.ExpiredAt = time.Now().Add(source.ExpiredAt.Sub(source.CreatedAt))
+	}
+
+	m, err := s.newLeaderboard(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CopyEntries {
+		if err := s.checkCopyQuota(ctx, source, m); err != nil {
+			return nil, err
+		}
+	}
+
+	models := []model.Leaderboard{*m}
+	if err := s.createLeaderboards(ctx, models); err != nil {
+		return nil, err
+	}
+	clone := &models[0]
+
+	if req.CopyEntries {
+		if err := s.copyEntries(ctx, source, clone); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to copy entries", "source", source.ID, "clone", clone.ID, "error", err)
+			s.discardLeaderboard(ctx, clone)
+			return nil, errorx.Wrap(errorx.ErrCreateLeaderboard, err)
+		}
+	}
+
+	s.logger.Info("[LeaderboardSvc] Cloned leaderboard", "source", source.ID, "clone", clone.ID, "entries", req.CopyEntries)
+
+	var resp dto.LeaderboardDto
+	resp.FromModel(clone)
+	return &resp, nil
+}
+
+// checkCopyQuota ensures the entries of source fit in target under its tenant's entry quota.
+func (s *LeaderBoardSvc) checkCopyQuota(ctx context.Context, source *model.Leaderboard, target *model.Leaderboard) error {
+	maxEntries, err := s.entryQuota(ctx, target)
+	if err != nil || maxEntries == 0 {
+		return err
+	}
+	count, err := s.cache.Count(ctx, s.entriesCacheKey(source))
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to count entries", "leaderboard", source.ID, "error", err)
+		return errorx.Wrap(errorx.ErrInternal, err)
+	}
+	if count > int64(maxEntries) {
+		return s.entryQuotaExceeded(maxEntries)
+	}
+	return nil
+}
+
+// copyEntries copies the ranked entries of source into target in batches. Ratings are copied
+// too so matches on a cloned rating leaderboard continue from the copied ratings.
+func (s *LeaderBoardSvc) copyEntries(ctx context.Context, source *model.Leaderboard, target *model.Leaderboard) error {
+	if source.Type == model.LeaderboardTypeRating {
+		if err := s.ratingRepo.CopyAll(ctx, source.ID, target.ID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	sourceKey := s.entriesCacheKey(source)
+	// Pages follow the last entry copied, so sharded sources are not reread from their tops
+	var after *cache.LeaderboardEntry
+	for {
+		var entries []cache.LeaderboardEntry
+		err := s.readDecayed(ctx, source, func() (err error) {
+			entries, err = s.cache.GetRangeAfter(ctx, sourceKey, after, entryCopyBatchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		scores := make(map[string]float64, len(entries))
+		for _, entry := range entries {
+			member, ok := entry.Member.(string)
+			if !ok {
+				continue
+			}
+			// Decaying scores are normalized against each leaderboard's own epoch
+			scores[member] = s.presentScore(source, entry.Score, now) * target.DecayGrowth(now)
+		}
+		if len(scores) > 0 {
+			if err := s.setScores(ctx, target, scores); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < entryCopyBatchSize {
+			return nil
+		}
+		after = &entries[len(entries)-1]
+	}
+}
+
+// discardLeaderboard removes a leaderboard whose creation could not be completed.
+func (s *LeaderBoardSvc) discardLeaderboard(ctx context.Context, leaderboard *model.Leaderboard) {
+	if leaderboard.Slug != nil {
+		// Soft-deleted rows keep their unique slug, so release it first
+		if err := s.leaderboardRepo.ChangeSlug(ctx, leaderboard.TenantID, leaderboard.ID, nil, nil); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to release leaderboard slug", "id", leaderboard.ID, "error", err)
+		}
+	}
+	if err := s.leaderboardRepo.DeleteById(ctx, leaderboard.ID); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to discard leaderboard", "id", leaderboard.ID, "error", err)
+	}
+	if err := s.cache.Delete(ctx, s.entriesCacheKey(leaderboard)); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to discard leaderboard entries", "id", leaderboard.ID, "error", err)
+	}
+	s.invalidateTopEntries(ctx, leaderboard)
+	s.refreshCacheLeaderboard(ctx, leaderboard.TenantID, leaderboard.ID)
+}
@@ -12,6 +12,7 @@ import (
 
 type IHistorySvc interface {
 	Record(ctx context.Context, req *dto.CreateHistoryReq) (*model.History, error)
+	RecordBatch(ctx context.Context, reqs []*dto.CreateHistoryReq) ([]model.History, error)
 	List(ctx context.Context, req *dto.ListHistoriesReq) (*dto.PaginationResp[dto.HistoryDto], error)
 }
 
@@ -42,15 +43,33 @@ func (s *HistorySvc) Record(ctx context.Context, req *dto.CreateHistoryReq) (*mo
 	return m, nil
 }
 
+// RecordBatch saves score history records with a single insert.
+func (s *HistorySvc) RecordBatch(ctx context.Context, reqs []*dto.CreateHistoryReq) ([]model.History, error) {
+	histories := make([]model.History, 0, len(reqs))
+	for _, req := range reqs {
+		histories = append(histories, *req.ToModel())
+	}
+
+	err := s.historyRepo.BulkCreate(ctx, histories)
+	if err != nil {
+		s.logger.Error("[HistorySvc] failed to record score history batch", "count", len(histories), "error", err)
+		return nil, err
+	}
+
+	return histories, nil
+}
+
 // List retrieves a list of score history records based on the provided request filters.
 func (s *HistorySvc) List(ctx context.Context, req *dto.ListHistoriesReq) (*dto.PaginationResp[dto.HistoryDto], error) {
+	req.Normalize()
+
 	histories, total, err := s.historyRepo.GetList(ctx, *req)
 	if err != nil {
 		s.logger.Error("[HistorySvc] failed to list score histories", "leaderboard", req.LeaderboardID, "error", err)
 		return nil, err
 	}
 
-	var historyDtos []dto.HistoryDto
+	historyDtos := make([]dto.HistoryDto, 0, len(histories))
 	for _, history := range histories {
 		historyDtos = append(historyDtos, dto.HistoryDto{}.FromModel(&history))
 	}
@@ -60,5 +79,6 @@ func (s *HistorySvc) List(ctx context.Context, req *dto.ListHistoriesReq) (*dto.
 		Total:    total,
 		Page:     req.Page,
 		PageSize: req.PageSize,
+		HasNext:  int64(req.Offset()+len(historyDtos)) < total,
 	}, nil
 }
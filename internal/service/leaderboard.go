@@ -2,61 +2,136 @@ package service
 
 import (
 	"context"
-
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
 	"github.com/hiamthach108/simplerank/internal/dto"
 	"github.com/hiamthach108/simplerank/internal/errorx"
 	"github.com/hiamthach108/simplerank/internal/model"
 	"github.com/hiamthach108/simplerank/internal/repository"
 	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
 	"github.com/hiamthach108/simplerank/pkg/cache"
 	"github.com/hiamthach108/simplerank/pkg/logger"
 	"github.com/hiamthach108/simplerank/presentation/socket"
+	"golang.org/x/sync/singleflight"
 )
 
 type ILeaderboardSvc interface {
+	ResolveLeaderboard(ctx context.Context, identifier string) (*dto.LeaderboardRef, error)
 	GetLeaderboardDetail(ctx context.Context, leaderboardID string) (*dto.LeaderboardDto, error)
-	GetEntryRank(ctx context.Context, leaderboardID string, entryID string) (int, error)
-	UpdateEntryScore(ctx context.Context, leaderboardID string, entryID string, score float64) error
-	GetListLeaderboards(ctx context.Context) ([]dto.LeaderboardDto, error)
+	GetEntryRank(ctx context.Context, leaderboardID string, entryID string) (*dto.EntryRankDto, error)
+	UpdateEntryScore(ctx context.Context, leaderboardID string, req dto.UpdateEntryScore) error
+	SubmitMatchResult(ctx context.Context, leaderboardID string, req dto.SubmitMatchResultReq) ([]dto.RatingChangeDto, error)
+	GetListLeaderboards(ctx context.Context, req dto.ListLeaderboardsReq) (*dto.PaginationResp[dto.LeaderboardDto], error)
 	CreateLeaderboard(ctx context.Context, req dto.CreateLeaderboardReq) (*dto.LeaderboardDto, error)
+	CreateLeaderboards(ctx context.Context, reqs []dto.CreateLeaderboardReq) ([]dto.LeaderboardDto, error)
+	CloneLeaderboard(ctx context.Context, leaderboardID string, req dto.CloneLeaderboardReq) (*dto.LeaderboardDto, error)
 	UpdateLeaderboard(ctx context.Context, leaderboardID string, req dto.UpdateLeaderboardReq) error
+	CreateTemplate(ctx context.Context, req dto.CreateTemplateReq) (*dto.TemplateDto, error)
+	GetTemplate(ctx context.Context, templateID string) (*dto.TemplateDto, error)
+	GetListTemplates(ctx context.Context, req dto.ListTemplatesReq) (*dto.PaginationResp[dto.TemplateDto], error)
+	InstantiateTemplate(ctx context.Context, templateID string, req dto.InstantiateTemplateReq) ([]dto.LeaderboardDto, error)
 }
 
+const (
+	defaultMaxOvertakenNotifications = 10
+
+	// missingLeaderboardVersion marks a cached lookup of a leaderboard that does not exist.
+	// Stored leaderboards start at version 1.
+	missingLeaderboardVersion = 0
+)
+
 type LeaderBoardSvc struct {
+	config          *config.AppConfig
 	logger          logger.ILogger
 	cache           cache.ICache
+	localCache      cache.ILocalCache
 	leaderboardRepo repository.ILeaderboardRepository
+	ratingRepo      repository.IEntryRatingRepository
+	templateRepo    repository.ITemplateRepository
+	tenantSvc       ITenantSvc
+	notificationSvc INotificationSvc
+	milestoneSvc    IMilestoneSvc
+	outboxSvc       IOutboxSvc
 	broadcaster     socket.IBroadcaster
+	loadGroup       singleflight.Group
 }
 
 func NewLeaderBoardSvc(
+	config *config.AppConfig,
 	logger logger.ILogger,
 	cache cache.ICache,
+	localCache cache.ILocalCache,
 	leaderboardRepo repository.ILeaderboardRepository,
+	ratingRepo repository.IEntryRatingRepository,
+	templateRepo repository.ITemplateRepository,
+	tenantSvc ITenantSvc,
+	notificationSvc INotificationSvc,
+	milestoneSvc IMilestoneSvc,
+	outboxSvc IOutboxSvc,
 	broadcaster socket.IBroadcaster,
 ) ILeaderboardSvc {
 	return &LeaderBoardSvc{
+		config:          config,
 		logger:          logger,
 		cache:           cache,
+		localCache:      localCache,
 		leaderboardRepo: leaderboardRepo,
+		ratingRepo:      ratingRepo,
+		templateRepo:    templateRepo,
+		tenantSvc:       tenantSvc,
+		notificationSvc: notificationSvc,
+		milestoneSvc:    milestoneSvc,
+		outboxSvc:       outboxSvc,
 		broadcaster:     broadcaster,
 	}
 }
 
 // UpdateEntryScore adds or updates an entry's score in the leaderboard.
-func (s *LeaderBoardSvc) UpdateEntryScore(ctx context.Context, leaderboardID string, entryID string, score float64) error {
+func (s *LeaderBoardSvc) UpdateEntryScore(ctx context.Context, leaderboardID string, req dto.UpdateEntryScore) error {
+	if err := s.validateMetadata(req.Metadata, constants.MAX_ENTRY_METADATA_BYTES); err != nil {
+		return err
+	}
+
 	leaderboard, err := s.getCacheLeaderboard(ctx, leaderboardID)
 	if err != nil || leaderboard == nil {
 		return err
 	}
+	if leaderboard.Type == model.LeaderboardTypeRating {
+		return errorx.New(errorx.ErrBadRequest, "rating leaderboards only accept match results")
+	}
+	if err := s.tenantSvc.AllowSubmission(ctx, leaderboard.TenantID); err != nil {
+		return err
+	}
+	maxEntries, err := s.entryQuota(ctx, leaderboard)
+	if err != nil {
+		return err
+	}
 
-	err = s.cache.AddScore(s.entriesCacheKey(leaderboardID), entryID, score)
+	standing, err := s.applyScore(ctx, leaderboard, req.EntryID, req.Score, maxEntries)
+	if errors.Is(err, cache.ErrBoardFull) {
+		return s.entryQuotaExceeded(maxEntries)
+	}
 	if err != nil {
-		s.logger.Error("[LeaderboardSvc] failed to update entry score", "leaderboard", leaderboardID, "entry", entryID, "error", err)
+		s.logger.Error("[LeaderboardSvc] failed to update entry score", "leaderboard", leaderboardID, "entry", req.EntryID, "error", err)
 		return errorx.Wrap(errorx.ErrUpdateScore, err)
 	}
+	if changesTopEntries(standing) {
+		s.invalidateTopEntries(ctx, leaderboard)
+	}
 
-	s.publishEvent(leaderboardID, entryID, score)
+	// The score is already applied, so the update succeeds even when its event is lost
+	s.publishEvent(ctx, leaderboard, req.EntryID, standing.Score, req.Metadata)
+
+	if leaderboard.NotifyOvertaken || len(leaderboard.Milestones) > 0 {
+		go s.handleStandingChange(context.WithoutCancel(ctx), leaderboard, req.EntryID, standing)
+	}
 
 	return nil
 }
@@ -69,12 +144,17 @@ func (s *LeaderBoardSvc) GetLeaderboardDetail(ctx context.Context, leaderboardID
 		return nil, err
 	}
 
-	entries, err := s.cache.GetTopN(s.entriesCacheKey(leaderboardID), 100)
+	entries, err := s.getTopEntries(ctx, leaderboard)
 	if err != nil {
 		s.logger.Error("[LeaderboardSvc] failed to get top entries", "leaderboard", leaderboardID, "error", err)
 		return nil, errorx.Wrap(errorx.ErrInternal, err)
 	}
 
+	now := time.Now()
+	for i := range entries {
+		entries[i].Score = s.presentScore(leaderboard, entries[i].Score, now)
+	}
+
 	leaderboardDto := &dto.LeaderboardDto{}
 	leaderboardDto.FromModel(leaderboard)
 	leaderboardDto.TopEntries = entries
@@ -82,32 +162,130 @@ func (s *LeaderBoardSvc) GetLeaderboardDetail(ctx context.Context, leaderboardID
 	return leaderboardDto, nil
 }
 
-// GetEntryRank retrieves an entry's rank (1-based) from the leaderboard.
-func (s *LeaderBoardSvc) GetEntryRank(ctx context.Context, leaderboardID string, entryID string) (int, error) {
-	rank, _, err := s.cache.GetRank(s.entriesCacheKey(leaderboardID), entryID)
+// topEntriesPage is a top page of a leaderboard kept in the local cache, with the decay epoch
+// its stored scores are normalized against.
+type topEntriesPage struct {
+	entries    []cache.LeaderboardEntry
+	decayEpoch time.Time
+}
+
+// getTopEntries returns a copy of the top page of a leaderboard with stored scores, pointing the
+// leaderboard's decay epoch at the one they are stored against. Submissions that reach the page
+// drop it from the local cache on every instance.
+func (s *LeaderBoardSvc) getTopEntries(ctx context.Context, leaderboard *model.Leaderboard) ([]cache.LeaderboardEntry, error) {
+	key := s.entriesCacheKey(leaderboard)
+	if cached, ok := s.localCache.Get(key); ok {
+		page := cached.(topEntriesPage)
+		leaderboard.DecayEpoch = page.decayEpoch
+		return slices.Clone(page.entries), nil
+	}
+
+	var entries []cache.LeaderboardEntry
+	err := s.readDecayed(ctx, leaderboard, func() (err error) {
+		entries, err = s.cache.GetTopN(ctx, key, constants.LEADERBOARD_TOP_ENTRIES)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.localCache.Set(key, topEntriesPage{entries: slices.Clone(entries), decayEpoch: leaderboard.DecayEpoch})
+
+	return entries, nil
+}
+
+// GetEntryRank retrieves an entry's rank (1-based) and percentile from the leaderboard, estimated
+// on leaderboards that rank approximately.
+func (s *LeaderBoardSvc) GetEntryRank(ctx context.Context, leaderboardID string, entryID string) (*dto.EntryRankDto, error) {
+	leaderboard, err := s.getCacheLeaderboard(ctx, leaderboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	entriesKey := s.entriesCacheKey(leaderboard)
+	var rank, total int64
+	var score float64
+	err = s.readDecayed(ctx, leaderboard, func() (err error) {
+		if leaderboard.ApproximateRank {
+			rank, score, total, err = s.cache.EstimateRank(ctx, entriesKey, entryID)
+			return err
+		}
+		rank, score, err = s.cache.GetRank(ctx, entriesKey, entryID)
+		if err == nil {
+			total, err = s.cache.Count(ctx, entriesKey)
+		}
+		return err
+	})
+	if cache.IsNil(err) {
+		return nil, errorx.New(errorx.ErrNotFound, fmt.Sprintf("entry %q is not on the leaderboard", entryID))
+	}
 	if err != nil {
 		s.logger.Error("[LeaderboardSvc] failed to get rank for entry", "leaderboard", leaderboardID, "entry", entryID, "error", err)
-		return 0, errorx.Wrap(errorx.ErrInternal, err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
 	}
-	return int(rank), nil
+
+	// Histogram counts may briefly trail the sorted set
+	total = max(total, rank)
+
+	return &dto.EntryRankDto{
+		EntryID:     entryID,
+		Rank:        rank,
+		Score:       s.presentScore(leaderboard, score, time.Now()),
+		Total:       total,
+		Percentile:  float64(rank) / float64(total) * 100,
+		Approximate: leaderboard.ApproximateRank,
+	}, nil
 }
 
-// GetListLeaderboards retrieves all leaderboards.
-func (s *LeaderBoardSvc) GetListLeaderboards(ctx context.Context) ([]dto.LeaderboardDto, error) {
-	leaderboards, err := s.leaderboardRepo.FindAll(ctx)
+// GetListLeaderboards retrieves a page of leaderboards matching the request filters.
+func (s *LeaderBoardSvc) GetListLeaderboards(ctx context.Context, req dto.ListLeaderboardsReq) (*dto.PaginationResp[dto.LeaderboardDto], error) {
+	req.Normalize()
+	if req.Status == "" {
+		req.Status = string(model.LeaderboardStatusActive)
+	}
+	switch req.Status {
+	case string(model.LeaderboardStatusActive), string(model.LeaderboardStatusExpired),
+		string(model.LeaderboardStatusArchived), dto.LeaderboardStatusAll:
+	default:
+		return nil, errorx.New(errorx.ErrBadRequest, fmt.Sprintf("unknown status %q", req.Status))
+	}
+
+	tags, err := s.normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	req.Tags = tags
+
+	if req.Metadata != nil {
+		// Re-encode the filter so only a well-formed JSON object reaches the query
+		var metadata map[string]any
+		if err := json.Unmarshal([]byte(*req.Metadata), &metadata); err != nil || metadata == nil {
+			return nil, errorx.New(errorx.ErrBadRequest, "metadata filter must be a JSON object")
+		}
+		data, _ := json.Marshal(metadata)
+		filter := string(data)
+		req.Metadata = &filter
+	}
+
+	leaderboards, total, err := s.leaderboardRepo.GetList(ctx, tenant.FromContext(ctx), req)
 	if err != nil {
 		s.logger.Error("[LeaderboardSvc] failed to get list of leaderboards", "error", err)
-		return nil, errorx.Wrap(errorx.ErrLeaderboardNotFound, err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
 	}
 
-	var resp []dto.LeaderboardDto
+	items := make([]dto.LeaderboardDto, 0, len(leaderboards))
 	for _, lb := range leaderboards {
 		var lbDto dto.LeaderboardDto
 		lbDto.FromModel(&lb)
-		resp = append(resp, lbDto)
+		items = append(items, lbDto)
 	}
 
-	return resp, nil
+	return &dto.PaginationResp[dto.LeaderboardDto]{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		HasNext:  int64(req.Offset()+len(items)) < total,
+	}, nil
 }
 
 // CreateLeaderboard creates a new leaderboard.
@@ -116,23 +294,126 @@ func (s *LeaderBoardSvc) CreateLeaderboard(ctx context.Context, req dto.CreateLe
 	// This could involve creating a new key in the cache or storing metadata in a database.
 	s.logger.Info("[LeaderboardSvc] Creating leaderboard", "name", req.Name)
 
-	m := model.Leaderboard{
-		Name:        req.Name,
-		Description: req.Description,
-		ExpiredAt:   req.ExpiredAt,
+	m, err := s.newLeaderboard(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	leaderboard, err := s.leaderboardRepo.Create(ctx, &m)
-	if err != nil {
-		s.logger.Error("[LeaderboardSvc] failed to create leaderboard", "name", req.Name, "error", err)
-		return nil, errorx.Wrap(errorx.ErrCreateLeaderboard, err)
+	models := []model.Leaderboard{*m}
+	if err := s.createLeaderboards(ctx, models); err != nil {
+		return nil, err
 	}
 	var resp dto.LeaderboardDto
-	resp.FromModel(leaderboard)
+	resp.FromModel(&models[0])
 
 	return &resp, nil
 }
 
+// CreateLeaderboards creates several leaderboards at once; either all of them are created or none.
+func (s *LeaderBoardSvc) CreateLeaderboards(ctx context.Context, reqs []dto.CreateLeaderboardReq) ([]dto.LeaderboardDto, error) {
+	models := make([]model.Leaderboard, 0, len(reqs))
+	slugs := make(map[string]struct{}, len(reqs))
+	for _, req := range reqs {
+		m, err := s.newLeaderboard(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Slug != "" {
+			if _, ok := slugs[req.Slug]; ok {
+				return nil, errorx.New(errorx.ErrConflict, fmt.Sprintf("slug %q is used more than once", req.Slug))
+			}
+			slugs[req.Slug] = struct{}{}
+		}
+		models = append(models, *m)
+	}
+
+	if err := s.createLeaderboards(ctx, models); err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.LeaderboardDto, len(models))
+	for i := range models {
+		resp[i].FromModel(&models[i])
+	}
+
+	return resp, nil
+}
+
+// newLeaderboard applies defaults to a creation request, validates it and builds the leaderboard.
+func (s *LeaderBoardSvc) newLeaderboard(ctx context.Context, req dto.CreateLeaderboardReq) (*model.Leaderboard, error) {
+	if req.Type == "" {
+		req.Type = model.LeaderboardTypeStandard
+	}
+	if req.Type == model.LeaderboardTypeRating && req.RatingSystem == "" {
+		req.RatingSystem = model.RatingSystemElo
+	}
+	if req.UpdatePolicy == "" {
+		req.UpdatePolicy = model.UpdatePolicyLatest
+	}
+	if err := s.validateType(req); err != nil {
+		return nil, err
+	}
+	if err := s.validateMilestones(req.Milestones); err != nil {
+		return nil, err
+	}
+	if req.ApproximateRank && req.Type == model.LeaderboardTypeTrending {
+		// Rebasing rescales every stored score, which would invalidate the histogram
+		return nil, errorx.New(errorx.ErrBadRequest, "trending leaderboards do not support approximate ranks")
+	}
+	if req.Shards > 1 && req.Type == model.LeaderboardTypeTrending {
+		// Rebasing must rescale every stored score at once, which shards cannot do
+		return nil, errorx.New(errorx.ErrBadRequest, "trending leaderboards cannot be sharded")
+	}
+	if req.Shards < 0 || req.Shards > constants.MAX_LEADERBOARD_SHARDS {
+		return nil, errorx.New(errorx.ErrBadRequest, fmt.Sprintf("shards must be between 0 and %d", constants.MAX_LEADERBOARD_SHARDS))
+	}
+	if err := s.validateMetadata(req.Metadata, constants.MAX_LEADERBOARD_METADATA_BYTES); err != nil {
+		return nil, err
+	}
+	tags, err := s.normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if req.Slug != "" {
+		if err := s.validateSlug(req.Slug); err != nil {
+			return nil, err
+		}
+		if err := s.ensureSlugAvailable(ctx, req.Slug, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	m := model.Leaderboard{
+		TenantID:        tenant.FromContext(ctx),
+		Name:            req.Name,
+		Description:     req.Description,
+		Type:            req.Type,
+		UpdatePolicy:    req.UpdatePolicy,
+		ExpiredAt:       req.ExpiredAt,
+		NotifyOvertaken: req.NotifyOvertaken,
+		Milestones:      req.Milestones,
+		Tags:            tags,
+		Shards:          req.Shards,
+		ApproximateRank: req.ApproximateRank,
+	}
+	if req.Slug != "" {
+		m.Slug = &req.Slug
+	}
+	if req.Metadata != nil {
+		data, _ := json.Marshal(req.Metadata)
+		m.Metadata = data
+	}
+	switch req.Type {
+	case model.LeaderboardTypeTrending:
+		m.DecayHalfLifeSec = req.DecayHalfLifeSec
+		m.DecayEpoch = time.Now().Truncate(time.Microsecond)
+	case model.LeaderboardTypeRating:
+		m.RatingSystem = req.RatingSystem
+	}
+
+	return &m, nil
+}
+
 // UpdateLeaderboard updates an existing leaderboard's details.
 func (s *LeaderBoardSvc) UpdateLeaderboard(ctx context.Context, leaderboardID string, req dto.UpdateLeaderboardReq) error {
 	leaderboard, err := s.getCacheLeaderboard(ctx, leaderboardID)
@@ -140,65 +421,464 @@ func (s *LeaderBoardSvc) UpdateLeaderboard(ctx context.Context, leaderboardID st
 		return err
 	}
 
+	if req.Milestones != nil {
+		if err := s.validateMilestones(*req.Milestones); err != nil {
+			return err
+		}
+	}
+	if req.Metadata != nil {
+		if err := s.validateMetadata(*req.Metadata, constants.MAX_LEADERBOARD_METADATA_BYTES); err != nil {
+			return err
+		}
+	}
+	if req.Tags != nil {
+		tags, err := s.normalizeTags(*req.Tags)
+		if err != nil {
+			return err
+		}
+		req.Tags = &tags
+	}
+
+	if req.Slug != nil {
+		if err := s.changeSlug(ctx, leaderboard, *req.Slug); err != nil {
+			return err
+		}
+	}
+
 	updatedModel, fields := req.ToModel()
 	if len(fields) == 0 {
-		s.logger.Info("[LeaderboardSvc] no fields to update for leaderboard", "id", leaderboardID)
+		s.logger.Info("[LeaderboardSvc] no fields to update for leaderboard", "id", leaderboard.ID)
 		return nil // Nothing to update
 	}
 
-	err = s.leaderboardRepo.Update(ctx, leaderboardID, *updatedModel, fields...)
+	err = s.leaderboardRepo.Update(ctx, leaderboard.ID, *updatedModel, fields...)
 	if err != nil {
-		s.logger.Error("[LeaderboardSvc] failed to update leaderboard", "id", leaderboardID, "error", err)
+		s.logger.Error("[LeaderboardSvc] failed to update leaderboard", "id", leaderboard.ID, "error", err)
 		return errorx.Wrap(errorx.ErrUpdateLeaderboard, err)
 	}
+	s.refreshCacheLeaderboard(ctx, leaderboard.TenantID, leaderboard.ID)
 
 	return nil
 }
 
-func (s *LeaderBoardSvc) leaderboardCacheKey(leaderboardID string) string {
-	return constants.CACHE_LEADERBOARD_PREFIX + leaderboardID
+func (s *LeaderBoardSvc) leaderboardCacheKey(tenantID string, leaderboardID string) string {
+	return constants.CACHE_LEADERBOARD_PREFIX + tenantID + ":" + leaderboardID
 }
 
-func (s *LeaderBoardSvc) entriesCacheKey(leaderboardID string) string {
-	return constants.CACHE_LEADERBOARD_ENTRIES_PREFIX + leaderboardID
+func (s *LeaderBoardSvc) entriesCacheKey(leaderboard *model.Leaderboard) string {
+	// Entries are only stored in Redis, so the default tenant keeps the keys from before tenants existed
+	key := constants.CACHE_LEADERBOARD_ENTRIES_PREFIX + leaderboard.ID
+	if leaderboard.TenantID != tenant.Default {
+		key = constants.CACHE_LEADERBOARD_ENTRIES_PREFIX + leaderboard.TenantID + ":" + leaderboard.ID
+	}
+	return cache.ShardedBoardKey(key, leaderboard.Shards)
 }
 
-func (s *LeaderBoardSvc) getCacheLeaderboard(ctx context.Context, leaderboardID string) (*model.Leaderboard, error) {
+// getCacheLeaderboard loads the caller tenant's leaderboard identified by an ID, slug or previous slug.
+func (s *LeaderBoardSvc) getCacheLeaderboard(ctx context.Context, identifier string) (*model.Leaderboard, error) {
+	leaderboardID, err := s.resolveLeaderboardID(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	key := s.leaderboardCacheKey(tenantID, leaderboardID)
+	if cached, ok := s.localCache.Get(key); ok {
+		// Hand out a copy since callers may adjust the leaderboard
+		localLeaderboard := cached.(model.Leaderboard)
+		return &localLeaderboard, nil
+	}
+
+	// Concurrent misses of the same leaderboard share a single load, which must not be
+	// cancelled by the caller that happened to start it
+	loaded, err, _ := s.loadGroup.Do(key, func() (any, error) {
+		return s.loadLeaderboard(context.WithoutCancel(ctx), tenantID, leaderboardID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := loaded.(model.Leaderboard)
+	return &leaderboard, nil
+}
+
+// loadLeaderboard reads a leaderboard through the shared cache, remembering leaderboards that do
+// not exist for a short while so repeated lookups of them do not reach the database.
+func (s *LeaderBoardSvc) loadLeaderboard(ctx context.Context, tenantID string, leaderboardID string) (model.Leaderboard, error) {
+	key := s.leaderboardCacheKey(tenantID, leaderboardID)
+
 	var cacheLeaderboard model.Leaderboard
-	if err := s.cache.Get(s.leaderboardCacheKey(leaderboardID), &cacheLeaderboard); err == nil {
-		return &cacheLeaderboard, nil
+	if version, err := s.cache.GetVersioned(ctx, key, &cacheLeaderboard); err == nil {
+		if version == missingLeaderboardVersion {
+			return model.Leaderboard{}, errorx.Wrap(errorx.ErrLeaderboardNotFound, nil)
+		}
+		s.localCache.Set(key, cacheLeaderboard)
+		return cacheLeaderboard, nil
 	}
 
+	// Leaderboards of other tenants are reported as missing
 	leaderboard := s.leaderboardRepo.FindOneById(ctx, leaderboardID)
-	if leaderboard == nil {
+	if leaderboard == nil || leaderboard.TenantID != tenantID {
 		s.logger.Error("[LeaderboardSvc] leaderboard not found", "id", leaderboardID)
-		return nil, errorx.Wrap(errorx.ErrLeaderboardNotFound, nil)
+		// Any stored version replaces the marker, so a leaderboard saved meanwhile is never hidden
+		if _, err := s.cache.SetVersioned(ctx, key, nil, missingLeaderboardVersion, cache.Jittered(cache.NegativeTTL)); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to cache missing leaderboard", "id", leaderboardID, "error", err)
+		}
+		return model.Leaderboard{}, errorx.Wrap(errorx.ErrLeaderboardNotFound, nil)
 	}
 
-	// Set to cache leaderboard
-	err := s.cache.Set(s.leaderboardCacheKey(leaderboard.ID), &leaderboard, &cache.DefaultTTL)
+	// Set to cache leaderboard unless a concurrent write already cached a newer version
+	_, err := s.cache.SetVersioned(ctx, key, leaderboard, leaderboard.Version, cache.Jittered(cache.DefaultTTL))
 	if err != nil {
 		s.logger.Error("[LeaderboardSvc] failed to cache leaderboard", "id", leaderboardID, "error", err)
-		return nil, errorx.Wrap(errorx.ErrInternal, err)
+		return model.Leaderboard{}, errorx.Wrap(errorx.ErrInternal, err)
+	}
+	s.localCache.Set(key, *leaderboard)
+
+	return *leaderboard, nil
+}
+
+// refreshCacheLeaderboard writes the stored leaderboard through to the cache after a change and
+// tells other instances to drop their copies of it.
+func (s *LeaderBoardSvc) refreshCacheLeaderboard(ctx context.Context, tenantID string, leaderboardID string) {
+	// The change is already stored, so the cache must follow it even if the caller has gone away
+	ctx = context.WithoutCancel(ctx)
+	key := s.leaderboardCacheKey(tenantID, leaderboardID)
+	s.localCache.Delete(key)
+	if leaderboard := s.leaderboardRepo.FindOneById(ctx, leaderboardID); leaderboard != nil {
+		if _, err := s.cache.SetVersioned(ctx, key, leaderboard, leaderboard.Version, cache.Jittered(cache.DefaultTTL)); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to cache leaderboard", "id", leaderboardID, "error", err)
+		}
+	} else if err := s.cache.Delete(ctx, key); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to invalidate leaderboard cache", "id", leaderboardID, "error", err)
+	}
+
+	if err := s.cache.PublishInvalidation(ctx, key); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to publish leaderboard invalidation", "id", leaderboardID, "error", err)
+	}
+}
+
+// createLeaderboards stores new leaderboards of the caller's tenant, all of them or none, within
+// the tenant's leaderboard quota.
+func (s *LeaderBoardSvc) createLeaderboards(ctx context.Context, models []model.Leaderboard) error {
+	tenantID := tenant.FromContext(ctx)
+	quota, err := s.tenantSvc.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.leaderboardRepo.CreateAll(ctx, tenantID, models, quota.MaxLeaderboards)
+	if errors.Is(err, repository.ErrQuotaExceeded) {
+		return errorx.New(errorx.ErrForbidden, fmt.Sprintf("tenant quota of %d leaderboards exceeded", quota.MaxLeaderboards))
+	}
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to create leaderboards", "tenant", tenantID, "count", len(models), "error", err)
+		return errorx.Wrap(errorx.ErrCreateLeaderboard, err)
+	}
+
+	// Lookups of the new slugs may have been cached as missing
+	for _, m := range models {
+		if m.Slug == nil {
+			continue
+		}
+		if err := s.cache.Delete(context.WithoutCancel(ctx), s.slugCacheKey(tenantID, *m.Slug)); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to invalidate leaderboard slug", "slug", *m.Slug, "error", err)
+		}
+	}
+	return nil
+}
+
+// entryQuota returns the number of entries the leaderboard's tenant allows per leaderboard; 0
+// means unlimited. The quota is enforced by the write adding an entry.
+func (s *LeaderBoardSvc) entryQuota(ctx context.Context, leaderboard *model.Leaderboard) (int, error) {
+	quota, err := s.tenantSvc.GetTenant(ctx, leaderboard.TenantID)
+	if err != nil {
+		return 0, err
+	}
+	return quota.MaxEntriesPerLeaderboard, nil
+}
+
+func (s *LeaderBoardSvc) entryQuotaExceeded(maxEntries int) error {
+	return errorx.New(errorx.ErrForbidden, fmt.Sprintf("tenant quota of %d entries per leaderboard exceeded", maxEntries))
+}
+
+// validateType ensures the leaderboard type is known and carries the settings it requires.
+// Only standard leaderboards apply submitted scores by a policy other than the latest.
+func (s *LeaderBoardSvc) validateType(req dto.CreateLeaderboardReq) error {
+	if req.Type != model.LeaderboardTypeStandard && req.UpdatePolicy != model.UpdatePolicyLatest {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("%s leaderboards only support the latest update policy", req.Type))
+	}
+
+	switch req.Type {
+	case model.LeaderboardTypeStandard:
+		switch req.UpdatePolicy {
+		case model.UpdatePolicyLatest, model.UpdatePolicyBest, model.UpdatePolicyIncrement:
+			return nil
+		}
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("unknown update policy %q", req.UpdatePolicy))
+	case model.LeaderboardTypeTrending:
+		if req.DecayHalfLifeSec <= 0 {
+			return errorx.New(errorx.ErrBadRequest, "trending leaderboards require a positive decayHalfLifeSec")
+		}
+		return nil
+	case model.LeaderboardTypeRating:
+		if req.RatingSystem != model.RatingSystemElo && req.RatingSystem != model.RatingSystemGlicko2 {
+			return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("unknown rating system %q", req.RatingSystem))
+		}
+		return nil
+	}
+	return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("unknown leaderboard type %q", req.Type))
+}
+
+// validateMilestones ensures every milestone rule is well-formed and unique.
+func (s *LeaderBoardSvc) validateMilestones(rules []model.MilestoneRule) error {
+	seen := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if !rule.Valid() {
+			return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("invalid milestone %q", rule.Key()))
+		}
+		if _, ok := seen[rule.Key()]; ok {
+			return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("duplicate milestone %q", rule.Key()))
+		}
+		seen[rule.Key()] = struct{}{}
 	}
+	return nil
+}
 
-	return leaderboard, nil
+// normalizeTags trims, lowercases and deduplicates tags and enforces the tag limits.
+func (s *LeaderBoardSvc) normalizeTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if len(tag) > constants.MAX_LEADERBOARD_TAG_LENGTH {
+			return nil, errorx.New(errorx.ErrBadRequest, fmt.Sprintf("tag %q exceeds %d characters", tag, constants.MAX_LEADERBOARD_TAG_LENGTH))
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+
+	if len(result) > constants.MAX_LEADERBOARD_TAGS {
+		return nil, errorx.New(errorx.ErrBadRequest, fmt.Sprintf("a leaderboard allows at most %d tags", constants.MAX_LEADERBOARD_TAGS))
+	}
+	return result, nil
 }
 
-func (s *LeaderBoardSvc) publishEvent(leaderboardID string, entryID string, score float64) {
-	// Publish to Redis stream for history tracking
-	go func() {
-		if err := s.cache.Publish(constants.STREAM_LEADERBOARD_UPDATE, dto.CreateHistoryReq{
+// validateMetadata ensures metadata is JSON-encodable and within the size limit.
+func (s *LeaderBoardSvc) validateMetadata(metadata map[string]any, maxBytes int) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return errorx.Wrap(errorx.ErrBadRequest, err)
+	}
+	if len(data) > maxBytes {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("metadata exceeds %d bytes", maxBytes))
+	}
+
+	return nil
+}
+
+// applyScore writes a submission to the leaderboard and returns the entry's ranked standing
+// around it. Standard leaderboards apply the submitted
+// score by their update policy, while trending leaderboards accumulate the submitted points normalized against the
+// decay epoch; the standing is returned in current points either way. New entries beyond
+// maxEntries fail with cache.ErrBoardFull.
+func (s *LeaderBoardSvc) applyScore(ctx context.Context, leaderboard *model.Leaderboard, entryID string, points float64, maxEntries int) (*cache.ScoreStanding, error) {
+	update := cache.ScoreUpdate{
+		Member:     entryID,
+		Score:      points,
+		Tracked:    leaderboard.ApproximateRank,
+		Ranked:     true,
+		MaxMembers: int64(maxEntries),
+	}
+	if leaderboard.NotifyOvertaken {
+		update.Passed = int64(s.config.Leaderboard.MaxOvertakenNotifications)
+		if update.Passed <= 0 {
+			update.Passed = defaultMaxOvertakenNotifications
+		}
+	}
+
+	switch leaderboard.UpdatePolicy {
+	case model.UpdatePolicyBest:
+		update.Best = true
+	case model.UpdatePolicyIncrement:
+		update.Incr = true
+	}
+	if leaderboard.IsDecaying() {
+		// The cache weights the points by the growth since the epoch stored next to the scores
+		update.Incr = true
+		update.Decay = &cache.Decay{
+			HalfLife: time.Duration(leaderboard.DecayHalfLifeSec) * time.Second,
+			Epoch:    leaderboard.DecayEpoch,
+		}
+	}
+	return s.cache.UpdateScore(ctx, s.entriesCacheKey(leaderboard), update)
+}
+
+// setScores stores entries' scores, keeping the histogram of approximately ranked leaderboards.
+func (s *LeaderBoardSvc) setScores(ctx context.Context, leaderboard *model.Leaderboard, scores map[string]float64) error {
+	if leaderboard.ApproximateRank {
+		return s.cache.AddScoresTracked(ctx, s.entriesCacheKey(leaderboard), scores)
+	}
+	return s.cache.AddScores(ctx, s.entriesCacheKey(leaderboard), scores)
+}
+
+// readDecayed runs read, which reads stored scores of a leaderboard, and points the leaderboard's
+// decay epoch at the one those scores are stored against, kept next to them in the cache.
+func (s *LeaderBoardSvc) readDecayed(ctx context.Context, leaderboard *model.Leaderboard, read func() error) error {
+	if !leaderboard.IsDecaying() {
+		return read()
+	}
+
+	key := s.entriesCacheKey(leaderboard)
+	epoch, err := s.cache.GetDecayEpoch(ctx, key, leaderboard.DecayEpoch)
+	if err != nil {
+		return err
+	}
+	// A rebase racing the read may have rescaled the scores read; rebases are hundreds of
+	// half-lives apart, so the read is retried at most once
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := read(); err != nil {
+			return err
+		}
+
+		current, err := s.cache.GetDecayEpoch(ctx, key, leaderboard.DecayEpoch)
+		if err != nil {
+			return err
+		}
+		if current.Equal(epoch) {
+			leaderboard.DecayEpoch = epoch
+			return nil
+		}
+		epoch = current
+	}
+	return fmt.Errorf("scores of leaderboard %s were rebased while being read", leaderboard.ID)
+}
+
+// changesTopEntries reports whether a ranked standing change can alter the top page of its
+// leaderboard, which holds the entries ranked within LEADERBOARD_TOP_ENTRIES.
+func changesTopEntries(standing *cache.ScoreStanding) bool {
+	if !standing.New && standing.Score == standing.PrevScore {
+		return false
+	}
+	return standing.Rank <= constants.LEADERBOARD_TOP_ENTRIES || standing.PrevRank <= constants.LEADERBOARD_TOP_ENTRIES
+}
+
+// invalidateTopEntries drops the cached top pages of a leaderboard on every instance.
+func (s *LeaderBoardSvc) invalidateTopEntries(ctx context.Context, leaderboard *model.Leaderboard) {
+	ctx = context.WithoutCancel(ctx)
+	key := s.entriesCacheKey(leaderboard)
+	s.localCache.Delete(key)
+	if err := s.cache.PublishInvalidation(ctx, key); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to publish top entries invalidation", "id", leaderboard.ID, "error", err)
+	}
+}
+
+// presentScore converts a stored score into the units returned by read APIs.
+func (s *LeaderBoardSvc) presentScore(leaderboard *model.Leaderboard, stored float64, at time.Time) float64 {
+	if !leaderboard.IsDecaying() {
+		return stored
+	}
+	return stored / leaderboard.DecayGrowth(at)
+}
+
+// handleStandingChange runs the rank-dependent side effects of a score update.
+func (s *LeaderBoardSvc) handleStandingChange(ctx context.Context, leaderboard *model.Leaderboard, entryID string, standing *cache.ScoreStanding) {
+	if leaderboard.NotifyOvertaken && len(standing.Passed) > 0 {
+		s.notifyOvertaken(ctx, leaderboard, entryID, standing)
+	}
+
+	if len(leaderboard.Milestones) > 0 {
+		// Entries new to the leaderboard had no previous standing to have reached milestones with
+		prevRank := standing.PrevRank
+		if standing.New {
+			prevRank = 0
+		}
+		s.milestoneSvc.Evaluate(ctx, leaderboard, entryID, prevRank, standing.PrevScore, standing.Rank, standing.Score)
+	}
+}
+
+// notifyOvertaken pushes a personal notification to the entries passed by entryID in a score
+// update, bounded by the configured maximum.
+func (s *LeaderBoardSvc) notifyOvertaken(ctx context.Context, leaderboard *model.Leaderboard, entryID string, standing *cache.ScoreStanding) {
+	leaderboardID := leaderboard.ID
+
+	userIDs := make([]string, 0, len(standing.Passed))
+	scores := make(map[string]float64, len(standing.Passed))
+	ranks := make(map[string]int64, len(standing.Passed))
+	for i, entry := range standing.Passed {
+		userID, ok := entry.Member.(string)
+		if !ok || userID == entryID {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+		scores[userID] = entry.Score
+		// Passed entries sit right below the updated entry
+		ranks[userID] = standing.Rank + int64(i) + 1
+	}
+
+	recipients, err := s.notificationSvc.FilterMuted(ctx, leaderboardID, userIDs)
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to filter muted users", "leaderboard", leaderboardID, "error", err)
+		return
+	}
+
+	for _, userID := range recipients {
+		s.broadcaster.BroadcastToUser(leaderboard.TenantID, userID, socket.MessageTypeOvertaken, dto.OvertakenNotification{
 			LeaderboardID: leaderboardID,
-			EntryID:       entryID,
-			Score:         score,
-		}); err != nil {
-			s.logger.Error("[LeaderboardSvc] failed to publish event", "error", err)
+			OvertakenBy:   entryID,
+			Score:         standing.Score,
+			YourScore:     scores[userID],
+			YourRank:      ranks[userID],
+		})
+	}
+}
+
+// publishEvent records an entry's new score for history tracking before the update is
+// acknowledged and broadcasts it. Events the stream does not take wait in the outbox, and events
+// that could be neither published nor added to the outbox are logged as lost.
+func (s *LeaderBoardSvc) publishEvent(ctx context.Context, leaderboard *model.Leaderboard, entryID string, score float64, metadata map[string]any) {
+	// Publishing the update outlives the request that scored
+	ctx = context.WithoutCancel(ctx)
+	stream, req := s.historyEvent(leaderboard.ID, entryID, score, metadata)
+	if err := s.cache.Publish(ctx, stream, req); err != nil {
+		// The relay publishes the event once the stream is back, possibly after later events
+		s.logger.Warn("[LeaderboardSvc] failed to publish event, adding it to the outbox", "leaderboard", leaderboard.ID, "entry", entryID, "error", err)
+		if err := s.outboxSvc.Publish(ctx, stream, req); err != nil {
+			s.logger.Error("[LeaderboardSvc] lost history event", "leaderboard", leaderboard.ID, "entry", entryID, "score", score, "occurredAt", req.OccurredAt, "error", err)
 		}
-	}()
+	}
+
+	s.broadcastEntryUpdate(leaderboard, entryID, score)
+}
+
+// historyEvent returns the history event of an entry's new score and the stream it is published
+// to. Updates of a leaderboard share a partition, which a single consumer records in order.
+func (s *LeaderBoardSvc) historyEvent(leaderboardID string, entryID string, score float64, metadata map[string]any) (string, dto.CreateHistoryReq) {
+	req := dto.CreateHistoryReq{
+		LeaderboardID: leaderboardID,
+		EntryID:       entryID,
+		Score:         score,
+		OccurredAt:    time.Now(),
+	}
+	if len(metadata) > 0 {
+		req.Metadata = metadata
+	}
+	return cache.PartitionStream(constants.STREAM_LEADERBOARD_UPDATE, leaderboardID, s.config.Stream.UpdatePartitions), req
+}
 
-	// Broadcast to WebSocket clients using topic-based system
-	topic := socket.TopicLeaderboard + leaderboardID
+// broadcastEntryUpdate sends an entry's new score to the WebSocket clients of its leaderboard.
+func (s *LeaderBoardSvc) broadcastEntryUpdate(leaderboard *model.Leaderboard, entryID string, score float64) {
+	topic := socket.LeaderboardTopic(leaderboard.TenantID, leaderboard.ID)
 	go s.broadcaster.Broadcast(topic, socket.MessageTypeEntryUpdate, map[string]any{
 		"entryId": entryID,
 		"score":   score,
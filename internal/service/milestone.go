@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/hiamthach108/simplerank/presentation/socket"
+)
+
+type IMilestoneSvc interface {
+	Evaluate(ctx context.Context, leaderboard *model.Leaderboard, entryID string, prevRank int64, prevScore float64, rank int64, score float64)
+}
+
+type MilestoneSvc struct {
+	logger        logger.ILogger
+	cache         cache.ICache
+	milestoneRepo repository.IMilestoneRepository
+	broadcaster   socket.IBroadcaster
+}
+
+func NewMilestoneSvc(
+	logger logger.ILogger,
+	cache cache.ICache,
+	milestoneRepo repository.IMilestoneRepository,
+	broadcaster socket.IBroadcaster,
+) IMilestoneSvc {
+	return &MilestoneSvc{
+		logger:        logger,
+		cache:         cache,
+		milestoneRepo: milestoneRepo,
+		broadcaster:   broadcaster,
+	}
+}
+
+// Evaluate fires every milestone of the leaderboard crossed by moving an entry from its
+// previous standing (prevRank 0 when the entry is new) to the current one.
+func (s *MilestoneSvc) Evaluate(ctx context.Context, leaderboard *model.Leaderboard, entryID string, prevRank int64, prevScore float64, rank int64, score float64) {
+	for _, rule := range leaderboard.Milestones {
+		if prevRank > 0 && rule.Reached(prevRank, prevScore) {
+			continue
+		}
+		if !rule.Reached(rank, score) {
+			continue
+		}
+
+		m := &model.Milestone{
+			LeaderboardID: leaderboard.ID,
+			EntryID:       entryID,
+			Key:           rule.Key(),
+			Rank:          rank,
+			Score:         score,
+		}
+		created, err := s.milestoneRepo.CreateIfNotExists(ctx, m)
+		if err != nil {
+			s.logger.Error("[MilestoneSvc] failed to record milestone", "leaderboard", leaderboard.ID, "entry", entryID, "milestone", m.Key, "error", err)
+			continue
+		}
+		if !created {
+			// Already fired for this entry
+			continue
+		}
+
+		s.fire(ctx, leaderboard.TenantID, dto.MilestoneEvent{}.FromModel(m, rule))
+	}
+}
+
+func (s *MilestoneSvc) fire(ctx context.Context, tenantID string, event dto.MilestoneEvent) {
+	if err := s.cache.Publish(ctx, constants.STREAM_LEADERBOARD_MILESTONE, event); err != nil {
+		s.logger.Error("[MilestoneSvc] failed to publish milestone event", "leaderboard", event.LeaderboardID, "entry", event.EntryID, "error", err)
+	}
+
+	s.broadcaster.Broadcast(socket.LeaderboardTopic(tenantID, event.LeaderboardID), socket.MessageTypeMilestone, event)
+	s.broadcaster.BroadcastToUser(tenantID, event.EntryID, socket.MessageTypeMilestone, event)
+}
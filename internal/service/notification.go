@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+)
+
+type INotificationSvc interface {
+	GetPreferences(ctx context.Context, userID string) ([]dto.NotificationPreferenceDto, error)
+	UpdatePreference(ctx context.Context, userID string, req dto.UpdateNotificationPreferenceReq) (*dto.NotificationPreferenceDto, error)
+	FilterMuted(ctx context.Context, leaderboardID string, userIDs []string) ([]string, error)
+}
+
+type NotificationSvc struct {
+	logger   logger.ILogger
+	prefRepo repository.INotificationPreferenceRepository
+}
+
+func NewNotificationSvc(logger logger.ILogger, prefRepo repository.INotificationPreferenceRepository) INotificationSvc {
+	return &NotificationSvc{
+		logger:   logger,
+		prefRepo: prefRepo,
+	}
+}
+
+// GetPreferences retrieves all notification preferences of a user.
+func (s *NotificationSvc) GetPreferences(ctx context.Context, userID string) ([]dto.NotificationPreferenceDto, error) {
+	prefs, err := s.prefRepo.FindByUser(ctx, tenant.FromContext(ctx), userID)
+	if err != nil {
+		s.logger.Error("[NotificationSvc] failed to get preferences", "user", userID, "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	resp := make([]dto.NotificationPreferenceDto, 0, len(prefs))
+	for _, pref := range prefs {
+		resp = append(resp, dto.NotificationPreferenceDto{}.FromModel(&pref))
+	}
+
+	return resp, nil
+}
+
+// UpdatePreference creates or updates a user's notification preference.
+func (s *NotificationSvc) UpdatePreference(ctx context.Context, userID string, req dto.UpdateNotificationPreferenceReq) (*dto.NotificationPreferenceDto, error) {
+	if userID == "" {
+		return nil, errorx.New(errorx.ErrBadRequest, "userId is required")
+	}
+
+	m := req.ToModel(userID)
+	m.TenantID = tenant.FromContext(ctx)
+
+	pref, err := s.prefRepo.Upsert(ctx, m)
+	if err != nil {
+		s.logger.Error("[NotificationSvc] failed to update preference", "user", userID, "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	resp := dto.NotificationPreferenceDto{}.FromModel(pref)
+	return &resp, nil
+}
+
+// FilterMuted returns the userIDs that have not muted overtaken notifications for the leaderboard.
+func (s *NotificationSvc) FilterMuted(ctx context.Context, leaderboardID string, userIDs []string) ([]string, error) {
+	muted, err := s.prefRepo.FindMutedUserIDs(ctx, tenant.FromContext(ctx), leaderboardID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(muted) == 0 {
+		return userIDs, nil
+	}
+
+	mutedSet := make(map[string]struct{}, len(muted))
+	for _, id := range muted {
+		mutedSet[id] = struct{}{}
+	}
+
+	var result []string
+	for _, id := range userIDs {
+		if _, ok := mutedSet[id]; !ok {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+)
+
+// DefaultOutboxBatchSize is the number of outbox events relayed at once unless configured.
+const DefaultOutboxBatchSize = 100
+
+type IOutboxSvc interface {
+	Publish(ctx context.Context, stream string, message any) error
+	PublishInTx(ctx context.Context, tx repository.IOutboxRepository, stream string, message any) error
+	Relay(ctx context.Context, token int64) (int, error)
+	Stats(ctx context.Context) (*dto.OutboxStats, error)
+}
+
+type OutboxSvc struct {
+	config     *config.AppConfig
+	logger     logger.ILogger
+	cache      cache.ICache
+	outboxRepo repository.IOutboxRepository
+
+	// mu guards the horizon the relay waits to settle and the Seq up to which the outbox settled
+	mu         sync.Mutex
+	pending    *repository.OutboxHorizon
+	settledSeq uint64
+}
+
+func NewOutboxSvc(config *config.AppConfig, logger logger.ILogger, cache cache.ICache, outboxRepo repository.IOutboxRepository) IOutboxSvc {
+	return &OutboxSvc{
+		config:     config,
+		logger:     logger,
+		cache:      cache,
+		outboxRepo: outboxRepo,
+	}
+}
+
+// outboxDecoders decode the payloads of the streams whose events go through the outbox, by the
+// stream they belong to, into the messages published to them.
+var outboxDecoders = map[string]func(payload []byte) (any, error){
+	constants.STREAM_LEADERBOARD_UPDATE: decodeOutboxPayload[dto.CreateHistoryReq],
+}
+
+func decodeOutboxPayload[T any](payload []byte) (any, error) {
+	var message T
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Publish adds a message to the outbox, from which the relay publishes it to the stream after
+// the events added before it. The message is stored when Publish returns.
+func (s *OutboxSvc) Publish(ctx context.Context, stream string, message any) error {
+	return s.PublishInTx(ctx, s.outboxRepo, stream, message)
+}
+
+// PublishInTx adds a message to the outbox with a repository bound to the caller's transaction,
+// so the message is only published if the transaction commits.
+func (s *OutboxSvc) PublishInTx(ctx context.Context, tx repository.IOutboxRepository, stream string, message any) error {
+	if _, ok := outboxDecoders[cache.BaseStream(stream)]; !ok {
+		return fmt.Errorf("stream %s cannot be relayed from the outbox", stream)
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(ctx, &model.OutboxEvent{Stream: stream, Payload: payload}); err != nil {
+		s.logger.Error("[OutboxSvc] failed to add event to the outbox", "stream", stream, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Relay publishes the events waiting in the outbox in order, stopping at the first one that
+// fails to publish so none overtakes it, and returns how many were published. It runs on the
+// leader only, with the fencing token of its leadership, and fails with repository.ErrFenced
+// once a later leader relayed the outbox. Events are published at least once: an event may be
+// published again if removing it from the outbox fails.
+func (s *OutboxSvc) Relay(ctx context.Context, token int64) (int, error) {
+	batchSize := s.config.Outbox.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultOutboxBatchSize
+	}
+
+	maxSeq, err := s.settle(ctx)
+	if err != nil {
+		s.logger.Error("[OutboxSvc] failed to settle the outbox", "error", err)
+		return 0, err
+	}
+
+	relayed := 0
+	err = s.outboxRepo.RunFenced(ctx, token, func(tx repository.IOutboxRepository) error {
+		events, err := tx.FindOldest(ctx, maxSeq, batchSize)
+		if err != nil {
+			return err
+		}
+
+		done := make([]uint64, 0, len(events))
+		for _, event := range events {
+			if err := s.relay(ctx, event); err != nil {
+				s.logger.Warn("[OutboxSvc] failed to relay event", "seq", event.Seq, "stream", event.Stream, "attempts", event.Attempts+1, "error", err)
+				if err := tx.MarkFailed(ctx, event.Seq, err.Error()); err != nil {
+					s.logger.Error("[OutboxSvc] failed to record relay attempt", "seq", event.Seq, "error", err)
+				}
+				break
+			}
+			done = append(done, event.Seq)
+		}
+
+		if err := tx.DeleteBySeqs(ctx, done); err != nil {
+			return err
+		}
+		relayed = len(done)
+		return nil
+	})
+	if errors.Is(err, repository.ErrFenced) {
+		s.logger.Warn("[OutboxSvc] a later leader relays the outbox", "token", token)
+		return 0, err
+	}
+	if err != nil {
+		s.logger.Error("[OutboxSvc] failed to relay the outbox", "token", token, "error", err)
+		return 0, err
+	}
+	return relayed, nil
+}
+
+// settle returns the Seq up to which the outbox holds every event it will ever hold. Events
+// added concurrently may commit out of Seq order, so the relay only goes as far as a horizon
+// whose transactions all ended, and no event overtakes one still being committed.
+func (s *OutboxSvc) settle(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil {
+		horizon, err := s.outboxRepo.Horizon(ctx)
+		if err != nil {
+			return 0, err
+		}
+		s.pending = &horizon
+	}
+	settled, err := s.outboxRepo.Settled(ctx, *s.pending)
+	if err != nil {
+		return 0, err
+	}
+	if settled {
+		s.settledSeq = s.pending.Seq
+		s.pending = nil
+	}
+	return s.settledSeq, nil
+}
+
+// relay publishes one outbox event. Events that cannot be decoded are dropped, as retrying them
+// never succeeds.
+func (s *OutboxSvc) relay(ctx context.Context, event model.OutboxEvent) error {
+	decode, ok := outboxDecoders[cache.BaseStream(event.Stream)]
+	if !ok {
+		s.logger.Error("[OutboxSvc] dropping event of a stream that cannot be relayed", "seq", event.Seq, "stream", event.Stream)
+		return nil
+	}
+	message, err := decode(event.Payload)
+	if err != nil {
+		s.logger.Error("[OutboxSvc] dropping event that cannot be decoded", "seq", event.Seq, "stream", event.Stream, "error", err)
+		return nil
+	}
+	return s.cache.Publish(ctx, event.Stream, message)
+}
+
+// Stats reports how many events wait in the outbox and for how long the oldest has waited.
+func (s *OutboxSvc) Stats(ctx context.Context) (*dto.OutboxStats, error) {
+	count, oldest, err := s.outboxRepo.Stats(ctx)
+	if err != nil {
+		s.logger.Error("[OutboxSvc] failed to get outbox stats", "error", err)
+		return nil, err
+	}
+
+	stats := &dto.OutboxStats{Depth: count}
+	if oldest != nil {
+		stats.OldestAgeSeconds = time.Since(*oldest).Seconds()
+	}
+	return stats, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger/loggertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutbox is an in-memory outbox repository. Its relay fence is held for the duration of
+// RunFenced, like the fence row of the database one, and its horizons settle unless unsettled
+// is set.
+type memoryOutbox struct {
+	mu        sync.Mutex
+	relay     sync.Mutex
+	fence     int64
+	seq       uint64
+	events    []model.OutboxEvent
+	unsettled bool
+}
+
+func (r *memoryOutbox) Create(_ context.Context, event *model.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	event.Seq = r.seq
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryOutbox) RunFenced(_ context.Context, token int64, fn func(tx repository.IOutboxRepository) error) error {
+	r.relay.Lock()
+	defer r.relay.Unlock()
+	if token < r.fence {
+		return repository.ErrFenced
+	}
+	r.fence = token
+	return fn(r)
+}
+
+func (r *memoryOutbox) Horizon(_ context.Context) (repository.OutboxHorizon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return repository.OutboxHorizon{Seq: r.seq}, nil
+}
+
+func (r *memoryOutbox) Settled(_ context.Context, _ repository.OutboxHorizon) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.unsettled, nil
+}
+
+func (r *memoryOutbox) FindOldest(_ context.Context, maxSeq uint64, limit int) ([]model.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var results []model.OutboxEvent
+	for _, event := range r.events {
+		if event.Seq <= maxSeq && len(results) < limit {
+			results = append(results, event)
+		}
+	}
+	return results, nil
+}
+
+func (r *memoryOutbox) DeleteBySeqs(_ context.Context, seqs []uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = slices.DeleteFunc(r.events, func(event model.OutboxEvent) bool {
+		return slices.Contains(seqs, event.Seq)
+	})
+	return nil
+}
+
+func (r *memoryOutbox) MarkFailed(_ context.Context, seq uint64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i].Seq == seq {
+			r.events[i].Attempts++
+			r.events[i].LastError = reason
+		}
+	}
+	return nil
+}
+
+func (r *memoryOutbox) Stats(_ context.Context) (int64, *time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) == 0 {
+		return 0, nil, nil
+	}
+	return int64(len(r.events)), &r.events[0].CreatedAt, nil
+}
+
+// streamRecorder records the messages published to streams, failing those of entries in fail.
+type streamRecorder struct {
+	cache.ICache
+	fail      map[string]bool
+	published []dto.CreateHistoryReq
+}
+
+func (c *streamRecorder) Publish(_ context.Context, _ string, message any) error {
+	req := message.(dto.CreateHistoryReq)
+	if c.fail[req.EntryID] {
+		return errors.New("stream unavailable")
+	}
+	c.published = append(c.published, req)
+	return nil
+}
+
+func newTestOutboxSvc() (*OutboxSvc, *memoryOutbox, *streamRecorder) {
+	outbox := &memoryOutbox{}
+	streams := &streamRecorder{fail: make(map[string]bool)}
+	cfg := &config.AppConfig{}
+	cfg.Outbox.BatchSize = 2
+	return NewOutboxSvc(cfg, loggertest.NopLogger{}, streams, outbox).(*OutboxSvc), outbox, streams
+}
+
+func TestOutboxSvc_Publish(t *testing.T) {
+	ctx := context.Background()
+	stream := constants.STREAM_LEADERBOARD_UPDATE
+
+	t.Run("events are stored, not published", func(t *testing.T) {
+		svc, outbox, streams := newTestOutboxSvc()
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{LeaderboardID: "board", EntryID: "a", Score: 1}))
+
+		assert.Empty(t, streams.published)
+		require.Len(t, outbox.events, 1)
+		assert.Equal(t, stream, outbox.events[0].Stream)
+		assert.JSONEq(t, `{"leaderboardId":"board","entryId":"a","score":1,"metadata":null}`, string(outbox.events[0].Payload))
+	})
+
+	t.Run("partitions of a relayable stream are accepted", func(t *testing.T) {
+		svc, outbox, _ := newTestOutboxSvc()
+		partition := cache.PartitionStream(stream, "board", 4)
+		require.NoError(t, svc.Publish(ctx, partition, dto.CreateHistoryReq{EntryID: "a"}))
+		assert.Equal(t, partition, outbox.events[0].Stream)
+	})
+
+	t.Run("streams that cannot be relayed are rejected", func(t *testing.T) {
+		svc, outbox, _ := newTestOutboxSvc()
+		assert.Error(t, svc.Publish(ctx, constants.STREAM_LEADERBOARD_MILESTONE, dto.MilestoneEvent{}))
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("PublishInTx writes to the transaction's outbox", func(t *testing.T) {
+		svc, outbox, _ := newTestOutboxSvc()
+		tx := &memoryOutbox{}
+		require.NoError(t, svc.PublishInTx(ctx, tx, stream, dto.CreateHistoryReq{EntryID: "a"}))
+		assert.Empty(t, outbox.events)
+		assert.Len(t, tx.events, 1)
+	})
+}
+
+func TestOutboxSvc_Relay(t *testing.T) {
+	ctx := context.Background()
+	stream := constants.STREAM_LEADERBOARD_UPDATE
+
+	t.Run("events are published in order, a batch at a time", func(t *testing.T) {
+		svc, outbox, streams := newTestOutboxSvc()
+		for _, entryID := range []string{"a", "b", "c"} {
+			require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: entryID}))
+		}
+
+		relayed, err := svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, relayed)
+		relayed, err = svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+
+		entryIDs := make([]string, len(streams.published))
+		for i, req := range streams.published {
+			entryIDs[i] = req.EntryID
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, entryIDs)
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("a failed event holds back the ones after it", func(t *testing.T) {
+		svc, outbox, streams := newTestOutboxSvc()
+		for _, entryID := range []string{"a", "b"} {
+			require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: entryID}))
+		}
+		streams.fail["a"] = true
+
+		relayed, err := svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 0, relayed)
+		assert.Empty(t, streams.published)
+		require.Len(t, outbox.events, 2)
+		assert.Equal(t, 1, outbox.events[0].Attempts)
+		assert.Equal(t, "stream unavailable", outbox.events[0].LastError)
+
+		streams.fail["a"] = false
+		relayed, err = svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, relayed)
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("events wait for their horizon to settle", func(t *testing.T) {
+		svc, outbox, streams := newTestOutboxSvc()
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: "a"}))
+		outbox.unsettled = true
+
+		relayed, err := svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 0, relayed)
+
+		// Events added while the horizon settles wait for the next one
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: "b"}))
+		outbox.unsettled = false
+		relayed, err = svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+		relayed, err = svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+		assert.Len(t, streams.published, 2)
+	})
+
+	t.Run("undecodable events are dropped", func(t *testing.T) {
+		svc, outbox, streams := newTestOutboxSvc()
+		require.NoError(t, outbox.Create(ctx, &model.OutboxEvent{Stream: stream, Payload: []byte(`"not an update"`)}))
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: "a"}))
+
+		relayed, err := svc.Relay(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, relayed)
+		require.Len(t, streams.published, 1)
+		assert.Equal(t, "a", streams.published[0].EntryID)
+	})
+
+	t.Run("a previous leader is fenced off", func(t *testing.T) {
+		svc, _, streams := newTestOutboxSvc()
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: "a"}))
+		_, err := svc.Relay(ctx, 2)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Publish(ctx, stream, dto.CreateHistoryReq{EntryID: "b"}))
+		relayed, err := svc.Relay(ctx, 1)
+		assert.ErrorIs(t, err, repository.ErrFenced)
+		assert.Equal(t, 0, relayed)
+		require.Len(t, streams.published, 1)
+
+		relayed, err = svc.Relay(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed, "the current leader keeps relaying")
+	})
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/pkg/rating"
+)
+
+const maxMatchParticipants = 64
+
+// SubmitMatchResult rates the participants of a match on a rating leaderboard and
+// ranks them by their new ratings.
+func (s *LeaderBoardSvc) SubmitMatchResult(ctx context.Context, leaderboardID string, req dto.SubmitMatchResultReq) ([]dto.RatingChangeDto, error) {
+	if err := s.validateMetadata(req.Metadata, constants.MAX_ENTRY_METADATA_BYTES); err != nil {
+		return nil, err
+	}
+
+	placements := req.GetPlacements()
+	if err := s.validatePlacements(placements); err != nil {
+		return nil, err
+	}
+
+	leaderboard, err := s.getCacheLeaderboard(ctx, leaderboardID)
+	if err != nil {
+		return nil, err
+	}
+	if leaderboard.Type != model.LeaderboardTypeRating {
+		return nil, errorx.New(errorx.ErrBadRequest, "leaderboard does not accept match results")
+	}
+	if err := s.tenantSvc.AllowSubmission(ctx, leaderboard.TenantID); err != nil {
+		return nil, err
+	}
+
+	entryIDs := make([]string, len(placements))
+	for i, p := range placements {
+		entryIDs[i] = p.EntryID
+	}
+	maxEntries, err := s.entryQuota(ctx, leaderboard)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []dto.RatingChangeDto
+	err = s.ratingRepo.RunInTx(ctx, func(tx repository.IEntryRatingRepository) error {
+		// Concurrent first matches of a player both insert its rating, so the rows are created
+		// if missing and then read back locked. Rating rows are the leaderboard's entries, so
+		// the entry quota is enforced on them
+		if err := tx.CreateMissing(ctx, leaderboard.ID, s.defaultRatings(leaderboard.ID, entryIDs), maxEntries); err != nil {
+			return err
+		}
+		existing, err := tx.FindForUpdate(ctx, leaderboard.ID, entryIDs)
+		if err != nil {
+			return err
+		}
+
+		byEntry := make(map[string]model.EntryRating, len(existing))
+		for _, r := range existing {
+			byEntry[r.EntryID] = r
+		}
+
+		ratings := make([]model.EntryRating, len(placements))
+		players := make([]rating.Player, len(placements))
+		places := make([]int, len(placements))
+		for i, p := range placements {
+			r, ok := byEntry[p.EntryID]
+			if !ok {
+				return fmt.Errorf("rating of entry %s not found", p.EntryID)
+			}
+			ratings[i] = r
+			players[i] = rating.Player{Rating: r.Rating, Deviation: r.Deviation, Volatility: r.Volatility}
+			places[i] = p.Place
+		}
+
+		outcomes := rating.OutcomesFromPlaces(players, places)
+		scores := make(map[string]float64, len(ratings))
+		changes = make([]dto.RatingChangeDto, 0, len(ratings))
+		for i := range ratings {
+			next := s.ratePlayer(leaderboard.RatingSystem, players[i], outcomes[i])
+
+			change := dto.RatingChangeDto{
+				EntryID:        ratings[i].EntryID,
+				Place:          places[i],
+				PreviousRating: players[i].Rating,
+				Rating:         next.Rating,
+				Delta:          next.Rating - players[i].Rating,
+			}
+			if leaderboard.RatingSystem == model.RatingSystemGlicko2 {
+				change.Deviation = next.Deviation
+			}
+			changes = append(changes, change)
+
+			ratings[i].Rating = next.Rating
+			ratings[i].Deviation = next.Deviation
+			ratings[i].Volatility = next.Volatility
+			ratings[i].Matches++
+			scores[ratings[i].EntryID] = next.Rating
+		}
+
+		if err := tx.SaveAll(ctx, ratings); err != nil {
+			return err
+		}
+
+		// The history events are committed with the ratings, so neither is kept without the other
+		for _, change := range changes {
+			stream, event := s.historyEvent(leaderboard.ID, change.EntryID, change.Rating, s.ratingMetadata(req.Metadata, change))
+			if err := s.outboxSvc.PublishInTx(ctx, tx.Outbox(), stream, event); err != nil {
+				return err
+			}
+		}
+
+		// Update the sorted set while the rows are still locked so concurrent matches apply in order
+		return s.setScores(ctx, leaderboard, scores)
+	})
+	if errors.Is(err, repository.ErrQuotaExceeded) {
+		return nil, s.entryQuotaExceeded(maxEntries)
+	}
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to submit match result", "leaderboard", leaderboardID, "error", err)
+		return nil, errorx.Wrap(errorx.ErrUpdateScore, err)
+	}
+	s.invalidateTopEntries(ctx, leaderboard)
+
+	for _, change := range changes {
+		s.broadcastEntryUpdate(leaderboard, change.EntryID, change.Rating)
+	}
+
+	return changes, nil
+}
+
+// defaultRatings returns the starting ratings of the given entries, ordered by entry like the
+// rows FindForUpdate locks so concurrent matches take their locks in the same order.
+func (s *LeaderBoardSvc) defaultRatings(leaderboardID string, entryIDs []string) []model.EntryRating {
+	sorted := slices.Sorted(slices.Values(entryIDs))
+	ratings := make([]model.EntryRating, len(sorted))
+	for i, entryID := range sorted {
+		ratings[i] = model.EntryRating{
+			LeaderboardID: leaderboardID,
+			EntryID:       entryID,
+			Rating:        rating.DefaultRating,
+			Deviation:     rating.DefaultDeviation,
+			Volatility:    rating.DefaultVolatility,
+		}
+	}
+	return ratings
+}
+
+func (s *LeaderBoardSvc) ratePlayer(system model.RatingSystem, player rating.Player, outcomes []rating.Outcome) rating.Player {
+	if system == model.RatingSystemGlicko2 {
+		return rating.Glicko2(player, outcomes, rating.DefaultTau)
+	}
+	return rating.Elo(player, outcomes, rating.DefaultEloK)
+}
+
+// validatePlacements ensures a match has enough distinct participants with valid places.
+func (s *LeaderBoardSvc) validatePlacements(placements []dto.MatchPlacement) error {
+	if len(placements) < 2 {
+		return errorx.New(errorx.ErrBadRequest, "a match requires at least two participants")
+	}
+	if len(placements) > maxMatchParticipants {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("a match allows at most %d participants", maxMatchParticipants))
+	}
+
+	seen := make(map[string]struct{}, len(placements))
+	for _, p := range placements {
+		if p.EntryID == "" {
+			return errorx.New(errorx.ErrBadRequest, "participant entryId is required")
+		}
+		if p.Place < 1 {
+			return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("invalid place %d for %q", p.Place, p.EntryID))
+		}
+		if _, ok := seen[p.EntryID]; ok {
+			return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("duplicate participant %q", p.EntryID))
+		}
+		seen[p.EntryID] = struct{}{}
+	}
+	return nil
+}
+
+// ratingMetadata attaches the rating change of a participant to the match metadata recorded in history.
+func (s *LeaderBoardSvc) ratingMetadata(metadata map[string]any, change dto.RatingChangeDto) map[string]any {
+	result := make(map[string]any, len(metadata)+3)
+	maps.Copy(result, metadata)
+	result["place"] = change.Place
+	result["previousRating"] = change.PreviousRating
+	result["ratingDelta"] = change.Delta
+	return result
+}
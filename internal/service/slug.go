@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// ResolveLeaderboard resolves a leaderboard ID, slug or previous slug to the leaderboard it identifies.
+func (s *LeaderBoardSvc) ResolveLeaderboard(ctx context.Context, identifier string) (*dto.LeaderboardRef, error) {
+	ref, err := s.resolveIdentifier(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard, err := s.getCacheLeaderboard(ctx, ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	if leaderboard.Slug != nil {
+		ref.Slug = *leaderboard.Slug
+	}
+
+	return ref, nil
+}
+
+// resolveLeaderboardID returns the ID of the leaderboard identified by an ID, slug or previous slug.
+func (s *LeaderBoardSvc) resolveLeaderboardID(ctx context.Context, identifier string) (string, error) {
+	ref, err := s.resolveIdentifier(ctx, identifier)
+	if err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+func (s *LeaderBoardSvc) resolveIdentifier(ctx context.Context, identifier string) (*dto.LeaderboardRef, error) {
+	// Slugs never parse as UUIDs, so IDs need no lookup
+	if _, err := uuid.Parse(identifier); err == nil {
+		return &dto.LeaderboardRef{ID: identifier}, nil
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	key := s.slugCacheKey(tenantID, identifier)
+	var ref dto.LeaderboardRef
+	if err := s.cache.Get(ctx, key, &ref); err != nil {
+		// Concurrent misses of the same slug share a single lookup, which must not be cancelled
+		// by the caller that happened to start it
+		loaded, _, _ := s.loadGroup.Do(key, func() (any, error) {
+			return s.loadSlug(context.WithoutCancel(ctx), tenantID, identifier), nil
+		})
+		ref = loaded.(dto.LeaderboardRef)
+	}
+
+	if ref.ID == "" {
+		return nil, errorx.Wrap(errorx.ErrLeaderboardNotFound, nil)
+	}
+	return &ref, nil
+}
+
+// loadSlug looks up the leaderboard using a slug, or previously using it, and caches the result.
+// Slugs nobody uses are cached as an empty reference for a short while, so repeated lookups of
+// them do not reach the database.
+func (s *LeaderBoardSvc) loadSlug(ctx context.Context, tenantID string, slug string) dto.LeaderboardRef {
+	var ref dto.LeaderboardRef
+	ttl := cache.Jittered(cache.DefaultTTL)
+	if leaderboard := s.leaderboardRepo.FindOneBySlug(ctx, tenantID, slug); leaderboard != nil {
+		ref = dto.LeaderboardRef{ID: leaderboard.ID, Slug: slug}
+	} else if alias := s.leaderboardRepo.FindSlugAlias(ctx, tenantID, slug); alias != nil {
+		ref = dto.LeaderboardRef{ID: alias.LeaderboardID, IsAlias: true}
+	} else {
+		s.logger.Error("[LeaderboardSvc] leaderboard not found", "slug", slug)
+		ttl = cache.Jittered(cache.NegativeTTL)
+	}
+
+	if err := s.cache.Set(ctx, s.slugCacheKey(tenantID, slug), &ref, ttl); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to cache leaderboard slug", "slug", slug, "error", err)
+	}
+	return ref
+}
+
+// validateSlug ensures a slug is lowercase kebab-case within the length limits and cannot be
+// mistaken for an ID.
+func (s *LeaderBoardSvc) validateSlug(slug string) error {
+	if len(slug) < constants.MIN_LEADERBOARD_SLUG_LENGTH || len(slug) > constants.MAX_LEADERBOARD_SLUG_LENGTH {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("slug must be between %d and %d characters",
+			constants.MIN_LEADERBOARD_SLUG_LENGTH, constants.MAX_LEADERBOARD_SLUG_LENGTH))
+	}
+	if !slugPattern.MatchString(slug) {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("slug %q must contain lowercase letters, digits and single hyphens", slug))
+	}
+	if _, err := uuid.Parse(slug); err == nil {
+		return errorx.New(errorx.ErrBadRequest, fmt.Sprintf("slug %q must not be a UUID", slug))
+	}
+	return nil
+}
+
+// ensureSlugAvailable rejects a slug used or previously used by a leaderboard of the caller's
+// tenant other than leaderboardID.
+func (s *LeaderBoardSvc) ensureSlugAvailable(ctx context.Context, slug string, leaderboardID string) error {
+	tenantID := tenant.FromContext(ctx)
+	if leaderboard := s.leaderboardRepo.FindOneBySlug(ctx, tenantID, slug); leaderboard != nil && leaderboard.ID != leaderboardID {
+		return errorx.New(errorx.ErrConflict, fmt.Sprintf("slug %q is already taken", slug))
+	}
+	if alias := s.leaderboardRepo.FindSlugAlias(ctx, tenantID, slug); alias != nil && alias.LeaderboardID != leaderboardID {
+		return errorx.New(errorx.ErrConflict, fmt.Sprintf("slug %q is already taken", slug))
+	}
+	return nil
+}
+
+// changeSlug renames a leaderboard's slug, keeping the previous slug as a redirecting alias.
+// An empty slug removes the current one.
+func (s *LeaderBoardSvc) changeSlug(ctx context.Context, leaderboard *model.Leaderboard, slug string) error {
+	var newSlug *string
+	if slug != "" {
+		if err := s.validateSlug(slug); err != nil {
+			return err
+		}
+		if err := s.ensureSlugAvailable(ctx, slug, leaderboard.ID); err != nil {
+			return err
+		}
+		newSlug = &slug
+	}
+
+	oldSlug := leaderboard.Slug
+	if (oldSlug == nil && newSlug == nil) || (oldSlug != nil && newSlug != nil && *oldSlug == *newSlug) {
+		return nil
+	}
+
+	if err := s.leaderboardRepo.ChangeSlug(ctx, leaderboard.TenantID, leaderboard.ID, oldSlug, newSlug); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to change leaderboard slug", "id", leaderboard.ID, "slug", slug, "error", err)
+		return errorx.Wrap(errorx.ErrUpdateLeaderboard, err)
+	}
+
+	s.refreshCacheLeaderboard(ctx, leaderboard.TenantID, leaderboard.ID)
+
+	ctx = context.WithoutCancel(ctx)
+	var keys []string
+	if oldSlug != nil {
+		keys = append(keys, s.slugCacheKey(leaderboard.TenantID, *oldSlug))
+	}
+	if newSlug != nil {
+		keys = append(keys, s.slugCacheKey(leaderboard.TenantID, *newSlug))
+	}
+	for _, key := range keys {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			s.logger.Error("[LeaderboardSvc] failed to invalidate leaderboard cache", "key", key, "error", err)
+		}
+	}
+	if err := s.cache.PublishInvalidation(ctx, keys...); err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to publish slug invalidation", "id", leaderboard.ID, "error", err)
+	}
+
+	return nil
+}
+
+func (s *LeaderBoardSvc) slugCacheKey(tenantID string, slug string) string {
+	return constants.CACHE_LEADERBOARD_SLUG_PREFIX + tenantID + ":" + slug
+}
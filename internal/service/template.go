@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
+)
+
+var namePlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// CreateTemplate stores a reusable leaderboard configuration.
+func (s *LeaderBoardSvc) CreateTemplate(ctx context.Context, req dto.CreateTemplateReq) (*dto.TemplateDto, error) {
+	if strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.NamePattern) == "" {
+		return nil, errorx.New(errorx.ErrBadRequest, "name and namePattern are required")
+	}
+	if req.Type == "" {
+		req.Type = model.LeaderboardTypeStandard
+	}
+	if req.Type == model.LeaderboardTypeRating && req.RatingSystem == "" {
+		req.RatingSystem = model.RatingSystemElo
+	}
+	if req.UpdatePolicy == "" {
+		req.UpdatePolicy = model.UpdatePolicyLatest
+	}
+
+	// Templates are held to the same rules as the leaderboards they create
+	err := s.validateType(dto.CreateLeaderboardReq{
+		Type:             req.Type,
+		UpdatePolicy:     req.UpdatePolicy,
+		DecayHalfLifeSec: req.DecayHalfLifeSec,
+		RatingSystem:     req.RatingSystem,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateMilestones(req.Milestones); err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(req.Metadata, constants.MAX_LEADERBOARD_METADATA_BYTES); err != nil {
+		return nil, err
+	}
+	tags, err := s.normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	m := model.LeaderboardTemplate{
+		TenantID:        tenant.FromContext(ctx),
+		Name:            req.Name,
+		NamePattern:     req.NamePattern,
+		Description:     req.Description,
+		Type:            req.Type,
+		UpdatePolicy:    req.UpdatePolicy,
+		NotifyOvertaken: req.NotifyOvertaken,
+		Milestones:      req.Milestones,
+		Tags:            tags,
+	}
+	if req.Metadata != nil {
+		data, _ := json.Marshal(req.Metadata)
+		m.Metadata = data
+	}
+	switch req.Type {
+	case model.LeaderboardTypeTrending:
+		m.DecayHalfLifeSec = req.DecayHalfLifeSec
+	case model.LeaderboardTypeRating:
+		m.RatingSystem = req.RatingSystem
+	}
+
+	template, err := s.templateRepo.Create(ctx, &m)
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to create template", "name", req.Name, "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	var resp dto.TemplateDto
+	resp.FromModel(template)
+	return &resp, nil
+}
+
+// GetTemplate retrieves a leaderboard template by ID.
+func (s *LeaderBoardSvc) GetTemplate(ctx context.Context, templateID string) (*dto.TemplateDto, error) {
+	template := s.templateRepo.FindOneById(ctx, templateID)
+	if template == nil || template.TenantID != tenant.FromContext(ctx) {
+		return nil, errorx.New(errorx.ErrNotFound, "leaderboard template not found")
+	}
+
+	var resp dto.TemplateDto
+	resp.FromModel(template)
+	return &resp, nil
+}
+
+// GetListTemplates retrieves a page of leaderboard templates.
+func (s *LeaderBoardSvc) GetListTemplates(ctx context.Context, req dto.ListTemplatesReq) (*dto.PaginationResp[dto.TemplateDto], error) {
+	req.Normalize()
+
+	templates, total, err := s.templateRepo.GetList(ctx, tenant.FromContext(ctx), req)
+	if err != nil {
+		s.logger.Error("[LeaderboardSvc] failed to get list of templates", "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	items := make([]dto.TemplateDto, len(templates))
+	for i := range templates {
+		items[i].FromModel(&templates[i])
+	}
+
+	return &dto.PaginationResp[dto.TemplateDto]{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		HasNext:  int64(req.Offset()+len(items)) < total,
+	}, nil
+}
+
+// InstantiateTemplate creates one leaderboard per requested instance from a template.
+func (s *LeaderBoardSvc) InstantiateTemplate(ctx context.Context, templateID string, req dto.InstantiateTemplateReq) ([]dto.LeaderboardDto, error) {
+	if len(req.Leaderboards) == 0 {
+		return nil, errorx.New(errorx.ErrBadRequest, "at least one leaderboard is required")
+	}
+	if len(req.Leaderboards) > constants.MAX_TEMPLATE_INSTANCES {
+		return nil, errorx.New(errorx.ErrBadRequest, fmt.Sprintf("a template creates at most %d leaderboards at once", constants.MAX_TEMPLATE_INSTANCES))
+	}
+
+	template, err := s.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]dto.CreateLeaderboardReq, len(req.Leaderboards))
+	for i, instance := range req.Leaderboards {
+		if instance.ExpiredAt.IsZero() {
+			return nil, errorx.New(errorx.ErrBadRequest, "expiredAt is required for every leaderboard")
+		}
+		name, err := renderNamePattern(template.NamePattern, instance.Variables)
+		if err != nil {
+			return nil, err
+		}
+
+		reqs[i] = dto.CreateLeaderboardReq{
+			Name:             name,
+			Slug:             instance.Slug,
+			Description:      template.Description,
+			Type:             template.Type,
+			UpdatePolicy:     template.UpdatePolicy,
+			DecayHalfLifeSec: template.DecayHalfLifeSec,
+			RatingSystem:     template.RatingSystem,
+			ExpiredAt:        instance.ExpiredAt,
+			NotifyOvertaken:  template.NotifyOvertaken,
+			Milestones:       template.Milestones,
+			Tags:             template.Tags,
+			Metadata:         template.Metadata,
+		}
+	}
+
+	s.logger.Info("[LeaderboardSvc] Instantiating template", "template", templateID, "count", len(reqs))
+	return s.CreateLeaderboards(ctx, reqs)
+}
+
+// renderNamePattern replaces the {key} placeholders of a name pattern with their variables.
+func renderNamePattern(pattern string, variables map[string]string) (string, error) {
+	var missing string
+	name := namePlaceholder.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		key := placeholder[1 : len(placeholder)-1]
+		value, ok := variables[key]
+		if !ok && missing == "" {
+			missing = key
+		}
+		return value
+	})
+	if missing != "" {
+		return "", errorx.New(errorx.ErrBadRequest, fmt.Sprintf("missing variable %q for the name pattern", missing))
+	}
+	return name, nil
+}
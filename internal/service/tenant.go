@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/model"
+	"github.com/hiamthach108/simplerank/internal/repository"
+	"github.com/hiamthach108/simplerank/internal/shared/constants"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type ITenantSvc interface {
+	GetTenant(ctx context.Context, tenantID string) (*dto.TenantDto, error)
+	UpdateTenant(ctx context.Context, tenantID string, req dto.UpdateTenantReq) (*dto.TenantDto, error)
+	AllowSubmission(ctx context.Context, tenantID string) error
+	IssueAPIKey(ctx context.Context, tenantID string) (*dto.TenantAPIKeyDto, error)
+	RevokeAPIKey(ctx context.Context, tenantID string, keyID string) error
+	Authenticate(ctx context.Context, apiKey string) (string, error)
+}
+
+// rateWindowTTL keeps a per-second submission counter slightly longer than its window.
+const rateWindowTTL = 2 * time.Second
+
+// apiKeyBytes is the amount of randomness in an issued API key.
+const apiKeyBytes = 32
+
+type TenantSvc struct {
+	config     *config.AppConfig
+	logger     logger.ILogger
+	cache      cache.ICache
+	tenantRepo repository.ITenantRepository
+}
+
+func NewTenantSvc(
+	config *config.AppConfig,
+	logger logger.ILogger,
+	cache cache.ICache,
+	tenantRepo repository.ITenantRepository,
+) ITenantSvc {
+	return &TenantSvc{
+		config:     config,
+		logger:     logger,
+		cache:      cache,
+		tenantRepo: tenantRepo,
+	}
+}
+
+// GetTenant retrieves a tenant with its effective quotas. Tenants without a stored record
+// use the configured default quotas.
+func (s *TenantSvc) GetTenant(ctx context.Context, tenantID string) (*dto.TenantDto, error) {
+	var cached dto.TenantDto
+	if err := s.cache.Get(ctx, s.tenantCacheKey(tenantID), &cached); err == nil {
+		return &cached, nil
+	}
+
+	m := s.tenantRepo.FindOneById(ctx, tenantID)
+	if m == nil {
+		m = &model.Tenant{BaseModel: model.BaseModel{ID: tenantID}}
+	}
+	resp := s.toDto(m)
+
+	if err := s.cache.Set(ctx, s.tenantCacheKey(tenantID), resp, cache.Jittered(cache.DefaultTTL)); err != nil {
+		s.logger.Error("[TenantSvc] failed to cache tenant", "tenant", tenantID, "error", err)
+	}
+
+	return resp, nil
+}
+
+// UpdateTenant creates or updates a tenant's name and quota overrides.
+func (s *TenantSvc) UpdateTenant(ctx context.Context, tenantID string, req dto.UpdateTenantReq) (*dto.TenantDto, error) {
+	for _, quota := range []*int{req.MaxLeaderboards, req.MaxEntriesPerLeaderboard, req.MaxSubmissionsPerSec} {
+		if quota != nil && *quota < 0 {
+			return nil, errorx.New(errorx.ErrBadRequest, "quotas must not be negative")
+		}
+	}
+
+	m := s.tenantRepo.FindOneById(ctx, tenantID)
+	if m == nil {
+		m = &model.Tenant{BaseModel: model.BaseModel{ID: tenantID}}
+	}
+	req.Apply(m)
+
+	tenant, err := s.tenantRepo.Upsert(ctx, m)
+	if err != nil {
+		s.logger.Error("[TenantSvc] failed to update tenant", "tenant", tenantID, "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	if err := s.cache.Delete(ctx, s.tenantCacheKey(tenantID)); err != nil {
+		s.logger.Error("[TenantSvc] failed to invalidate tenant cache", "tenant", tenantID, "error", err)
+	}
+
+	return s.toDto(tenant), nil
+}
+
+// AllowSubmission counts a score or match submission against the tenant's per-second quota.
+// Submissions are let through when the counter cannot be reached.
+func (s *TenantSvc) AllowSubmission(ctx context.Context, tenantID string) error {
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.MaxSubmissionsPerSec <= 0 {
+		return nil
+	}
+
+	window := strconv.FormatInt(time.Now().Unix(), 10)
+	ttl := rateWindowTTL
+	count, err := s.cache.Incr(ctx, constants.CACHE_TENANT_RATE_PREFIX+tenantID+":"+window, &ttl)
+	if err != nil {
+		s.logger.Error("[TenantSvc] failed to count submission", "tenant", tenantID, "error", err)
+		return nil
+	}
+	if count > int64(tenant.MaxSubmissionsPerSec) {
+		return errorx.New(errorx.ErrRateLimit, fmt.Sprintf("tenant exceeded %d submissions per second", tenant.MaxSubmissionsPerSec))
+	}
+
+	return nil
+}
+
+// IssueAPIKey creates an API key authenticating callers as the tenant. The key itself is only
+// returned here; the tenant keeps its hash.
+func (s *TenantSvc) IssueAPIKey(ctx context.Context, tenantID string) (*dto.TenantAPIKeyDto, error) {
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+	key := hex.EncodeToString(secret)
+
+	m := &model.TenantAPIKey{TenantID: tenantID, KeyHash: s.hashAPIKey(key)}
+	if err := s.tenantRepo.CreateAPIKey(ctx, m); err != nil {
+		s.logger.Error("[TenantSvc] failed to issue api key", "tenant", tenantID, "error", err)
+		return nil, errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	return &dto.TenantAPIKeyDto{ID: m.ID, TenantID: tenantID, Key: key, CreatedAt: m.CreatedAt}, nil
+}
+
+// RevokeAPIKey deletes an API key of the tenant, which stops authenticating once the cached
+// lookup of it is dropped.
+func (s *TenantSvc) RevokeAPIKey(ctx context.Context, tenantID string, keyID string) error {
+	m := s.tenantRepo.FindAPIKey(ctx, tenantID, keyID)
+	if m == nil {
+		return errorx.New(errorx.ErrNotFound, "api key not found")
+	}
+	if err := s.tenantRepo.DeleteAPIKey(ctx, m.ID); err != nil {
+		s.logger.Error("[TenantSvc] failed to revoke api key", "tenant", tenantID, "error", err)
+		return errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	if err := s.cache.Delete(ctx, s.apiKeyCacheKey(m.KeyHash)); err != nil {
+		s.logger.Error("[TenantSvc] failed to invalidate api key cache", "tenant", tenantID, "error", err)
+		return errorx.Wrap(errorx.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the tenant an API key was issued to. Unknown keys are remembered for a
+// short while so guessing keys does not reach the database.
+func (s *TenantSvc) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	keyHash := s.hashAPIKey(apiKey)
+	key := s.apiKeyCacheKey(keyHash)
+
+	// Strings are cached unquoted, so the tenant is wrapped to read back as JSON
+	var ref struct {
+		TenantID string `json:"tenantId"`
+	}
+	if err := s.cache.Get(ctx, key, &ref); err != nil {
+		ttl := cache.Jittered(cache.DefaultTTL)
+		m, err := s.tenantRepo.FindAPIKeyByHash(ctx, keyHash)
+		switch {
+		case err == nil:
+			ref.TenantID = m.TenantID
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ttl = cache.Jittered(cache.NegativeTTL)
+		default:
+			// Only keys known to be missing are remembered, so an outage does not lock tenants out
+			s.logger.Error("[TenantSvc] failed to find api key", "error", err)
+			return "", errorx.Wrap(errorx.ErrInternal, err)
+		}
+		if err := s.cache.Set(ctx, key, ref, ttl); err != nil {
+			s.logger.Error("[TenantSvc] failed to cache api key", "error", err)
+		}
+	}
+
+	if ref.TenantID == "" {
+		return "", errorx.New(errorx.ErrUnauthorized, "invalid api key")
+	}
+	return ref.TenantID, nil
+}
+
+func (s *TenantSvc) toDto(m *model.Tenant) *dto.TenantDto {
+	quota := func(override *int, fallback int) int {
+		if override != nil {
+			return *override
+		}
+		return fallback
+	}
+
+	return &dto.TenantDto{
+		ID:                       m.ID,
+		Name:                     m.Name,
+		MaxLeaderboards:          quota(m.MaxLeaderboards, s.config.Tenant.MaxLeaderboards),
+		MaxEntriesPerLeaderboard: quota(m.MaxEntriesPerLeaderboard, s.config.Tenant.MaxEntriesPerLeaderboard),
+		MaxSubmissionsPerSec:     quota(m.MaxSubmissionsPerSec, s.config.Tenant.MaxSubmissionsPerSec),
+	}
+}
+
+func (s *TenantSvc) tenantCacheKey(tenantID string) string {
+	return constants.CACHE_TENANT_PREFIX + tenantID
+}
+
+func (s *TenantSvc) apiKeyCacheKey(keyHash string) string {
+	return constants.CACHE_TENANT_API_KEY_PREFIX + keyHash
+}
+
+func (s *TenantSvc) hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,4 +3,8 @@ package constants
 const (
 	CACHE_LEADERBOARD_PREFIX         = "leaderboards:"
 	CACHE_LEADERBOARD_ENTRIES_PREFIX = "leaderboard_entries:"
+	CACHE_LEADERBOARD_SLUG_PREFIX    = "leaderboard_slugs:"
+	CACHE_TENANT_PREFIX              = "tenants:"
+	CACHE_TENANT_RATE_PREFIX         = "tenant_rates:"
+	CACHE_TENANT_API_KEY_PREFIX      = "tenant_api_keys:"
 )
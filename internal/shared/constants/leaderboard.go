@@ -0,0 +1,24 @@
+package constants
+
+const (
+	// MAX_ENTRY_METADATA_BYTES caps the JSON-encoded size of the metadata attached to a score submission.
+	MAX_ENTRY_METADATA_BYTES = 4 * 1024
+
+	// MAX_LEADERBOARD_METADATA_BYTES caps the JSON-encoded size of a leaderboard's custom metadata.
+	MAX_LEADERBOARD_METADATA_BYTES = 16 * 1024
+
+	MAX_LEADERBOARD_TAGS       = 20
+	MAX_LEADERBOARD_TAG_LENGTH = 64
+
+	MIN_LEADERBOARD_SLUG_LENGTH = 3
+	MAX_LEADERBOARD_SLUG_LENGTH = 128
+
+	// MAX_LEADERBOARD_SHARDS caps the number of sorted sets a leaderboard's entries are spread over.
+	MAX_LEADERBOARD_SHARDS = 256
+
+	// LEADERBOARD_TOP_ENTRIES is the number of entries returned with a leaderboard's details.
+	LEADERBOARD_TOP_ENTRIES = 100
+)
+
+// MAX_TEMPLATE_INSTANCES caps the number of leaderboards created from a template in one request.
+const MAX_TEMPLATE_INSTANCES = 100
@@ -5,5 +5,9 @@ const (
 
 	STREAM_LEADERBOARD_UPDATE = "leaderboard_updates"
 
-	STREAM_HISTORY_CONSUMER_GROUP = "history-consumer-group"
+	STREAM_LEADERBOARD_MILESTONE = "leaderboard_milestones"
+
+	// STREAM_HISTORY_CONSUMER prefixes the consumer names of the history subscription, which are
+	// suffixed with the instance name so instances do not share pending entries.
+	STREAM_HISTORY_CONSUMER = "history-consumer"
 )
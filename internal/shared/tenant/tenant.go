@@ -0,0 +1,39 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of requests that carry no API key.
+const Default = "default"
+
+// Header optionally names the caller's tenant on HTTP and websocket requests; it must match the
+// tenant of the caller's API key.
+const Header = "X-Tenant-ID"
+
+// APIKeyHeader carries the API key that authenticates the caller as a tenant, or the admin key on
+// tenant administration routes.
+const APIKeyHeader = "X-API-Key"
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+type contextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant ID carried by ctx, or Default when there is none.
+func FromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(contextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return Default
+}
+
+// Valid reports whether a tenant ID is safe to embed in cache keys and topics.
+func Valid(tenantID string) bool {
+	return idPattern.MatchString(tenantID)
+}
@@ -1,12 +1,13 @@
 package cache
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hiamthach108/simplerank/config"
@@ -14,93 +15,357 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// Cache drivers selectable with config.Cache.Driver.
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
+// Redis deployments selectable with config.Cache.RedisMode.
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+// invalidationChannel is the pub/sub channel changed keys are announced on.
+const invalidationChannel = "cache_invalidations"
+
 type appCache struct {
 	serviceName string
 	logger      logger.ILogger
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
+	// opTimeout bounds every operation whose context has no earlier deadline; 0 disables it.
+	opTimeout  time.Duration
+	codecs     streamCodecs
+	policy     streamPolicy
+	retentions streamRetentions
+	// valueCodec encodes the values stored with Set; nil stores them as JSON.
+	valueCodec Codec
+	// trimmedAt holds when each stream was last trimmed, throttling trimming to streamTrimInterval.
+	trimmedAt sync.Map
+
+	invalidateMu       sync.RWMutex
+	invalidateHandlers []func(key string)
+	// listenMu guards subscribed, which is set once the invalidation channel is subscribed to.
+	listenMu   sync.Mutex
+	subscribed bool
 }
 
 func NewAppCache(config *config.AppConfig, logger logger.ILogger) (ICache, error) {
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     config.Cache.RedisHost + ":" + config.Cache.RedisPort,
-		Password: config.Cache.RedisPassword,
-		DB:       config.Cache.RedisDB,
-	})
+	switch config.Cache.Driver {
+	case "", DriverRedis:
+	case DriverMemory:
+		return NewMemoryCache(config, logger)
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", config.Cache.Driver)
+	}
+
+	codecs, err := newStreamCodecs(config)
+	if err != nil {
+		return nil, err
+	}
+	retentions, err := newStreamRetentions(config)
+	if err != nil {
+		return nil, err
+	}
+	valueCodec, err := newValueCodec(config)
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient, err := newRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := redisClient.Ping(ctx).Err(); err != nil {
+		_ = redisClient.Close()
 		logger.Error("Failed to connect to Redis", "error", err)
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	logger.Info("Connected to Redis successfully")
+	logger.Info("Connected to Redis successfully", "mode", config.Cache.RedisMode)
 
 	return &appCache{
 		serviceName: config.App.Name,
 		logger:      logger,
 		redisClient: redisClient,
+		opTimeout:   time.Duration(config.Cache.OperationTimeoutMs) * time.Millisecond,
+		codecs:      codecs,
+		policy:      newStreamPolicy(config),
+		retentions:  retentions,
+		valueCodec:  valueCodec,
 	}, nil
 }
 
+// newRedisClient builds the client for the configured Redis deployment.
+func newRedisClient(config *config.AppConfig) (redis.UniversalClient, error) {
+	addrs := []string{config.Cache.RedisHost + ":" + config.Cache.RedisPort}
+	if config.Cache.RedisAddrs != "" {
+		addrs = addrs[:0]
+		for _, addr := range strings.Split(config.Cache.RedisAddrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	switch config.Cache.RedisMode {
+	case "", RedisModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:     addrs[0],
+			Password: config.Cache.RedisPassword,
+			DB:       config.Cache.RedisDB,
+		}), nil
+	case RedisModeSentinel:
+		if config.Cache.RedisMasterName == "" {
+			return nil, errors.New("redis sentinel mode requires a master name")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    config.Cache.RedisMasterName,
+			SentinelAddrs: addrs,
+			Password:      config.Cache.RedisPassword,
+			DB:            config.Cache.RedisDB,
+		}), nil
+	case RedisModeCluster:
+		// Cluster deployments have a single database
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    addrs,
+			Password: config.Cache.RedisPassword,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", config.Cache.RedisMode)
+	}
+}
+
+// withTimeout bounds an operation by the configured timeout. Deadlines already set on ctx, such
+// as a request's, still apply when they are earlier.
+func (c *appCache) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.opTimeout)
+}
+
 // =============================
 // 🔹 Basic Cache Operations
 // =============================
 
-func (c *appCache) Set(key string, value any, expireTime *time.Duration) error {
+func (c *appCache) Set(ctx context.Context, key string, value any, expireTime *time.Duration) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	rKey := c.prefixedKey(key)
 
-	// Serialize value to JSON for complex types
+	// Serialize complex types with the value codec
 	var data any
 	switch v := value.(type) {
 	case string, int, int64, float64, bool:
 		// Primitive types can be stored directly
 		data = v
 	default:
-		// Serialize complex types to JSON
-		jsonData, err := json.Marshal(value)
+		encoded, err := c.values().Marshal(value)
 		if err != nil {
 			return fmt.Errorf("failed to marshal value: %w", err)
 		}
-		data = jsonData
+		data = encoded
 	}
 
-	return c.redisClient.Set(context.Background(), rKey, data, *expireTime).Err()
+	return c.redisClient.Set(ctx, rKey, data, *expireTime).Err()
 }
 
-func (c *appCache) Get(key string, data any) error {
+func (c *appCache) Get(ctx context.Context, key string, data any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	rKey := c.prefixedKey(key)
-	val, err := c.redisClient.Get(context.Background(), rKey).Result()
+	val, err := c.redisClient.Get(ctx, rKey).Result()
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal([]byte(val), data); err != nil {
+	if err := c.values().Unmarshal([]byte(val), data); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (c *appCache) Delete(key string) error {
-	rKey := c.prefixedKey(key)
-	return c.redisClient.Del(context.Background(), rKey).Err()
+// values returns the codec values are stored with.
+func (c *appCache) values() Codec {
+	if c.valueCodec == nil {
+		return jsonCodec{}
+	}
+	return c.valueCodec
 }
 
-func (c *appCache) Clear() error {
-	return c.redisClient.FlushAll(context.Background()).Err()
+// setVersionedScript stores an envelope unless the stored envelope has the same or a newer version.
+var setVersionedScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current then
+	local ok, decoded = pcall(cjson.decode, current)
+	if ok and type(decoded) == "table" and tonumber(decoded["version"]) and tonumber(decoded["version"]) >= tonumber(ARGV[2]) then
+		return 0
+	end
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+type versionedEnvelope struct {
+	Version int64           `json:"version"`
+	Data    json.RawMessage `json:"data"`
 }
 
-func (c *appCache) ClearWithPrefix(prefix string) error {
-	ctx := context.Background()
-	pattern := c.prefixedKey(fmt.Sprintf("%s*", prefix))
-	keys, err := c.redisClient.Keys(ctx, pattern).Result()
+func (c *appCache) SetVersioned(ctx context.Context, key string, value any, version int64, expireTime *time.Duration) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.prefixedKey(key)
+
+	data, err := json.Marshal(value)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	envelope, err := json.Marshal(versionedEnvelope{Version: version, Data: data})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
 	}
-	if len(keys) > 0 {
-		return c.redisClient.Del(ctx, keys...).Err()
+
+	stored, err := setVersionedScript.Run(ctx, c.redisClient, []string{rKey},
+		envelope, version, expireTime.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return stored == 1, nil
+}
+
+func (c *appCache) GetVersioned(ctx context.Context, key string, data any) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.prefixedKey(key)
+	val, err := c.redisClient.Get(ctx, rKey).Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	var envelope versionedEnvelope
+	if err := json.Unmarshal(val, &envelope); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return 0, err
+	}
+
+	return envelope.Version, nil
+}
+
+func (c *appCache) Delete(ctx context.Context, key string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(key); shards > 1 {
+		return c.shardedDelete(ctx, baseKey, shards)
+	}
+	rKey := c.prefixedKey(key)
+	tagged := c.taggedKey(key)
+	if rKey == tagged {
+		return c.redisClient.Del(ctx, rKey, histogramKey(rKey), decayEpochKey(rKey)).Err()
+	}
+
+	// The key may name a value or, under its hash tag, a board; they live in different slots
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, rKey)
+		pipe.Del(ctx, tagged, histogramKey(tagged), decayEpochKey(tagged))
+		return nil
+	})
+	return err
+}
+
+// incrScript increments a counter and sets its expiration only when the counter is created.
+var incrScript = redis.NewScript(`
+local value = redis.call("INCR", KEYS[1])
+if value == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return value
+`)
+
+// Incr increments a counter and returns its new value. The expiration is set when the
+// counter is created, so fixed-window counters reset on their own.
+func (c *appCache) Incr(ctx context.Context, key string, expireTime *time.Duration) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.prefixedKey(key)
+
+	return incrScript.Run(ctx, c.redisClient, []string{rKey}, expireTime.Milliseconds()).Int64()
+}
+
+func (c *appCache) Clear(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if cluster, ok := c.redisClient.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return node.FlushAll(ctx).Err()
+		})
+	}
+	return c.redisClient.FlushAll(ctx).Err()
+}
+
+func (c *appCache) ClearWithPrefix(ctx context.Context, prefix string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	patterns := []string{c.prefixedKey(fmt.Sprintf("%s*", prefix))}
+
+	// Every cluster master holds part of the keyspace, so each one is scanned, for plain and
+	// hash-tagged keys alike
+	if cluster, ok := c.redisClient.(*redis.ClusterClient); ok {
+		patterns = append(patterns, c.prefixedKey(fmt.Sprintf("{%s*", prefix)))
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return deleteMatching(ctx, node, patterns)
+		})
+	}
+	return deleteMatching(ctx, c.redisClient, patterns)
+}
+
+// deleteMatching deletes the keys of one node matching any of patterns. Keys are scanned rather
+// than listed with KEYS so large keyspaces do not block the node, and deleted one by one since
+// they may belong to different cluster slots.
+func deleteMatching(ctx context.Context, client redis.Cmdable, patterns []string) error {
+	for _, pattern := range patterns {
+		iter := client.Scan(ctx, 0, pattern, 500).Iterator()
+		var keys []string
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -110,18 +375,90 @@ func (c *appCache) ClearWithPrefix(prefix string) error {
 // =============================
 
 // AddScore adds or updates a member’s score in a leaderboard.
-func (c *appCache) AddScore(boardKey, member string, score float64) error {
-	rKey := c.prefixedKey(boardKey)
-	return c.redisClient.ZAdd(context.Background(), rKey, redis.Z{
+func (c *appCache) AddScore(ctx context.Context, boardKey, member string, score float64) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.taggedKey(boardKey)
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		rKey = c.shardKeys(baseKey, shards)[shardOf(member, shards)]
+	}
+	return c.redisClient.ZAdd(ctx, rKey, redis.Z{
 		Score:  score,
 		Member: member,
 	}).Err()
 }
 
+// AddScores adds or updates several members’ scores atomically in a single command.
+func (c *appCache) AddScores(ctx context.Context, boardKey string, scores map[string]float64) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if len(scores) == 0 {
+		return nil
+	}
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.shardedAddScores(ctx, baseKey, shards, scores)
+	}
+
+	rKey := c.taggedKey(boardKey)
+	members := make([]redis.Z, 0, len(scores))
+	for member, score := range scores {
+		members = append(members, redis.Z{
+			Score:  score,
+			Member: member,
+		})
+	}
+	return c.redisClient.ZAdd(ctx, rKey, members...).Err()
+}
+
+// Count returns the number of members in a leaderboard.
+func (c *appCache) Count(ctx context.Context, boardKey string) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.shardedCount(ctx, baseKey, shards)
+	}
+	rKey := c.taggedKey(boardKey)
+	return c.redisClient.ZCard(ctx, rKey).Result()
+}
+
 // GetTopN retrieves top N members with their scores in descending order.
-func (c *appCache) GetTopN(boardKey string, n int64) ([]LeaderboardEntry, error) {
-	rKey := c.prefixedKey(boardKey)
-	zResult, err := c.redisClient.ZRevRangeWithScores(context.Background(), rKey, 0, n-1).Result()
+func (c *appCache) GetTopN(ctx context.Context, boardKey string, n int64) ([]LeaderboardEntry, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.shardedRange(ctx, baseKey, shards, 0, n-1)
+	}
+	rKey := c.taggedKey(boardKey)
+	zResult, err := c.redisClient.ZRevRangeWithScores(ctx, rKey, 0, n-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, len(zResult))
+	for i, z := range zResult {
+		entries[i] = LeaderboardEntry{
+			Member: z.Member,
+			Score:  z.Score,
+		}
+	}
+
+	return entries, nil
+}
+
+// GetRange retrieves members between the 0-based start and stop positions (inclusive) in descending order.
+func (c *appCache) GetRange(ctx context.Context, boardKey string, start, stop int64) ([]LeaderboardEntry, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.shardedRange(ctx, baseKey, shards, start, stop)
+	}
+	rKey := c.taggedKey(boardKey)
+	zResult, err := c.redisClient.ZRevRangeWithScores(ctx, rKey, start, stop).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -137,15 +474,38 @@ func (c *appCache) GetTopN(boardKey string, n int64) ([]LeaderboardEntry, error)
 	return entries, nil
 }
 
+// GetRangeAfter retrieves up to count members ranked right below after in descending order, or
+// from the top when after is nil.
+func (c *appCache) GetRangeAfter(ctx context.Context, boardKey string, after *LeaderboardEntry, count int64) ([]LeaderboardEntry, error) {
+	if after == nil {
+		return c.GetRange(ctx, boardKey, 0, count-1)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	member := fmt.Sprint(after.Member)
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.rangeBelow(ctx, c.shardKeys(baseKey, shards), shardOf(member, shards), member, after.Score, math.Inf(-1), count)
+	}
+	return c.rangeBelow(ctx, []string{c.taggedKey(boardKey)}, 0, member, after.Score, math.Inf(-1), count)
+}
+
 // GetRank retrieves the rank (1-based) and score of a specific member.
-func (c *appCache) GetRank(boardKey, member string) (rank int64, score float64, err error) {
-	rKey := c.prefixedKey(boardKey)
-	rank, err = c.redisClient.ZRevRank(context.Background(), rKey, member).Result()
+func (c *appCache) GetRank(ctx context.Context, boardKey, member string) (rank int64, score float64, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		return c.shardedRank(ctx, baseKey, shards, member)
+	}
+	rKey := c.taggedKey(boardKey)
+	rank, err = c.redisClient.ZRevRank(ctx, rKey, member).Result()
 	if err != nil {
 		return 0, 0, err
 	}
 
-	score, err = c.redisClient.ZScore(context.Background(), rKey, member).Result()
+	score, err = c.redisClient.ZScore(ctx, rKey, member).Result()
 	if err != nil {
 		return 0, 0, err
 	}
@@ -154,15 +514,31 @@ func (c *appCache) GetRank(boardKey, member string) (rank int64, score float64,
 }
 
 // RemoveMember removes a player from the leaderboard.
-func (c *appCache) RemoveMember(boardKey, member string) error {
-	rKey := c.prefixedKey(boardKey)
-	return c.redisClient.ZRem(context.Background(), rKey, member).Err()
+func (c *appCache) RemoveMember(ctx context.Context, boardKey, member string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.taggedKey(boardKey)
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		rKey = c.shardKeys(baseKey, shards)[shardOf(member, shards)]
+	}
+	return removeMemberScript.Run(ctx, c.redisClient, []string{rKey, histogramKey(rKey)}, member).Err()
 }
 
 // GetAroundMember gets a window of players around a given member (for user’s local rank view)
-func (c *appCache) GetAroundMember(boardKey, member string, radius int64) ([]LeaderboardEntry, error) {
-	rKey := c.prefixedKey(boardKey)
-	rank, err := c.redisClient.ZRevRank(context.Background(), rKey, member).Result()
+func (c *appCache) GetAroundMember(ctx context.Context, boardKey, member string, radius int64) ([]LeaderboardEntry, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		rank, _, err := c.shardedRank(ctx, baseKey, shards, member)
+		if err != nil {
+			return nil, err
+		}
+		return c.shardedRange(ctx, baseKey, shards, rank-1-radius, rank-1+radius)
+	}
+	rKey := c.taggedKey(boardKey)
+	rank, err := c.redisClient.ZRevRank(ctx, rKey, member).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +549,7 @@ func (c *appCache) GetAroundMember(boardKey, member string, radius int64) ([]Lea
 	}
 	end := rank + radius
 
-	zResult, err := c.redisClient.ZRevRangeWithScores(context.Background(), rKey, start, end).Result()
+	zResult, err := c.redisClient.ZRevRangeWithScores(ctx, rKey, start, end).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -193,30 +569,133 @@ func (c *appCache) GetAroundMember(boardKey, member string, radius int64) ([]Lea
 // 🔹 Stream Operations
 // =============================
 
-func (c *appCache) Publish(stream string, message any) error {
-	rKey := c.prefixedKey(stream)
+func (c *appCache) Publish(ctx context.Context, stream string, message any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	// Encode to binary using gob
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	if err := encoder.Encode(message); err != nil {
-		return fmt.Errorf("failed to encode message: %w", err)
+	rKey := c.taggedKey(stream)
+
+	values, err := encodeStreamEntry(c.codecs.forStream(stream), message)
+	if err != nil {
+		return err
 	}
 
-	// Store as binary data field
-	return c.redisClient.XAdd(context.Background(), &redis.XAddArgs{
+	if err := c.redisClient.XAdd(ctx, &redis.XAddArgs{
 		Stream: rKey,
-		Values: map[string]any{
-			"data": buf.Bytes(),
-		},
-	}).Err()
+		Values: values,
+	}).Err(); err != nil {
+		return err
+	}
+
+	// The message is published; failing to trim only delays retention until the next publish
+	if err := c.trim(ctx, stream, rKey); err != nil {
+		c.logger.Warn("Failed to trim stream", "stream", stream, "error", err)
+	}
+	return nil
+}
+
+// trim applies the stream's retention with approximate trimming, which only drops whole nodes
+// of the stream and so is cheap. Entries still needed by a consumer group, pending or not yet
+// delivered, are never trimmed.
+func (c *appCache) trim(ctx context.Context, stream, rKey string) error {
+	retention := c.retentions.forStream(stream)
+	if retention.keepsAll() || !c.trimDue(rKey) {
+		return nil
+	}
+
+	floor, err := c.retentionFloor(ctx, rKey)
+	if err != nil {
+		return err
+	}
+
+	if retention.maxAge > 0 {
+		minID := fmt.Sprintf("%d-0", time.Now().Add(-retention.maxAge).UnixMilli())
+		if floor != "" && compareStreamIDs(floor, minID) < 0 {
+			c.logger.Warn("Stream retention held back by entries a consumer group still needs", "stream", stream, "id", floor)
+			minID = floor
+		}
+		return c.redisClient.XTrimMinIDApprox(ctx, rKey, minID, 0).Err()
+	}
+
+	length, err := c.redisClient.XLen(ctx, rKey).Result()
+	if err != nil {
+		return err
+	}
+	excess := length - retention.maxLen
+	if excess <= 0 {
+		return nil
+	}
+
+	var limit int64
+	if floor != "" {
+		// Trimming removes entries from the start of the stream, so it is limited to those
+		// checked to come before the floor
+		want := min(excess, streamTrimBatch)
+		older, err := c.redisClient.XRangeN(ctx, rKey, "-", "("+floor, want).Result()
+		if err != nil {
+			return err
+		}
+		if int64(len(older)) < want {
+			c.logger.Warn("Stream retention held back by entries a consumer group still needs", "stream", stream, "id", floor)
+		}
+		if len(older) == 0 {
+			return nil
+		}
+		limit = int64(len(older))
+	}
+	return c.redisClient.XTrimMaxLenApprox(ctx, rKey, retention.maxLen, limit).Err()
 }
 
-func (c *appCache) EnsureGroup(stream, group string) error {
-	rKey := c.prefixedKey(stream)
+// trimDue reports whether the stream was not trimmed within streamTrimInterval, and if so
+// records that it is being trimmed now.
+func (c *appCache) trimDue(rKey string) bool {
+	now := time.Now()
+	if last, ok := c.trimmedAt.Load(rKey); ok && now.Sub(last.(time.Time)) < streamTrimInterval {
+		return false
+	}
+	c.trimmedAt.Store(rKey, now)
+	return true
+}
+
+// retentionFloor returns the ID of the oldest entry some consumer group of the stream still
+// needs: its oldest pending entry, or else the entry after the last one delivered to it. It
+// returns "" when the stream has no group.
+func (c *appCache) retentionFloor(ctx context.Context, rKey string) (string, error) {
+	groups, err := c.redisClient.XInfoGroups(ctx, rKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	floor := ""
+	for _, group := range groups {
+		needed, err := nextStreamID(group.LastDeliveredID)
+		if err != nil {
+			return "", err
+		}
+		if group.Pending > 0 {
+			pending, err := c.redisClient.XPending(ctx, rKey, group.Name).Result()
+			if err != nil {
+				return "", err
+			}
+			if pending.Count > 0 && compareStreamIDs(pending.Lower, needed) < 0 {
+				needed = pending.Lower
+			}
+		}
+		if floor == "" || compareStreamIDs(needed, floor) < 0 {
+			floor = needed
+		}
+	}
+	return floor, nil
+}
+
+func (c *appCache) EnsureGroup(ctx context.Context, stream, group string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.taggedKey(stream)
 
 	err := c.redisClient.
-		XGroupCreateMkStream(context.Background(), rKey, group, "$").
+		XGroupCreateMkStream(ctx, rKey, group, "$").
 		Err()
 
 	// If group already exists → ignore
@@ -230,35 +709,249 @@ func (c *appCache) EnsureGroup(stream, group string) error {
 	return nil
 }
 
-func (c *appCache) Subscribe(stream string, group string, handler ConsumerHandler) error {
-	rKey := c.prefixedKey(stream)
+// Subscribe consumes new messages of a stream as handler.Consumer of group until ctx is done,
+// after claiming the group's messages left pending by consumers idle for the claim interval.
+// Messages are acknowledged once handled; failures are retried with backoff and then moved to
+// the stream's dead-letter stream, "<stream>:dead". Messages interrupted by ctx, including those
+// buffered for a batch, stay pending, as do those failing with ErrRetryLater until claimed again.
+func (c *appCache) Subscribe(ctx context.Context, stream string, group string, handler ConsumerHandler) error {
+	rKey := c.taggedKey(stream)
+	// The subscription runs until ctx is cancelled, so reads are not bound by the operation timeout
+	for _, worker := range handler.workers() {
+		go c.work(ctx, stream, rKey, group, worker)
+	}
+
+	return nil
+}
+
+// work runs one consumer of a subscription until ctx is done.
+func (c *appCache) work(ctx context.Context, stream, rKey, group string, handler ConsumerHandler) {
+	size := handler.batchSize()
+	c.claimPending(ctx, rKey, group, handler)
+	claimAt := time.Now().Add(c.policy.idle())
+
+	backoff := c.policy.backoff()
+	var batch []redis.XMessage
+	var flushAt time.Time
+	for ctx.Err() == nil {
+		// Messages left pending, such as those failing with ErrRetryLater, are claimed again once
+		// idle for the claim interval
+		if len(batch) == 0 && !time.Now().Before(claimAt) {
+			c.claimPending(ctx, rKey, group, handler)
+			claimAt = time.Now().Add(c.policy.idle())
+		}
+
+		// A partial batch only waits for more messages until it is due
+		block := streamReadBlock
+		if len(batch) > 0 {
+			block = max(time.Until(flushAt), time.Millisecond)
+		}
+
+		streams, err := c.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: handler.Consumer,
+			Streams:  []string{rKey, ">"},
+			Count:    int64(size - len(batch)),
+			Block:    block,
+		}).Result()
+		if err != nil && ctx.Err() == nil && !IsNil(err) {
+			c.logger.Error("Failed to read from stream", "stream", stream, "group", group, "error", err)
+			sleep(ctx, backoff)
+			backoff = min(backoff*2, maxReadBackoff)
+			continue
+		}
+		if err == nil {
+			backoff = c.policy.backoff()
+			for _, strm := range streams {
+				if len(batch) == 0 && len(strm.Messages) > 0 {
+					flushAt = time.Now().Add(handler.flushInterval())
+				}
+				batch = append(batch, strm.Messages...)
+			}
+		}
+
+		if len(batch) >= size || (len(batch) > 0 && !time.Now().Before(flushAt)) {
+			c.consume(ctx, rKey, group, handler, batch)
+			batch = nil
+		}
+	}
+}
+
+// claimPending takes over the group's messages that have been pending for longer than the
+// claim interval, such as those of a consumer that died, and consumes them.
+func (c *appCache) claimPending(ctx context.Context, rKey, group string, handler ConsumerHandler) {
+	start := "0-0"
+	for ctx.Err() == nil {
+		messages, next, err := c.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   rKey,
+			Group:    group,
+			Consumer: handler.Consumer,
+			MinIdle:  c.policy.idle(),
+			Start:    start,
+			Count:    int64(handler.batchSize()),
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger.Error("Failed to claim pending stream messages", "stream", rKey, "group", group, "error", err)
+			}
+			return
+		}
+
+		if len(messages) > 0 {
+			c.logger.Info("Claimed pending stream messages", "stream", rKey, "group", group, "count", len(messages))
+			c.consume(ctx, rKey, group, handler, messages)
+		}
+
+		if next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// consume handles a batch of messages and then acknowledges them. A batch that cannot be
+// handled is consumed again one message at a time, and a single message that cannot be handled
+// is moved to the dead-letter stream, like the messages the handler rejects. Messages failing
+// with ErrRetryLater are left pending.
+func (c *appCache) consume(ctx context.Context, rKey, group string, handler ConsumerHandler, messages []redis.XMessage) {
+	handleErr := c.policy.deliver(ctx, handler, parseStreamMessages(messages))
+	if handleErr != nil && ctx.Err() != nil {
+		// Left pending to be claimed again
+		return
+	}
+	if errors.Is(handleErr, ErrRetryLater) {
+		c.logger.Warn("Leaving failed stream messages pending", "stream", rKey, "count", len(messages), "error", handleErr)
+		return
+	}
+	var rejected RejectedMessages
+	if handleErr != nil && !errors.As(handleErr, &rejected) && len(messages) > 1 {
+		c.logger.Warn("Retrying failed stream batch one message at a time", "stream", rKey, "count", len(messages), "error", handleErr)
+		for _, message := range messages {
+			c.consume(ctx, rKey, group, handler, []redis.XMessage{message})
+		}
+		return
+	}
+
+	// Handled messages are acknowledged even if the subscription is stopping meanwhile
+	ackCtx, cancel := c.withTimeout(context.WithoutCancel(ctx))
+	defer cancel()
+
+	dead := deadLetters(messages, handleErr)
+	ids := settledIDs(messages, handleErr)
+	if len(ids) == 0 {
+		return
+	}
+	// The dead-letter stream shares the stream's hash tag, so both commands run in one transaction
+	_, err := c.redisClient.TxPipelined(ackCtx, func(pipe redis.Pipeliner) error {
+		for _, message := range messages {
+			deadErr, ok := dead[message.ID]
+			if !ok {
+				continue
+			}
+			c.logger.Error("Moving stream message to dead-letter stream", "stream", rKey, "id", message.ID, "error", deadErr)
+			pipe.XAdd(ackCtx, &redis.XAddArgs{
+				Stream: rKey + deadLetterSuffix,
+				Values: deadLetterValues(message.ID, message.Values, deadErr),
+			})
+		}
+		pipe.XAck(ackCtx, rKey, group, ids...)
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("Failed to acknowledge stream messages", "stream", rKey, "ids", ids, "error", err)
+	}
+}
+
+// =============================
+// 🔹 Invalidation (Pub/Sub)
+// =============================
+
+func (c *appCache) PublishInvalidation(ctx context.Context, keys ...string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keys: %w", err)
+	}
+	return c.redisClient.Publish(ctx, c.prefixedKey(invalidationChannel), payload).Err()
+}
+
+func (c *appCache) OnInvalidate(ctx context.Context, handler func(key string)) error {
+	// A single subscription per instance serves every handler; a failed one is retried by the
+	// next call
+	c.listenMu.Lock()
+	defer c.listenMu.Unlock()
+	if !c.subscribed {
+		if err := c.listenInvalidations(ctx); err != nil {
+			return err
+		}
+		c.subscribed = true
+	}
+
+	c.invalidateMu.Lock()
+	c.invalidateHandlers = append(c.invalidateHandlers, handler)
+	c.invalidateMu.Unlock()
+	return nil
+}
+
+// listenInvalidations subscribes to the invalidation channel within ctx and then dispatches
+// invalidations for the lifetime of the cache.
+func (c *appCache) listenInvalidations(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	pubsub := c.redisClient.Subscribe(ctx, c.prefixedKey(invalidationChannel))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
 	go func() {
-		for {
-			streams, err := c.redisClient.XReadGroup(context.Background(), &redis.XReadGroupArgs{
-				Group:    group,
-				Consumer: handler.Consumer,
-				Streams:  []string{rKey, ">"},
-				Count:    1,
-				Block:    0,
-			}).Result()
-			if err != nil {
-				c.logger.Error("Failed to read from stream", "stream", stream, "group", group, "error", err)
+		for msg := range pubsub.Channel() {
+			var keys []string
+			if err := json.Unmarshal([]byte(msg.Payload), &keys); err != nil {
+				c.logger.Error("Failed to decode cache invalidation", "error", err)
 				continue
 			}
 
-			for _, strm := range streams {
-				for _, message := range strm.Messages {
-					handler.Handler(message.Values)
-					// Acknowledge message
-					c.redisClient.XAck(context.Background(), rKey, group, message.ID)
+			c.invalidateMu.RLock()
+			for _, key := range keys {
+				for _, handler := range c.invalidateHandlers {
+					handler(key)
 				}
 			}
+			c.invalidateMu.RUnlock()
 		}
 	}()
 
 	return nil
 }
 
+// Close closes the Redis client, ending the invalidation subscription with it.
+func (c *appCache) Close() error {
+	return c.redisClient.Close()
+}
+
+// IsNil reports whether err means the requested key or member does not exist.
+func IsNil(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
+
+// taggedKey returns the Redis key of a leaderboard or stream. In cluster mode the key is
+// hash-tagged, so the keys derived from it, such as a board's histogram, share its slot and
+// multi-key operations on them stay on one node. Other modes keep plain keys.
+func (c *appCache) taggedKey(key string) string {
+	if _, ok := c.redisClient.(*redis.ClusterClient); !ok {
+		return c.prefixedKey(key)
+	}
+	return c.prefixedKey("{" + key + "}")
+}
+
 func (c *appCache) prefixedKey(key string) string {
 	return fmt.Sprintf("%s:%s", c.serviceName, key)
 }
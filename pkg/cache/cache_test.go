@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/hiamthach108/simplerank/config"
 	"github.com/hiamthach108/simplerank/pkg/logger"
 	"github.com/redis/go-redis/v9"
@@ -49,6 +50,41 @@ func createTestCache() *appCache {
 	return cache
 }
 
+// newMiniredisCache returns a Redis driver backed by miniredis, an in-process Redis that runs Lua
+// scripts, so the script-backed operations are tested without a Redis server.
+func newMiniredisCache(t *testing.T) *appCache {
+	server := miniredis.RunT(t)
+
+	// miniredis only expires keys when its clock is moved, so the clock follows the wall clock
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				server.FastForward(now.Sub(last))
+				last = now
+			}
+		}
+	}()
+
+	redisClient := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		close(stop)
+		_ = redisClient.Close()
+	})
+
+	return &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+	}
+}
+
 func TestNewAppCache(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping Redis connection test")
@@ -70,12 +106,18 @@ func TestNewAppCache(t *testing.T) {
 					Name: "test-service",
 				},
 				Cache: struct {
+					Driver               string `env:"CACHE_DRIVER"`
 					DefaultExpireTimeSec int    `env:"CACHE_DEFAULT_EXPIRE_TIME_SEC"`
 					CleanupIntervalHour  int    `env:"CACHE_CLEANUP_INTERVAL_HOUR"`
 					RedisHost            string `env:"REDIS_HOST"`
 					RedisPort            string `env:"REDIS_PORT"`
 					RedisPassword        string `env:"REDIS_PASSWORD"`
 					RedisDB              int    `env:"REDIS_DB"`
+					RedisMode            string `env:"REDIS_MODE"`
+					RedisAddrs           string `env:"REDIS_ADDRS"`
+					RedisMasterName      string `env:"REDIS_MASTER_NAME"`
+					OperationTimeoutMs   int    `env:"CACHE_OPERATION_TIMEOUT_MS"`
+					Codec                string `env:"CACHE_CODEC"`
 				}{
 					RedisHost:     "localhost",
 					RedisPort:     "6379",
@@ -141,6 +183,58 @@ func TestAppCache_prefixedKey(t *testing.T) {
 	}
 }
 
+func TestAppCache_taggedKey(t *testing.T) {
+	t.Run("plain key outside cluster mode", func(t *testing.T) {
+		cache := createTestCache()
+		cache.redisClient = redis.NewClient(&redis.Options{})
+		assert.Equal(t, "test-service:board", cache.taggedKey("board"))
+	})
+
+	t.Run("hash-tagged key in cluster mode", func(t *testing.T) {
+		cache := createTestCache()
+		cache.redisClient = redis.NewClusterClient(&redis.ClusterOptions{})
+		rKey := cache.taggedKey("board")
+		assert.Equal(t, "test-service:{board}", rKey)
+		assert.Equal(t, "test-service:{board}:histogram", histogramKey(rKey))
+	})
+}
+
+func TestNewRedisClient(t *testing.T) {
+	newConfig := func(mode, addrs, masterName string) *config.AppConfig {
+		cfg := &config.AppConfig{}
+		cfg.Cache.RedisHost = "localhost"
+		cfg.Cache.RedisPort = "6379"
+		cfg.Cache.RedisMode = mode
+		cfg.Cache.RedisAddrs = addrs
+		cfg.Cache.RedisMasterName = masterName
+		return cfg
+	}
+
+	t.Run("standalone by default", func(t *testing.T) {
+		client, err := newRedisClient(newConfig("", "", ""))
+		require.NoError(t, err)
+		defer client.Close()
+		assert.IsType(t, &redis.Client{}, client)
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		client, err := newRedisClient(newConfig(RedisModeCluster, "node1:6379, node2:6379", ""))
+		require.NoError(t, err)
+		defer client.Close()
+		assert.IsType(t, &redis.ClusterClient{}, client)
+	})
+
+	t.Run("sentinel requires a master name", func(t *testing.T) {
+		_, err := newRedisClient(newConfig(RedisModeSentinel, "sentinel:26379", ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := newRedisClient(newConfig("replica", "", ""))
+		assert.Error(t, err)
+	})
+}
+
 // Test with real Redis connection (integration test)
 func TestAppCache_Integration(t *testing.T) {
 	if testing.Short() {
@@ -177,12 +271,12 @@ func TestAppCache_Integration(t *testing.T) {
 		expireTime := 5 * time.Minute
 
 		// Test Set
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		// Test Get
 		var result string
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -198,12 +292,12 @@ func TestAppCache_Integration(t *testing.T) {
 		expireTime := 5 * time.Minute
 
 		// Test Set
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		// Test Get
 		var result TestStruct
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value.Name, result.Name)
 		assert.Equal(t, value.Count, result.Count)
@@ -215,22 +309,22 @@ func TestAppCache_Integration(t *testing.T) {
 		expireTime := 5 * time.Minute
 
 		// Set a value first
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		// Verify it exists
 		var result string
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 
 		// Delete it
-		err = cache.Delete(key)
+		err = cache.Delete(ctx, key)
 		assert.NoError(t, err)
 
 		// Verify it's gone
 		var deleted string
-		err = cache.Get(key, &deleted)
+		err = cache.Get(ctx, key, &deleted)
 		assert.Error(t, err)
 		assert.Equal(t, redis.Nil, err)
 	})
@@ -242,25 +336,25 @@ func TestAppCache_Integration(t *testing.T) {
 		// Set multiple keys with prefix
 		keys := []string{"test-prefix:1", "test-prefix:2", "other-key"}
 		for _, key := range keys {
-			err := cache.Set(key, "value", &expireTime)
+			err := cache.Set(ctx, key, "value", &expireTime)
 			assert.NoError(t, err)
 		}
 
 		// Clear with prefix
-		err := cache.ClearWithPrefix(prefix)
+		err := cache.ClearWithPrefix(ctx, prefix)
 		assert.NoError(t, err)
 
 		// Verify prefixed keys are gone
 		for _, key := range []string{"test-prefix:1", "test-prefix:2"} {
 			var result string
-			err := cache.Get(key, &result)
+			err := cache.Get(ctx, key, &result)
 			assert.Error(t, err)
 			assert.Equal(t, redis.Nil, err)
 		}
 
 		// Verify other key still exists
 		var result string
-		err = cache.Get("other-key", &result)
+		err = cache.Get(ctx, "other-key", &result)
 		assert.NoError(t, err)
 		assert.Equal(t, "value", result)
 	})
@@ -269,17 +363,17 @@ func TestAppCache_Integration(t *testing.T) {
 		boardKey := "test-leaderboard"
 
 		// Add scores
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
 
-		err = cache.AddScore(boardKey, "player2", 200.0)
+		err = cache.AddScore(ctx, boardKey, "player2", 200.0)
 		assert.NoError(t, err)
 
-		err = cache.AddScore(boardKey, "player3", 150.0)
+		err = cache.AddScore(ctx, boardKey, "player3", 150.0)
 		assert.NoError(t, err)
 
 		// Get top 3
-		topN, err := cache.GetTopN(boardKey, 3)
+		topN, err := cache.GetTopN(ctx, boardKey, 3)
 		assert.NoError(t, err)
 		assert.Len(t, topN, 3)
 
@@ -296,13 +390,13 @@ func TestAppCache_Integration(t *testing.T) {
 		boardKey := "test-leaderboard"
 
 		// Get rank for player2 (should be 1st)
-		rank, score, err := cache.GetRank(boardKey, "player2")
+		rank, score, err := cache.GetRank(ctx, boardKey, "player2")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), rank)
 		assert.Equal(t, 200.0, score)
 
 		// Get rank for player1 (should be 3rd)
-		rank, score, err = cache.GetRank(boardKey, "player1")
+		rank, score, err = cache.GetRank(ctx, boardKey, "player1")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(3), rank)
 		assert.Equal(t, 100.0, score)
@@ -312,15 +406,15 @@ func TestAppCache_Integration(t *testing.T) {
 		boardKey := "test-leaderboard"
 
 		// Remove player2
-		err := cache.RemoveMember(boardKey, "player2")
+		err := cache.RemoveMember(ctx, boardKey, "player2")
 		assert.NoError(t, err)
 
 		// Verify player2 is gone
-		_, _, err = cache.GetRank(boardKey, "player2")
+		_, _, err = cache.GetRank(ctx, boardKey, "player2")
 		assert.Error(t, err)
 
 		// Verify other players are still there
-		rank, _, err := cache.GetRank(boardKey, "player1")
+		rank, _, err := cache.GetRank(ctx, boardKey, "player1")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(2), rank) // Should now be 2nd instead of 3rd
 	})
@@ -329,7 +423,7 @@ func TestAppCache_Integration(t *testing.T) {
 		boardKey := "test-leaderboard"
 
 		// Get around player3 with radius 1
-		around, err := cache.GetAroundMember(boardKey, "player3", 1)
+		around, err := cache.GetAroundMember(ctx, boardKey, "player3", 1)
 		assert.NoError(t, err)
 		assert.Len(t, around, 2) // player3 and player1
 
@@ -338,19 +432,113 @@ func TestAppCache_Integration(t *testing.T) {
 		assert.Equal(t, "player1", around[1].Member)
 	})
 
+	t.Run("GetRange", func(t *testing.T) {
+		boardKey := "test-leaderboard"
+
+		// Skip the leader and fetch the next entry
+		entries, err := cache.GetRange(ctx, boardKey, 1, 1)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "player1", entries[0].Member)
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := cache.Count(ctx, "test-leaderboard")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("Incr", func(t *testing.T) {
+		ttl := time.Minute
+		value, err := cache.Incr(ctx, "test-counter", &ttl)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+
+		value, err = cache.Incr(ctx, "test-counter", &ttl)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), value)
+	})
+
+	t.Run("SetVersioned rejects stale versions", func(t *testing.T) {
+		testSetVersioned(t, cache)
+	})
+
+	t.Run("PublishInvalidation", func(t *testing.T) {
+		received := make(chan string, 1)
+		err := cache.OnInvalidate(ctx, func(key string) {
+			received <- key
+		})
+		assert.NoError(t, err)
+
+		err = cache.PublishInvalidation(ctx, "test-invalidated")
+		assert.NoError(t, err)
+
+		select {
+		case key := <-received:
+			assert.Equal(t, "test-invalidated", key)
+		case <-time.After(2 * time.Second):
+			t.Fatal("invalidation was not received")
+		}
+	})
+
 	t.Run("Clear", func(t *testing.T) {
 		// Clear all data
-		err := cache.Clear()
+		err := cache.Clear(ctx)
 		assert.NoError(t, err)
 
 		// Verify everything is gone
 		var result string
-		err = cache.Get("other-key", &result)
+		err = cache.Get(ctx, "other-key", &result)
 		assert.Error(t, err)
 		assert.Equal(t, redis.Nil, err)
 	})
 }
 
+// testSetVersioned runs the SetVersioned contract against a cache driver.
+func testSetVersioned(t *testing.T, cache ICache) {
+	ctx := context.Background()
+	key := "test-versioned"
+	expireTime := 5 * time.Minute
+
+	stored, err := cache.SetVersioned(ctx, key, "v2", 2, &expireTime)
+	assert.NoError(t, err)
+	assert.True(t, stored)
+
+	stored, err = cache.SetVersioned(ctx, key, "v1", 1, &expireTime)
+	assert.NoError(t, err)
+	assert.False(t, stored)
+
+	var result string
+	version, err := cache.GetVersioned(ctx, key, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.Equal(t, "v2", result)
+}
+
+// TestAppCache_Scripts runs the operations backed by Lua scripts on every test run, against
+// miniredis instead of a Redis server.
+func TestAppCache_Scripts(t *testing.T) {
+	cache := newMiniredisCache(t)
+
+	t.Run("SetVersioned", func(t *testing.T) {
+		testSetVersioned(t, cache)
+	})
+	for _, boardKey := range []string{"standing", ShardedBoardKey("sharded-standing", 4)} {
+		t.Run("UpdateScore on "+boardKey, func(t *testing.T) {
+			testScoreUpdates(t, cache, boardKey)
+		})
+	}
+	t.Run("UpdateScore with decay", func(t *testing.T) {
+		testDecayingScores(t, cache, "decaying")
+	})
+	t.Run("AddScoresTracked", func(t *testing.T) {
+		testScoreHistogram(t, cache)
+	})
+	t.Run("Locks", func(t *testing.T) {
+		testLocks(t, cache)
+	})
+}
+
 // Test error cases with mock Redis client
 func TestAppCache_ErrorCases(t *testing.T) {
 	t.Run("Set error", func(t *testing.T) {
@@ -466,11 +654,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := 42
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result int
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -480,11 +668,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := int64(9223372036854775807)
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result int64
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -494,11 +682,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := 3.14159
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result float64
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -508,11 +696,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := true
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result bool
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -522,11 +710,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := []string{"apple", "banana", "cherry"}
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result []string
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -536,11 +724,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		value := map[string]int{"a": 1, "b": 2, "c": 3}
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result map[string]int
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -567,11 +755,11 @@ func TestAppCache_Set_DifferentTypes(t *testing.T) {
 		}
 		expireTime := 5 * time.Minute
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		var result User
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value.Name, result.Name)
 		assert.Equal(t, value.Age, result.Age)
@@ -604,7 +792,7 @@ func TestAppCache_Get_NonExistentKey(t *testing.T) {
 	}
 
 	var result string
-	err := cache.Get("non-existent-key", &result)
+	err := cache.Get(ctx, "non-existent-key", &result)
 	assert.Error(t, err)
 	assert.Equal(t, redis.Nil, err)
 }
@@ -636,15 +824,15 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 		boardKey := "test-update-score"
 
 		// Add initial score
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
 
 		// Update score
-		err = cache.AddScore(boardKey, "player1", 200.0)
+		err = cache.AddScore(ctx, boardKey, "player1", 200.0)
 		assert.NoError(t, err)
 
 		// Verify updated score
-		rank, score, err := cache.GetRank(boardKey, "player1")
+		rank, score, err := cache.GetRank(ctx, boardKey, "player1")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), rank)
 		assert.Equal(t, 200.0, score)
@@ -654,13 +842,13 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 		boardKey := "test-topn-limit"
 
 		// Add only 2 players
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
-		err = cache.AddScore(boardKey, "player2", 200.0)
+		err = cache.AddScore(ctx, boardKey, "player2", 200.0)
 		assert.NoError(t, err)
 
 		// Request top 10 (more than available)
-		topN, err := cache.GetTopN(boardKey, 10)
+		topN, err := cache.GetTopN(ctx, boardKey, 10)
 		assert.NoError(t, err)
 		assert.Len(t, topN, 2)
 	})
@@ -668,10 +856,10 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 	t.Run("GetTopN with zero", func(t *testing.T) {
 		boardKey := "test-topn-zero"
 
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
 
-		topN, err := cache.GetTopN(boardKey, 0)
+		topN, err := cache.GetTopN(ctx, boardKey, 0)
 		assert.NoError(t, err)
 		assert.Len(t, topN, 0)
 	})
@@ -679,17 +867,17 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 	t.Run("GetRank for non-existent member", func(t *testing.T) {
 		boardKey := "test-rank-missing"
 
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
 
-		_, _, err = cache.GetRank(boardKey, "non-existent-player")
+		_, _, err = cache.GetRank(ctx, boardKey, "non-existent-player")
 		assert.Error(t, err)
 	})
 
 	t.Run("RemoveMember non-existent", func(t *testing.T) {
 		boardKey := "test-remove-missing"
 
-		err := cache.RemoveMember(boardKey, "non-existent-player")
+		err := cache.RemoveMember(ctx, boardKey, "non-existent-player")
 		assert.NoError(t, err) // Redis doesn't error on removing non-existent members
 	})
 
@@ -698,12 +886,12 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 
 		// Add players
 		for i := 1; i <= 10; i++ {
-			err := cache.AddScore(boardKey, string(rune('A'+i-1)), float64(i*10))
+			err := cache.AddScore(ctx, boardKey, string(rune('A'+i-1)), float64(i*10))
 			assert.NoError(t, err)
 		}
 
 		// Get around top player with large radius
-		around, err := cache.GetAroundMember(boardKey, "J", 100)
+		around, err := cache.GetAroundMember(ctx, boardKey, "J", 100)
 		assert.NoError(t, err)
 		assert.LessOrEqual(t, len(around), 10)
 		assert.Equal(t, "J", around[0].Member)
@@ -713,7 +901,7 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 		boardKey := "test-around-bottom"
 
 		// Get around bottom player
-		around, err := cache.GetAroundMember(boardKey, "A", 2)
+		around, err := cache.GetAroundMember(ctx, boardKey, "A", 2)
 		assert.NoError(t, err)
 		assert.Greater(t, len(around), 0)
 	})
@@ -722,14 +910,14 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 		boardKey := "test-same-score"
 
 		// Add multiple players with same score
-		err := cache.AddScore(boardKey, "player1", 100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", 100.0)
 		assert.NoError(t, err)
-		err = cache.AddScore(boardKey, "player2", 100.0)
+		err = cache.AddScore(ctx, boardKey, "player2", 100.0)
 		assert.NoError(t, err)
-		err = cache.AddScore(boardKey, "player3", 100.0)
+		err = cache.AddScore(ctx, boardKey, "player3", 100.0)
 		assert.NoError(t, err)
 
-		topN, err := cache.GetTopN(boardKey, 3)
+		topN, err := cache.GetTopN(ctx, boardKey, 3)
 		assert.NoError(t, err)
 		assert.Len(t, topN, 3)
 
@@ -742,12 +930,12 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 	t.Run("Negative scores", func(t *testing.T) {
 		boardKey := "test-negative-score"
 
-		err := cache.AddScore(boardKey, "player1", -100.0)
+		err := cache.AddScore(ctx, boardKey, "player1", -100.0)
 		assert.NoError(t, err)
-		err = cache.AddScore(boardKey, "player2", 50.0)
+		err = cache.AddScore(ctx, boardKey, "player2", 50.0)
 		assert.NoError(t, err)
 
-		topN, err := cache.GetTopN(boardKey, 2)
+		topN, err := cache.GetTopN(ctx, boardKey, 2)
 		assert.NoError(t, err)
 		assert.Len(t, topN, 2)
 
@@ -761,10 +949,10 @@ func TestAppCache_Leaderboard_EdgeCases(t *testing.T) {
 	t.Run("Float precision scores", func(t *testing.T) {
 		boardKey := "test-float-precision"
 
-		err := cache.AddScore(boardKey, "player1", 123.456789)
+		err := cache.AddScore(ctx, boardKey, "player1", 123.456789)
 		assert.NoError(t, err)
 
-		rank, score, err := cache.GetRank(boardKey, "player1")
+		rank, score, err := cache.GetRank(ctx, boardKey, "player1")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), rank)
 		assert.InDelta(t, 123.456789, score, 0.000001)
@@ -796,34 +984,34 @@ func TestAppCache_ClearWithPrefix_EdgeCases(t *testing.T) {
 
 	t.Run("ClearWithPrefix no matches", func(t *testing.T) {
 		expireTime := 5 * time.Minute
-		err := cache.Set("other-key", "value", &expireTime)
+		err := cache.Set(ctx, "other-key", "value", &expireTime)
 		assert.NoError(t, err)
 
-		err = cache.ClearWithPrefix("non-existent-prefix")
+		err = cache.ClearWithPrefix(ctx, "non-existent-prefix")
 		assert.NoError(t, err)
 
 		// Verify other key still exists
 		var result string
-		err = cache.Get("other-key", &result)
+		err = cache.Get(ctx, "other-key", &result)
 		assert.NoError(t, err)
 	})
 
 	t.Run("ClearWithPrefix empty prefix", func(t *testing.T) {
 		expireTime := 5 * time.Minute
-		err := cache.Set("key1", "value1", &expireTime)
+		err := cache.Set(ctx, "key1", "value1", &expireTime)
 		assert.NoError(t, err)
-		err = cache.Set("key2", "value2", &expireTime)
+		err = cache.Set(ctx, "key2", "value2", &expireTime)
 		assert.NoError(t, err)
 
 		// Empty prefix with * should match all keys with service prefix
-		err = cache.ClearWithPrefix("")
+		err = cache.ClearWithPrefix(ctx, "")
 		assert.NoError(t, err)
 
 		// All keys should be cleared
 		var result string
-		err = cache.Get("key1", &result)
+		err = cache.Get(ctx, "key1", &result)
 		assert.Error(t, err)
-		err = cache.Get("key2", &result)
+		err = cache.Get(ctx, "key2", &result)
 		assert.Error(t, err)
 	})
 }
@@ -858,7 +1046,7 @@ func TestAppCache_StreamOperations(t *testing.T) {
 			"data":  "hello world",
 		}
 
-		err := cache.Publish(stream, message)
+		err := cache.Publish(ctx, stream, message)
 		assert.NoError(t, err)
 
 		// Verify message was added to stream
@@ -869,7 +1057,14 @@ func TestAppCache_StreamOperations(t *testing.T) {
 		}).Result()
 		assert.NoError(t, err)
 		assert.Len(t, messages, 1)
-		assert.Equal(t, "test", messages[0].Messages[0].Values["event"])
+
+		entry := messages[0].Messages[0]
+		assert.Equal(t, CodecJSON, entry.Values["codec"])
+		assert.Equal(t, "1", entry.Values["schema_version"])
+
+		var decoded map[string]interface{}
+		require.NoError(t, parseStreamMessage(entry.ID, entry.Values).Decode(&decoded))
+		assert.Equal(t, "test", decoded["event"])
 	})
 
 	t.Run("EnsureGroup creates group", func(t *testing.T) {
@@ -877,14 +1072,14 @@ func TestAppCache_StreamOperations(t *testing.T) {
 		group := "test-group"
 
 		// Publish a message first to create the stream
-		err := cache.Publish(stream, map[string]interface{}{"init": "true"})
+		err := cache.Publish(ctx, stream, map[string]interface{}{"init": "true"})
 		assert.NoError(t, err)
 
-		err = cache.EnsureGroup(stream, group)
+		err = cache.EnsureGroup(ctx, stream, group)
 		assert.NoError(t, err)
 
 		// Calling again should not error
-		err = cache.EnsureGroup(stream, group)
+		err = cache.EnsureGroup(ctx, stream, group)
 		assert.NoError(t, err)
 	})
 
@@ -893,26 +1088,28 @@ func TestAppCache_StreamOperations(t *testing.T) {
 		group := "test-group-subscribe"
 
 		// Publish initial message to create stream
-		err := cache.Publish(stream, map[string]interface{}{"init": "true"})
+		err := cache.Publish(ctx, stream, map[string]interface{}{"init": "true"})
 		require.NoError(t, err)
 
 		// Create group
-		err = cache.EnsureGroup(stream, group)
+		err = cache.EnsureGroup(ctx, stream, group)
 		require.NoError(t, err)
 
 		// Set up message handler
 		messageReceived := make(chan map[string]interface{}, 1)
 		handler := ConsumerHandler{
 			Consumer: "consumer-1",
-			Handler: func(message any) {
-				if msg, ok := message.(map[string]interface{}); ok {
+			Handler: func(message StreamMessage) error {
+				var msg map[string]interface{}
+				if err := message.Decode(&msg); err == nil {
 					messageReceived <- msg
 				}
+				return nil
 			},
 		}
 
 		// Subscribe
-		err = cache.Subscribe(stream, group, handler)
+		err = cache.Subscribe(ctx, stream, group, handler)
 		require.NoError(t, err)
 
 		// Publish a new message
@@ -920,7 +1117,7 @@ func TestAppCache_StreamOperations(t *testing.T) {
 			"event": "test-event",
 			"value": "123",
 		}
-		err = cache.Publish(stream, testMessage)
+		err = cache.Publish(ctx, stream, testMessage)
 		require.NoError(t, err)
 
 		// Wait for message (with timeout)
@@ -938,11 +1135,11 @@ func TestAppCache_StreamOperations(t *testing.T) {
 		group := "test-group-multi"
 
 		// Publish initial message to create stream
-		err := cache.Publish(stream, map[string]interface{}{"init": "true"})
+		err := cache.Publish(ctx, stream, map[string]interface{}{"init": "true"})
 		require.NoError(t, err)
 
 		// Create group
-		err = cache.EnsureGroup(stream, group)
+		err = cache.EnsureGroup(ctx, stream, group)
 		require.NoError(t, err)
 
 		// Set up two consumers
@@ -951,27 +1148,29 @@ func TestAppCache_StreamOperations(t *testing.T) {
 
 		handler1 := ConsumerHandler{
 			Consumer: "consumer-1",
-			Handler: func(message any) {
+			Handler: func(message StreamMessage) error {
 				consumer1Received <- true
+				return nil
 			},
 		}
 
 		handler2 := ConsumerHandler{
 			Consumer: "consumer-2",
-			Handler: func(message any) {
+			Handler: func(message StreamMessage) error {
 				consumer2Received <- true
+				return nil
 			},
 		}
 
 		// Subscribe both consumers
-		err = cache.Subscribe(stream, group, handler1)
+		err = cache.Subscribe(ctx, stream, group, handler1)
 		require.NoError(t, err)
-		err = cache.Subscribe(stream, group, handler2)
+		err = cache.Subscribe(ctx, stream, group, handler2)
 		require.NoError(t, err)
 
 		// Publish messages
 		for i := 0; i < 2; i++ {
-			err = cache.Publish(stream, map[string]interface{}{"count": i})
+			err = cache.Publish(ctx, stream, map[string]interface{}{"count": i})
 			require.NoError(t, err)
 		}
 
@@ -1000,13 +1199,14 @@ func TestAppCache_StreamOperations(t *testing.T) {
 
 		handler := ConsumerHandler{
 			Consumer: "consumer-1",
-			Handler: func(message any) {
+			Handler: func(message StreamMessage) error {
 				// This shouldn't be called
+				return nil
 			},
 		}
 
 		// Subscribe without creating group first
-		err := cacheWithMock.Subscribe(stream, group, handler)
+		err := cacheWithMock.Subscribe(ctx, stream, group, handler)
 		require.NoError(t, err) // Subscribe itself doesn't error
 
 		// Give it a moment to try reading
@@ -1045,12 +1245,12 @@ func TestAppCache_Expiration(t *testing.T) {
 		value := "test-value"
 		expireTime := 1 * time.Second
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		// Immediately should exist
 		var result string
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 
@@ -1058,7 +1258,7 @@ func TestAppCache_Expiration(t *testing.T) {
 		time.Sleep(2 * time.Second)
 
 		// Should be gone
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.Error(t, err)
 		assert.Equal(t, redis.Nil, err)
 	})
@@ -1068,17 +1268,17 @@ func TestAppCache_Expiration(t *testing.T) {
 		value := "test-value"
 		expireTime := 0 * time.Second
 
-		err := cache.Set(key, value, &expireTime)
+		err := cache.Set(ctx, key, value, &expireTime)
 		assert.NoError(t, err)
 
 		// Should exist immediately
 		var result string
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 
 		// Should still exist after waiting
 		time.Sleep(1 * time.Second)
-		err = cache.Get(key, &result)
+		err = cache.Get(ctx, key, &result)
 		assert.NoError(t, err)
 		assert.Equal(t, value, result)
 	})
@@ -1128,8 +1328,9 @@ func TestConsumerHandler(t *testing.T) {
 		called := false
 		handler := ConsumerHandler{
 			Consumer: "test-consumer",
-			Handler: func(message any) {
+			Handler: func(message StreamMessage) error {
 				called = true
+				return nil
 			},
 		}
 
@@ -1137,7 +1338,7 @@ func TestConsumerHandler(t *testing.T) {
 		assert.NotNil(t, handler.Handler)
 
 		// Call handler
-		handler.Handler(nil)
+		handler.Handler(StreamMessage{})
 		assert.True(t, called)
 	})
 }
@@ -1147,3 +1348,13 @@ func TestDefaultTTL(t *testing.T) {
 		assert.Equal(t, time.Duration(1*time.Hour), DefaultTTL)
 	})
 }
+
+func TestJittered(t *testing.T) {
+	t.Run("Jittered stays within 10% of the TTL", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			ttl := *Jittered(time.Minute)
+			assert.GreaterOrEqual(t, ttl, 54*time.Second)
+			assert.LessOrEqual(t, ttl, 66*time.Second)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// =============================
+// 🔹 Codecs
+// =============================
+
+// Codec names selectable per stream.
+const (
+	CodecJSON     = "json"
+	CodecMsgPack  = "msgpack"
+	CodecProtobuf = "protobuf"
+	// CodecGob is only read, from entries written before streams carried a codec header.
+	CodecGob = "gob"
+)
+
+// Codec encodes messages written to streams and decodes them when read.
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// NewCodec returns the codec with the given name.
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case CodecJSON:
+		return jsonCodec{}, nil
+	case CodecMsgPack:
+		return msgpackCodec{}, nil
+	case CodecProtobuf:
+		return protobufCodec{}, nil
+	case CodecGob:
+		return gobCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return CodecJSON }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec names fields after their json tags, so both codecs produce the same documents.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return CodecMsgPack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+// ProtoMarshaler is implemented by messages written with the Protobuf codec, which encodes the
+// generated message they convert to.
+type ProtoMarshaler interface {
+	MarshalProto() (proto.Message, error)
+}
+
+// ProtoUnmarshaler is implemented by pointers to messages read with the Protobuf codec. NewProto
+// returns the empty generated message to decode into, and UnmarshalProto fills the message from it.
+type ProtoUnmarshaler interface {
+	NewProto() proto.Message
+	UnmarshalProto(message proto.Message) error
+}
+
+// protobufCodec encodes generated messages, and messages converting to them.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return CodecProtobuf }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Marshal(m)
+	case ProtoMarshaler:
+		message, err := m.MarshalProto()
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(message)
+	}
+	return nil, fmt.Errorf("%T has no Protobuf message", v)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	case ProtoUnmarshaler:
+		message := m.NewProto()
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		return m.UnmarshalProto(message)
+	}
+	return fmt.Errorf("%T has no Protobuf message", v)
+}
+
+func init() {
+	// Register the dynamic types produced by decoding arbitrary JSON so that
+	// stream messages carrying free-form metadata can be gob-encoded.
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return CodecGob }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// =============================
+// 🔹 Stream Messages
+// =============================
+
+// Fields of a stream entry.
+const (
+	streamFieldCodec         = "codec"
+	streamFieldSchemaVersion = "schema_version"
+	streamFieldData          = "data"
+)
+
+// DefaultSchemaVersion is stamped on messages that do not implement SchemaVersioner.
+const DefaultSchemaVersion = 1
+
+// SchemaVersioner is implemented by stream messages to stamp the version of their shape on the
+// entries they are written to, so consumers can tell old entries apart.
+type SchemaVersioner interface {
+	SchemaVersion() int
+}
+
+// StreamMessage is an entry read from a stream. Entries written before streams carried a codec
+// header are gob-encoded and have schema version 0.
+type StreamMessage struct {
+	ID            string
+	Codec         string
+	SchemaVersion int
+	Data          []byte
+}
+
+// Decode decodes the message into v with the codec it was written with.
+func (m StreamMessage) Decode(v any) error {
+	codec, err := NewCodec(m.Codec)
+	if err != nil {
+		return err
+	}
+	if err := codec.Unmarshal(m.Data, v); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+	return nil
+}
+
+// encodeStreamEntry encodes a message into the fields of a stream entry.
+func encodeStreamEntry(codec Codec, message any) (map[string]any, error) {
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	version := DefaultSchemaVersion
+	if versioner, ok := message.(SchemaVersioner); ok {
+		version = versioner.SchemaVersion()
+	}
+
+	return map[string]any{
+		streamFieldCodec:         codec.Name(),
+		streamFieldSchemaVersion: version,
+		streamFieldData:          data,
+	}, nil
+}
+
+// parseStreamMessage reads a stream entry as returned by Redis, with every field a string.
+func parseStreamMessage(id string, values map[string]any) StreamMessage {
+	message := StreamMessage{ID: id, Codec: CodecGob}
+	if codec, ok := values[streamFieldCodec].(string); ok {
+		message.Codec = codec
+		if version, ok := values[streamFieldSchemaVersion].(string); ok {
+			message.SchemaVersion, _ = strconv.Atoi(version)
+		}
+	}
+	if data, ok := values[streamFieldData].(string); ok {
+		message.Data = []byte(data)
+	}
+	return message
+}
+
+// streamCodecs selects the codec each stream is written with.
+type streamCodecs struct {
+	byStream map[string]Codec
+	fallback Codec
+}
+
+// writeCodec returns the codec with the given name, refusing codecs that are only read.
+func writeCodec(name string) (Codec, error) {
+	if name == CodecGob {
+		return nil, fmt.Errorf("codec %q can only be read", name)
+	}
+	return NewCodec(name)
+}
+
+func newStreamCodecs(config *config.AppConfig) (streamCodecs, error) {
+	codecs := streamCodecs{byStream: make(map[string]Codec)}
+
+	if config.Stream.Codec != "" {
+		codec, err := writeCodec(config.Stream.Codec)
+		if err != nil {
+			return codecs, err
+		}
+		codecs.fallback = codec
+	}
+
+	for _, pair := range strings.Split(config.Stream.Codecs, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		stream, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return codecs, fmt.Errorf("invalid stream codec %q, expected stream=codec", pair)
+		}
+		codec, err := writeCodec(strings.TrimSpace(name))
+		if err != nil {
+			return codecs, err
+		}
+		codecs.byStream[strings.TrimSpace(stream)] = codec
+	}
+
+	return codecs, nil
+}
+
+// forStream returns the codec a stream is written with, JSON unless configured otherwise.
+func (s streamCodecs) forStream(stream string) Codec {
+	if codec, ok := s.byStream[stream]; ok {
+		return codec
+	}
+	if codec, ok := s.byStream[BaseStream(stream)]; ok {
+		return codec
+	}
+	if s.fallback != nil {
+		return s.fallback
+	}
+	return jsonCodec{}
+}
+
+// =============================
+// 🔹 Cached Values
+// =============================
+
+// newValueCodec returns the codec values stored with Set are encoded with, JSON unless
+// configured otherwise.
+func newValueCodec(config *config.AppConfig) (Codec, error) {
+	if config.Cache.Codec == "" {
+		return jsonCodec{}, nil
+	}
+	return writeCodec(config.Cache.Codec)
+}
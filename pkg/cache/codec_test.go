@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type codecTestMessage struct {
+	LeaderboardID string    `json:"leaderboardId"`
+	Score         float64   `json:"score"`
+	Metadata      any       `json:"metadata"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func (codecTestMessage) SchemaVersion() int { return 3 }
+
+// The test message goes through a Struct, standing in for a generated message.
+func (m codecTestMessage) MarshalProto() (proto.Message, error) {
+	return structpb.NewStruct(map[string]any{
+		"leaderboardId": m.LeaderboardID,
+		"score":         m.Score,
+		"metadata":      m.Metadata,
+		"createdAt":     m.CreatedAt.Format(time.RFC3339Nano),
+	})
+}
+
+func (m *codecTestMessage) NewProto() proto.Message { return &structpb.Struct{} }
+
+func (m *codecTestMessage) UnmarshalProto(message proto.Message) error {
+	fields := message.(*structpb.Struct).AsMap()
+	createdAt, err := time.Parse(time.RFC3339Nano, fields["createdAt"].(string))
+	if err != nil {
+		return err
+	}
+	*m = codecTestMessage{
+		LeaderboardID: fields["leaderboardId"].(string),
+		Score:         fields["score"].(float64),
+		Metadata:      fields["metadata"],
+		CreatedAt:     createdAt,
+	}
+	return nil
+}
+
+func TestCodecs(t *testing.T) {
+	message := codecTestMessage{
+		LeaderboardID: "board",
+		Score:         42.5,
+		Metadata:      map[string]any{"source": "test"},
+		CreatedAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	for _, name := range []string{CodecJSON, CodecMsgPack, CodecProtobuf, CodecGob} {
+		t.Run(name+" round trip", func(t *testing.T) {
+			codec, err := NewCodec(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, codec.Name())
+
+			data, err := codec.Marshal(message)
+			require.NoError(t, err)
+
+			var decoded codecTestMessage
+			require.NoError(t, codec.Unmarshal(data, &decoded))
+			assert.Equal(t, message.LeaderboardID, decoded.LeaderboardID)
+			assert.Equal(t, message.Score, decoded.Score)
+			assert.True(t, message.CreatedAt.Equal(decoded.CreatedAt))
+			assert.Equal(t, "test", decoded.Metadata.(map[string]any)["source"])
+		})
+	}
+
+	t.Run("protobuf encodes generated messages", func(t *testing.T) {
+		data, err := protobufCodec{}.Marshal(structpb.NewStringValue("board"))
+		require.NoError(t, err)
+
+		var decoded structpb.Value
+		require.NoError(t, protobufCodec{}.Unmarshal(data, &decoded))
+		assert.Equal(t, "board", decoded.GetStringValue())
+
+		_, err = protobufCodec{}.Marshal(map[string]any{"score": 1})
+		assert.Error(t, err, "values without a Protobuf message are rejected")
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		_, err := NewCodec("xml")
+		assert.Error(t, err)
+	})
+}
+
+func TestStreamEntries(t *testing.T) {
+	t.Run("entries carry their codec and schema version", func(t *testing.T) {
+		entry, err := encodeStreamEntry(msgpackCodec{}, codecTestMessage{LeaderboardID: "board"})
+		require.NoError(t, err)
+		assert.Equal(t, CodecMsgPack, entry["codec"])
+		assert.Equal(t, 3, entry["schema_version"])
+
+		// Redis returns every field as a string
+		message := parseStreamMessage("1-0", map[string]any{
+			"codec":          CodecMsgPack,
+			"schema_version": "3",
+			"data":           string(entry["data"].([]byte)),
+		})
+		assert.Equal(t, 3, message.SchemaVersion)
+
+		var decoded codecTestMessage
+		require.NoError(t, message.Decode(&decoded))
+		assert.Equal(t, "board", decoded.LeaderboardID)
+	})
+
+	t.Run("entries without a header are legacy gob", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, gob.NewEncoder(&buf).Encode(codecTestMessage{LeaderboardID: "legacy"}))
+
+		message := parseStreamMessage("1-0", map[string]any{"data": buf.String()})
+		assert.Equal(t, CodecGob, message.Codec)
+		assert.Equal(t, 0, message.SchemaVersion)
+
+		var decoded codecTestMessage
+		require.NoError(t, message.Decode(&decoded))
+		assert.Equal(t, "legacy", decoded.LeaderboardID)
+	})
+}
+
+func TestNewStreamCodecs(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Stream.Codec = CodecMsgPack
+	cfg.Stream.Codecs = "updates=json, milestones = json"
+
+	codecs, err := newStreamCodecs(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, CodecJSON, codecs.forStream("updates").Name())
+	assert.Equal(t, CodecJSON, codecs.forStream("milestones").Name())
+	assert.Equal(t, CodecMsgPack, codecs.forStream("other").Name())
+
+	assert.Equal(t, CodecJSON, streamCodecs{}.forStream("other").Name())
+
+	cfg.Stream.Codecs = "updates=gob"
+	_, err = newStreamCodecs(cfg)
+	assert.Error(t, err)
+}
+
+func TestNewValueCodec(t *testing.T) {
+	cfg := &config.AppConfig{}
+	codec, err := newValueCodec(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, CodecJSON, codec.Name())
+
+	cfg.Cache.Codec = CodecMsgPack
+	codec, err = newValueCodec(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, CodecMsgPack, codec.Name())
+
+	cfg.Cache.Codec = CodecGob
+	_, err = newValueCodec(cfg)
+	assert.Error(t, err)
+}
+
+func TestValueCodec(t *testing.T) {
+	ctx := context.Background()
+	expireTime := time.Minute
+	value := codecTestMessage{LeaderboardID: "board", Score: 42.5}
+
+	memCache := newMemoryCache(&MockLogger{})
+	memCache.valueCodec = msgpackCodec{}
+	redisCache := newMiniredisCache(t)
+	redisCache.valueCodec = msgpackCodec{}
+
+	for name, cache := range map[string]ICache{"memory": memCache, "redis": redisCache} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, cache.Set(ctx, "value", value, &expireTime))
+
+			var decoded codecTestMessage
+			require.NoError(t, cache.Get(ctx, "value", &decoded))
+			assert.Equal(t, value.LeaderboardID, decoded.LeaderboardID)
+			assert.Equal(t, value.Score, decoded.Score)
+		})
+	}
+
+	t.Run("values are stored with the codec", func(t *testing.T) {
+		var decoded codecTestMessage
+		require.NoError(t, msgpackCodec{}.Unmarshal(memCache.values["value"].data, &decoded))
+		assert.Equal(t, value.LeaderboardID, decoded.LeaderboardID)
+	})
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// =============================
+// 🔹 Stream Consumption Policy
+// =============================
+
+const (
+	DefaultStreamMaxRetries   = 3
+	DefaultStreamRetryBackoff = time.Duration(200 * time.Millisecond)
+	DefaultStreamClaimIdle    = time.Duration(1 * time.Minute)
+	// DefaultStreamBatchSize and DefaultStreamFlushInterval apply to batch handlers that do not
+	// set their own.
+	DefaultStreamBatchSize     = 100
+	DefaultStreamFlushInterval = time.Duration(1 * time.Second)
+
+	// maxReadBackoff caps the delay between failed stream reads.
+	maxReadBackoff = time.Duration(30 * time.Second)
+	// streamReadBlock bounds a blocking stream read, so subscriptions notice cancellation.
+	streamReadBlock = time.Duration(2 * time.Second)
+	// deadLetterSuffix names the dead-letter stream of a stream.
+	deadLetterSuffix = ":dead"
+)
+
+// RejectedMessages is returned by a handler that handled its messages except for those it can
+// never handle, such as messages that fail to decode, with their error by message ID. They are
+// moved to the dead-letter stream at once, without being retried, and the others are acknowledged.
+// Messages rejected with an error wrapping ErrRetryLater are left pending instead.
+type RejectedMessages map[string]error
+
+func (r RejectedMessages) Error() string {
+	return fmt.Sprintf("%d stream messages rejected", len(r))
+}
+
+// ErrRetryLater is wrapped by handler errors that are not the messages' fault, such as a store
+// being unavailable. The messages are retried with backoff as usual, but then left pending
+// instead of dead-lettered, and claimed again once idle for the claim interval.
+var ErrRetryLater = errors.New("retry later")
+
+// streamPolicy decides how failed stream messages are retried and when pending messages of
+// dead consumers are claimed. Its zero value uses the defaults.
+type streamPolicy struct {
+	maxRetries   int
+	retryBackoff time.Duration
+	claimIdle    time.Duration
+}
+
+func newStreamPolicy(config *config.AppConfig) streamPolicy {
+	return streamPolicy{
+		maxRetries:   config.Stream.MaxRetries,
+		retryBackoff: time.Duration(config.Stream.RetryBackoffMs) * time.Millisecond,
+		claimIdle:    time.Duration(config.Stream.ClaimIdleMs) * time.Millisecond,
+	}
+}
+
+func (p streamPolicy) retries() int {
+	if p.maxRetries <= 0 {
+		return DefaultStreamMaxRetries
+	}
+	return p.maxRetries
+}
+
+func (p streamPolicy) backoff() time.Duration {
+	if p.retryBackoff <= 0 {
+		return DefaultStreamRetryBackoff
+	}
+	return p.retryBackoff
+}
+
+func (p streamPolicy) idle() time.Duration {
+	if p.claimIdle <= 0 {
+		return DefaultStreamClaimIdle
+	}
+	return p.claimIdle
+}
+
+// workers returns the handler of each consumer the subscription runs, named after Consumer.
+func (h ConsumerHandler) workers() []ConsumerHandler {
+	if h.Workers <= 1 {
+		return []ConsumerHandler{h}
+	}
+	workers := make([]ConsumerHandler, h.Workers)
+	for i := range workers {
+		workers[i] = h
+		workers[i].Consumer = fmt.Sprintf("%s-%d", h.Consumer, i+1)
+	}
+	return workers
+}
+
+// batchSize returns how many messages are handed to the handler at once.
+func (h ConsumerHandler) batchSize() int {
+	if h.BatchHandler == nil {
+		return 1
+	}
+	if h.BatchSize <= 0 {
+		return DefaultStreamBatchSize
+	}
+	return h.BatchSize
+}
+
+// flushInterval returns how long a partial batch is buffered before it is handled.
+func (h ConsumerHandler) flushInterval() time.Duration {
+	if h.FlushInterval <= 0 {
+		return DefaultStreamFlushInterval
+	}
+	return h.FlushInterval
+}
+
+func (h ConsumerHandler) handle(messages []StreamMessage) error {
+	if h.BatchHandler != nil {
+		return h.BatchHandler(messages)
+	}
+	for _, message := range messages {
+		if err := h.Handler(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver hands messages to the handler, retrying failures with exponential backoff, and
+// returns the last error once retries are exhausted or ctx is done. Rejected messages are not
+// retried.
+func (p streamPolicy) deliver(ctx context.Context, handler ConsumerHandler, messages []StreamMessage) error {
+	backoff := p.backoff()
+	err := handler.handle(messages)
+	var rejected RejectedMessages
+	for attempt := 0; err != nil && !errors.As(err, &rejected) && attempt < p.retries(); attempt++ {
+		if !sleep(ctx, backoff) {
+			return err
+		}
+		backoff *= 2
+		err = handler.handle(messages)
+	}
+	return err
+}
+
+// parseStreamMessages reads stream entries as returned by Redis.
+func parseStreamMessages(messages []redis.XMessage) []StreamMessage {
+	parsed := make([]StreamMessage, len(messages))
+	for i, message := range messages {
+		parsed[i] = parseStreamMessage(message.ID, message.Values)
+	}
+	return parsed
+}
+
+// deadLetters returns the errors of the handled messages to move to the dead-letter stream by
+// message ID: those the handler rejected, or the message it failed to handle when alone.
+func deadLetters(messages []redis.XMessage, handleErr error) map[string]error {
+	if handleErr == nil {
+		return nil
+	}
+	var rejected RejectedMessages
+	if errors.As(handleErr, &rejected) {
+		dead := make(map[string]error, len(rejected))
+		for id, err := range rejected {
+			if !errors.Is(err, ErrRetryLater) {
+				dead[id] = err
+			}
+		}
+		return dead
+	}
+	return map[string]error{messages[0].ID: handleErr}
+}
+
+// settledIDs returns the IDs of the handled messages to acknowledge: all of them but those the
+// handler rejected with an error wrapping ErrRetryLater, which stay pending.
+func settledIDs(messages []redis.XMessage, handleErr error) []string {
+	var rejected RejectedMessages
+	errors.As(handleErr, &rejected)
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if err, ok := rejected[message.ID]; ok && errors.Is(err, ErrRetryLater) {
+			continue
+		}
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+// deadLetterValues returns the fields of the dead-letter entry of a message that could not be
+// handled: its own fields, the ID it had and the last error.
+func deadLetterValues(id string, values map[string]any, err error) map[string]any {
+	dead := make(map[string]any, len(values)+2)
+	for field, value := range values {
+		dead[field] = value
+	}
+	dead["source_id"] = id
+	dead["error"] = err.Error()
+	return dead
+}
+
+// sleep waits for d and reports whether it did so before ctx was done.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamPolicy(t *testing.T) {
+	t.Run("zero value uses the defaults", func(t *testing.T) {
+		var policy streamPolicy
+		assert.Equal(t, DefaultStreamMaxRetries, policy.retries())
+		assert.Equal(t, DefaultStreamRetryBackoff, policy.backoff())
+		assert.Equal(t, DefaultStreamClaimIdle, policy.idle())
+	})
+
+	t.Run("deliver retries until the handler succeeds", func(t *testing.T) {
+		policy := streamPolicy{maxRetries: 3, retryBackoff: time.Millisecond}
+		var attempts int
+		err := policy.deliver(context.Background(), ConsumerHandler{Handler: func(StreamMessage) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		}}, []StreamMessage{{}})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("deliver stops retrying when ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		policy := streamPolicy{maxRetries: 3, retryBackoff: time.Hour}
+		var attempts int
+		err := policy.deliver(ctx, ConsumerHandler{Handler: func(StreamMessage) error {
+			attempts++
+			return errors.New("boom")
+		}}, []StreamMessage{{}})
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestAppCache_ReliableConsumption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(context.Background())
+
+	cache := &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+		policy:      streamPolicy{maxRetries: 2, retryBackoff: time.Millisecond, claimIdle: 10 * time.Millisecond},
+	}
+
+	t.Run("failed messages are retried, then dead-lettered", func(t *testing.T) {
+		stream, group := "test-reliable-dead", "group"
+		require.NoError(t, cache.EnsureGroup(ctx, stream, group))
+
+		var attempts atomic.Int32
+		require.NoError(t, cache.Subscribe(ctx, stream, group, ConsumerHandler{
+			Consumer: "failing",
+			Handler: func(StreamMessage) error {
+				attempts.Add(1)
+				return errors.New("boom")
+			},
+		}))
+		require.NoError(t, cache.Publish(ctx, stream, "poison"))
+
+		deadKey := cache.taggedKey(stream) + deadLetterSuffix
+		assert.Eventually(t, func() bool {
+			return redisClient.XLen(ctx, deadKey).Val() == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(3), attempts.Load())
+
+		dead, err := redisClient.XRange(ctx, deadKey, "-", "+").Result()
+		require.NoError(t, err)
+		assert.Equal(t, "boom", dead[0].Values["error"])
+
+		var message string
+		require.NoError(t, parseStreamMessage(dead[0].ID, dead[0].Values).Decode(&message))
+		assert.Equal(t, "poison", message)
+
+		pending, err := redisClient.XPending(ctx, cache.taggedKey(stream), group).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), pending.Count)
+	})
+
+	t.Run("pending messages of a dead consumer are claimed", func(t *testing.T) {
+		stream, group := "test-reliable-claim", "group"
+		require.NoError(t, cache.EnsureGroup(ctx, stream, group))
+		require.NoError(t, cache.Publish(ctx, stream, "unfinished"))
+
+		// A consumer reads the message and dies before acknowledging it
+		_, err := redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: "dead",
+			Streams:  []string{cache.taggedKey(stream), ">"},
+			Count:    1,
+		}).Result()
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		received := make(chan string, 1)
+		require.NoError(t, cache.Subscribe(ctx, stream, group, ConsumerHandler{
+			Consumer: "recovering",
+			Handler: func(message StreamMessage) error {
+				var got string
+				if err := message.Decode(&got); err != nil {
+					return err
+				}
+				received <- got
+				return nil
+			},
+		}))
+
+		select {
+		case got := <-received:
+			assert.Equal(t, "unfinished", got)
+		case <-time.After(2 * time.Second):
+			t.Fatal("pending message not claimed")
+		}
+	})
+}
+
+func TestAppCache_BatchConsumption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(context.Background())
+
+	cache := &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+		policy:      streamPolicy{maxRetries: 1, retryBackoff: time.Millisecond},
+	}
+
+	stream, group := "test-batch", "group"
+	require.NoError(t, cache.EnsureGroup(ctx, stream, group))
+	for _, value := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, cache.Publish(ctx, stream, value))
+	}
+
+	batches := make(chan []string, 4)
+	require.NoError(t, cache.Subscribe(ctx, stream, group, ConsumerHandler{
+		Consumer: "batcher",
+		BatchHandler: func(messages []StreamMessage) error {
+			values := make([]string, len(messages))
+			for i, message := range messages {
+				if err := message.Decode(&values[i]); err != nil {
+					return err
+				}
+			}
+			batches <- values
+			return nil
+		},
+		BatchSize:     3,
+		FlushInterval: 50 * time.Millisecond,
+	}))
+
+	assert.Equal(t, []string{"a", "b", "c"}, <-batches)
+	assert.Equal(t, []string{"d"}, <-batches)
+
+	assert.Eventually(t, func() bool {
+		pending, err := redisClient.XPending(ctx, cache.taggedKey(stream), group).Result()
+		return err == nil && pending.Count == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
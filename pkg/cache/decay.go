@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// =============================
+// 🔹 Decaying Scores
+// =============================
+
+// decayRebaseExponent is the number of half-lives elapsed since a decaying board's epoch after
+// which the board is rebased onto a new epoch, keeping stored scores far from float64 overflow.
+const decayRebaseExponent = 512
+
+// errShardedDecay is returned when decaying scores are written to a sharded board, whose shards
+// cannot be rebased in one step.
+var errShardedDecay = errors.New("sharded boards cannot hold decaying scores")
+
+// Decay makes the scores written with UpdateScore halve every HalfLife. A decaying board stores
+// its scores multiplied by the growth since an epoch kept next to it, so members keep their order
+// without being rewritten as time passes; GetDecayEpoch reads it to convert stored scores back.
+// Epoch seeds the board's epoch when it has none.
+type Decay struct {
+	HalfLife time.Duration
+	Epoch    time.Time
+}
+
+// decayEpochKey holds the epoch of a decaying board, in microseconds since the Unix epoch.
+func decayEpochKey(rKey string) string {
+	return rKey + ":epoch"
+}
+
+// decayArgs returns the arguments of updateScoreScript describing decay at now.
+func decayArgs(decay *Decay, now time.Time) []any {
+	if decay == nil {
+		return []any{0, 0, 0, decayRebaseExponent}
+	}
+	return []any{decay.HalfLife.Microseconds(), now.UnixMicro(), decay.Epoch.UnixMicro(), decayRebaseExponent}
+}
+
+// GetDecayEpoch returns the epoch the scores of a decaying board are stored against, or fallback
+// when nothing was written to the board yet.
+func (c *appCache) GetDecayEpoch(ctx context.Context, boardKey string, fallback time.Time) (time.Time, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	micros, err := c.redisClient.Get(ctx, decayEpochKey(c.taggedKey(boardKey))).Result()
+	if IsNil(err) {
+		return fallback, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	epoch, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMicro(epoch), nil
+}
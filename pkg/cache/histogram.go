@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// =============================
+// 🔹 Score Histogram (Approximate Ranks)
+// =============================
+
+const (
+	// histogramBase is the ratio between the bounds of consecutive histogram buckets, so a
+	// bucket spans scores within 2% of each other.
+	histogramBase = 1.02
+	// histogramMinScore is the smallest magnitude with a bucket of its own; buckets start there,
+	// so fractional scores are told apart down to it.
+	histogramMinScore = 1e-6
+)
+
+// scoreBucket returns the histogram bucket of a score. Scores closer to 0 than histogramMinScore
+// share bucket 0; beyond that buckets grow geometrically, mirrored for negative scores. It must
+// match the bucket function of histogramLua.
+func scoreBucket(score float64) int64 {
+	abs := math.Abs(score)
+	if abs < histogramMinScore {
+		return 0
+	}
+	bucket := int64(math.Floor(math.Log(abs/histogramMinScore)/math.Log(histogramBase))) + 1
+	if score < 0 {
+		return -bucket
+	}
+	return bucket
+}
+
+// histogramLua defines the Lua functions scripts use to keep a histogram: bucket, the Lua
+// version of scoreBucket, and track, which moves a member from the bucket of its old score (nil
+// when it is new) to the bucket of its new one (nil when it is removed).
+const histogramLua = `
+local histogramBase, histogramMinScore = math.log(%v), %v
+local function bucket(score)
+	local abs = math.abs(score)
+	if abs < histogramMinScore then
+		return 0
+	end
+	local b = math.floor(math.log(abs / histogramMinScore) / histogramBase) + 1
+	if score < 0 then
+		return -b
+	end
+	return b
+end
+
+local function track(histogram, old, new)
+	local oldBucket = old and bucket(tonumber(old))
+	local newBucket = new and bucket(tonumber(new))
+	if oldBucket == newBucket then
+		return
+	end
+	if oldBucket then
+		redis.call("HINCRBY", histogram, oldBucket, -1)
+	end
+	if newBucket then
+		redis.call("HINCRBY", histogram, newBucket, 1)
+	end
+end
+`
+
+// histogramScript returns a script running body after the histogram functions.
+func histogramScript(body string) *redis.Script {
+	return redis.NewScript(fmt.Sprintf(histogramLua, histogramBase, histogramMinScore) + body)
+}
+
+// addScoresTrackedScript sets members' scores and moves each member between the buckets of the
+// board's histogram in one step, so concurrent updates keep the histogram exact. ARGV holds
+// member and score pairs.
+var addScoresTrackedScript = histogramScript(`
+for i = 1, #ARGV, 2 do
+	local member, score = ARGV[i], ARGV[i + 1]
+	local old = redis.call("ZSCORE", KEYS[1], member)
+	redis.call("ZADD", KEYS[1], score, member)
+	track(KEYS[2], old, score)
+end
+return 1
+`)
+
+// removeMemberScript removes a member and takes it out of the bucket of its score, when the
+// board keeps a histogram. ARGV holds the member.
+var removeMemberScript = histogramScript(`
+local old = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if not old then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	track(KEYS[2], old, nil)
+end
+return 1
+`)
+
+// histogramKey returns the key of the histogram kept next to a sorted set. It shares the sorted
+// set's hash tag, if any, so both live in the same cluster slot.
+func histogramKey(rKey string) string {
+	return rKey + ":histogram"
+}
+
+// AddScoresTracked sets members' scores like AddScores and keeps the board's score histogram up
+// to date for EstimateRank. A board must be updated through either method, never both.
+func (c *appCache) AddScoresTracked(ctx context.Context, boardKey string, scores map[string]float64) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if len(scores) == 0 {
+		return nil
+	}
+
+	// Every shard keeps its own histogram next to its sorted set; they are merged when estimating
+	grouped := make(map[string][]any)
+	baseKey, shards := parseBoardKey(boardKey)
+	for member, score := range scores {
+		rKey := c.taggedKey(boardKey)
+		if shards > 1 {
+			rKey = c.shardKeys(baseKey, shards)[shardOf(member, shards)]
+		}
+		grouped[rKey] = append(grouped[rKey], member, strconv.FormatFloat(score, 'g', -1, 64))
+	}
+
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for rKey, args := range grouped {
+			addScoresTrackedScript.Eval(ctx, pipe, []string{rKey, histogramKey(rKey)}, args...)
+		}
+		return nil
+	})
+	return err
+}
+
+// EstimateRank estimates a member's 1-based rank from the board's score histogram, assuming the
+// members of a bucket are spread evenly over it. It also returns the member's score and the
+// number of members on the board.
+func (c *appCache) EstimateRank(ctx context.Context, boardKey, member string) (rank int64, score float64, total int64, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rKey := c.taggedKey(boardKey)
+	histogramKeys := []string{histogramKey(rKey)}
+	if baseKey, shards := parseBoardKey(boardKey); shards > 1 {
+		keys := c.shardKeys(baseKey, shards)
+		rKey = keys[shardOf(member, shards)]
+		histogramKeys = histogramKeys[:0]
+		for _, key := range keys {
+			histogramKeys = append(histogramKeys, histogramKey(key))
+		}
+	}
+
+	score, err = c.redisClient.ZScore(ctx, rKey, member).Result()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(histogramKeys))
+	_, err = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range histogramKeys {
+			cmds[i] = pipe.HGetAll(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	histogram := make(map[int64]int64)
+	for _, cmd := range cmds {
+		for field, value := range cmd.Val() {
+			bucket, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			count, _ := strconv.ParseInt(value, 10, 64)
+			histogram[bucket] += count
+		}
+	}
+
+	rank, total = estimateRank(histogram, scoreBucket(score))
+	return rank, score, total, nil
+}
+
+// estimateRank estimates the rank of a member in the given bucket of a histogram and returns it
+// with the number of members in the histogram.
+func estimateRank(histogram map[int64]int64, bucket int64) (int64, int64) {
+	var above, within, total int64
+	for b, count := range histogram {
+		total += count
+		switch {
+		case b > bucket:
+			above += count
+		case b == bucket:
+			within = count
+		}
+	}
+
+	rank := above + (within+1)/2
+	if rank < 1 {
+		rank = 1
+	}
+	return rank, total
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreBucket(t *testing.T) {
+	t.Run("scores closest to 0 share bucket 0", func(t *testing.T) {
+		assert.Equal(t, int64(0), scoreBucket(0))
+		assert.Equal(t, int64(0), scoreBucket(histogramMinScore/2))
+		assert.Equal(t, int64(0), scoreBucket(-histogramMinScore/2))
+	})
+
+	t.Run("fractional scores have buckets of their own", func(t *testing.T) {
+		assert.Less(t, int64(0), scoreBucket(0.01))
+		assert.Less(t, scoreBucket(0.1), scoreBucket(0.5))
+		assert.Less(t, scoreBucket(0.5), scoreBucket(1))
+	})
+
+	t.Run("buckets follow score order", func(t *testing.T) {
+		assert.Less(t, scoreBucket(100), scoreBucket(110))
+		assert.Equal(t, -scoreBucket(100), scoreBucket(-100))
+		assert.Equal(t, scoreBucket(1000), scoreBucket(1001))
+	})
+}
+
+func TestEstimateRank(t *testing.T) {
+	histogram := map[int64]int64{10: 5, 20: 4, 30: 1}
+
+	t.Run("counts higher buckets and half of its own", func(t *testing.T) {
+		rank, total := estimateRank(histogram, 20)
+		assert.Equal(t, int64(3), rank)
+		assert.Equal(t, int64(10), total)
+	})
+
+	t.Run("top bucket ranks first", func(t *testing.T) {
+		rank, _ := estimateRank(histogram, 30)
+		assert.Equal(t, int64(1), rank)
+	})
+}
+
+func TestAppCache_ScoreHistogram(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(ctx)
+
+	testScoreHistogram(t, &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+	})
+}
+
+// testScoreHistogram runs the AddScoresTracked and EstimateRank contract against a cache driver.
+func testScoreHistogram(t *testing.T, cache ICache) {
+	ctx := context.Background()
+	for _, boardKey := range []string{"test-histogram", ShardedBoardKey("test-sharded-histogram", 4)} {
+		t.Run("EstimateRank follows updates on "+boardKey, func(t *testing.T) {
+			scores := make(map[string]float64)
+			for i := 1; i <= 100; i++ {
+				scores[fmt.Sprintf("player%03d", i)] = float64(i * 100)
+			}
+			require.NoError(t, cache.AddScoresTracked(ctx, boardKey, scores))
+
+			rank, score, total, err := cache.EstimateRank(ctx, boardKey, "player100")
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), rank)
+			assert.Equal(t, float64(10000), score)
+			assert.Equal(t, int64(100), total)
+
+			// Moving a member keeps the total and ranks it by its new score
+			require.NoError(t, cache.AddScoresTracked(ctx, boardKey, map[string]float64{"player001": 20000}))
+			rank, _, total, err = cache.EstimateRank(ctx, boardKey, "player001")
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), rank)
+			assert.Equal(t, int64(100), total)
+
+			rank, _, _, err = cache.EstimateRank(ctx, boardKey, "player050")
+			require.NoError(t, err)
+			assert.InDelta(t, 52, rank, 1)
+
+			// Removing a member takes it out of the histogram
+			require.NoError(t, cache.RemoveMember(ctx, boardKey, "player001"))
+			rank, _, total, err = cache.EstimateRank(ctx, boardKey, "player100")
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), rank)
+			assert.Equal(t, int64(99), total)
+		})
+	}
+
+	t.Run("EstimateRank of a missing member", func(t *testing.T) {
+		_, _, _, err := cache.EstimateRank(ctx, "test-histogram", "nobody")
+		assert.True(t, IsNil(err))
+	})
+}
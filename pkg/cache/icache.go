@@ -1,38 +1,131 @@
 package cache
 
 import (
+	"context"
+	"math/rand/v2"
 	"time"
 )
 
 var (
 	DefaultTTL = time.Duration(1 * time.Hour)
+	// NegativeTTL bounds how long a lookup of something missing is remembered.
+	NegativeTTL = time.Duration(30 * time.Second)
 )
 
+// ttlJitter is the largest fraction a jittered TTL deviates from the requested one.
+const ttlJitter = 0.1
+
+// Jittered spreads ttl by up to 10% either way, so keys cached at the same time do not all
+// expire at the same time.
+func Jittered(ttl time.Duration) *time.Duration {
+	jittered := ttl + time.Duration((rand.Float64()*2-1)*ttlJitter*float64(ttl))
+	return &jittered
+}
+
 type LeaderboardEntry struct {
 	Member any     `json:"member"`
 	Score  float64 `json:"score"`
 }
 
+// ConsumerHandler handles the messages of a subscription, one at a time with Handler or in
+// batches with BatchHandler. Messages are acknowledged once handled; those the handler returns
+// an error for are retried and eventually moved to the stream's dead-letter stream, unless it
+// rejects them with RejectedMessages or fails with ErrRetryLater.
 type ConsumerHandler struct {
 	Consumer string
-	Handler  func(message any)
+	Handler  func(message StreamMessage) error
+	// BatchHandler, when set, replaces Handler and receives up to BatchSize messages, buffered
+	// for at most FlushInterval. A batch that keeps failing is retried one message at a time so
+	// a single bad message does not fail the others.
+	BatchHandler  func(messages []StreamMessage) error
+	BatchSize     int
+	FlushInterval time.Duration
+	// Workers is the number of consumers the subscription runs concurrently, named
+	// "<Consumer>-<n>" when more than one. Messages are then handled out of order.
+	Workers int
+}
+
+// ScoreUpdate writes one member's score with UpdateScore.
+type ScoreUpdate struct {
+	Member string
+	// Score replaces the member's score, or is added to it when Incr is set. Best keeps the
+	// member's score instead when it is already at least Score.
+	Score float64
+	Incr  bool
+	Best  bool
+	// Tracked keeps the board's score histogram for EstimateRank, like AddScoresTracked.
+	Tracked bool
+	// Decay, when set, makes the board's scores decay; Score is then in current points.
+	Decay *Decay
+	// MaxMembers, when positive, rejects a member new to a board already holding that many
+	// members with ErrBoardFull.
+	MaxMembers int64
+	// Ranked reports the member's ranks in the standing; Passed returns up to that many of the
+	// members the update moved the member past, and implies Ranked.
+	Ranked bool
+	Passed int64
+}
+
+// ScoreStanding is a member's standing right before and after an UpdateScore. Ranks are 0
+// unless requested.
+type ScoreStanding struct {
+	// New reports that the member was not on the board, where it then stood one below the last
+	// place with a score of 0.
+	New       bool
+	PrevRank  int64
+	PrevScore float64
+	Rank      int64
+	Score     float64
+	// Passed holds the members passed by the update, highest ranked first.
+	Passed []LeaderboardEntry
 }
 
 type ICache interface {
-	Set(key string, value any, expireTime *time.Duration) error
-	Get(key string, data any) error
-	Delete(key string) error
-	Clear() error
-	ClearWithPrefix(prefix string) error
+	Set(ctx context.Context, key string, value any, expireTime *time.Duration) error
+	Get(ctx context.Context, key string, data any) error
+	// SetVersioned stores value unless the cached copy already has the same or a newer version,
+	// and reports whether it was stored. Versioned values are read back with GetVersioned.
+	SetVersioned(ctx context.Context, key string, value any, version int64, expireTime *time.Duration) (bool, error)
+	GetVersioned(ctx context.Context, key string, data any) (int64, error)
+	Delete(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, expireTime *time.Duration) (int64, error)
+	Clear(ctx context.Context) error
+	ClearWithPrefix(ctx context.Context, prefix string) error
+	// PublishInvalidation announces changed keys to every instance sharing the cache, and
+	// OnInvalidate registers a handler for the keys announced by any instance.
+	PublishInvalidation(ctx context.Context, keys ...string) error
+	OnInvalidate(ctx context.Context, handler func(key string)) error
 	// Leaderboard (Sorted Set) methods
-	AddScore(boardKey, member string, score float64) error
-	GetTopN(boardKey string, n int64) ([]LeaderboardEntry, error)
-	GetRank(boardKey, member string) (rank int64, score float64, err error)
-	RemoveMember(boardKey, member string) error
-	GetAroundMember(boardKey, member string, radius int64) ([]LeaderboardEntry, error)
+	AddScore(ctx context.Context, boardKey, member string, score float64) error
+	AddScores(ctx context.Context, boardKey string, scores map[string]float64) error
+	UpdateScore(ctx context.Context, boardKey string, update ScoreUpdate) (*ScoreStanding, error)
+	GetDecayEpoch(ctx context.Context, boardKey string, fallback time.Time) (time.Time, error)
+	GetTopN(ctx context.Context, boardKey string, n int64) ([]LeaderboardEntry, error)
+	GetRange(ctx context.Context, boardKey string, start, stop int64) ([]LeaderboardEntry, error)
+	// GetRangeAfter pages through a board from a previous page's last entry, reading sharded
+	// boards from that entry's position on every shard instead of from their tops.
+	GetRangeAfter(ctx context.Context, boardKey string, after *LeaderboardEntry, count int64) ([]LeaderboardEntry, error)
+	GetRank(ctx context.Context, boardKey, member string) (rank int64, score float64, err error)
+	Count(ctx context.Context, boardKey string) (int64, error)
+	RemoveMember(ctx context.Context, boardKey, member string) error
+	GetAroundMember(ctx context.Context, boardKey, member string, radius int64) ([]LeaderboardEntry, error)
+	// AddScoresTracked and EstimateRank keep a score histogram next to a board to estimate
+	// ranks without ranking the member exactly.
+	AddScoresTracked(ctx context.Context, boardKey string, scores map[string]float64) error
+	EstimateRank(ctx context.Context, boardKey, member string) (rank int64, score float64, total int64, err error)
 
 	// Stream methods
-	Publish(stream string, message any) error
-	EnsureGroup(stream string, group string) error
-	Subscribe(stream string, group string, handler ConsumerHandler) error
+	Publish(ctx context.Context, stream string, message any) error
+	EnsureGroup(ctx context.Context, stream string, group string) error
+	Subscribe(ctx context.Context, stream string, group string, handler ConsumerHandler) error
+
+	// Lock methods. A lock taken with AcquireLock expires after its ttl unless renewed with
+	// RenewLock; AcquireLock fails with ErrLockHeld while another owner holds it, and renewing or
+	// releasing a lock no longer held fails with ErrLockLost.
+	AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error)
+	RenewLock(ctx context.Context, lock *Lock, ttl time.Duration) error
+	ReleaseLock(ctx context.Context, lock *Lock) error
+
+	// Close releases the connections and stops the background work of the cache.
+	Close() error
 }
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+)
+
+var (
+	DefaultLocalTTL = time.Duration(1 * time.Second)
+)
+
+// LocalCacheStats reports the usage of the in-process cache for monitoring.
+type LocalCacheStats struct {
+	Enabled   bool   `json:"enabled"`
+	Size      int    `json:"size"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// ILocalCache is a size-bounded in-process cache in front of the shared cache for hot reads.
+// Values are returned as stored, so callers must not modify them.
+type ILocalCache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	Delete(key string)
+	Stats() LocalCacheStats
+}
+
+type localEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+type localCache struct {
+	maxEntries int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// NewLocalCache creates the in-process cache, disabled when no entries are allowed. Keys
+// invalidated on the shared cache by any instance are dropped from it.
+func NewLocalCache(config *config.AppConfig, logger logger.ILogger, cache ICache) (ILocalCache, error) {
+	ttl := time.Duration(config.LocalCache.TTLMs) * time.Millisecond
+	if ttl <= 0 {
+		ttl = DefaultLocalTTL
+	}
+
+	local := newLocalCache(config.LocalCache.MaxEntries, ttl)
+	if local.maxEntries <= 0 {
+		return local, nil
+	}
+
+	if err := cache.OnInvalidate(context.Background(), local.Delete); err != nil {
+		logger.Error("Failed to subscribe to cache invalidations", "error", err)
+		return nil, err
+	}
+
+	logger.Info("Local cache enabled", "maxEntries", local.maxEntries, "ttl", ttl)
+	return local, nil
+}
+
+func newLocalCache(maxEntries int, ttl time.Duration) *localCache {
+	return &localCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *localCache) Get(key string) (any, bool) {
+	if c.maxEntries <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := elem.Value.(*localEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+func (c *localCache) Set(key string, value any) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(*Jittered(c.ttl))
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&localEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *localCache) Delete(key string) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *localCache) Stats() LocalCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return LocalCacheStats{
+		Enabled:   c.maxEntries > 0,
+		Size:      size,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *localCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*localEntry).key)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalCache(t *testing.T) {
+	t.Run("Get returns stored values and counts hits and misses", func(t *testing.T) {
+		c := newLocalCache(10, time.Minute)
+		c.Set("a", 1)
+
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+
+		stats := c.Stats()
+		assert.True(t, stats.Enabled)
+		assert.Equal(t, 1, stats.Size)
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+	})
+
+	t.Run("Set evicts the least recently used entry", func(t *testing.T) {
+		c := newLocalCache(2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		_, ok = c.Get("a")
+		assert.True(t, ok)
+		_, ok = c.Get("c")
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), c.Stats().Evictions)
+	})
+
+	t.Run("Get drops expired entries", func(t *testing.T) {
+		c := newLocalCache(10, time.Millisecond)
+		c.Set("a", 1)
+		time.Sleep(5 * time.Millisecond)
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Stats().Size)
+	})
+
+	t.Run("Delete removes entries", func(t *testing.T) {
+		c := newLocalCache(10, time.Minute)
+		c.Set("a", 1)
+		c.Delete("a")
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("disabled cache stores nothing", func(t *testing.T) {
+		c := newLocalCache(0, time.Minute)
+		c.Set("a", 1)
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, LocalCacheStats{}, c.Stats())
+	})
+}
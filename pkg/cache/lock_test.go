@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLocks runs the lock contract against a cache driver.
+func testLocks(t *testing.T, cache ICache) {
+	ctx := context.Background()
+
+	t.Run("a held lock cannot be acquired", func(t *testing.T) {
+		lock, err := cache.AcquireLock(ctx, "held", time.Minute)
+		require.NoError(t, err)
+
+		_, err = cache.AcquireLock(ctx, "held", time.Minute)
+		assert.ErrorIs(t, err, ErrLockHeld)
+
+		require.NoError(t, cache.RenewLock(ctx, lock, time.Minute))
+		require.NoError(t, cache.ReleaseLock(ctx, lock))
+
+		next, err := cache.AcquireLock(ctx, "held", time.Minute)
+		require.NoError(t, err)
+		assert.Greater(t, next.Token, lock.Token, "fencing tokens grow with every acquisition")
+	})
+
+	t.Run("an expired lock is lost", func(t *testing.T) {
+		lock, err := cache.AcquireLock(ctx, "expiring", 20*time.Millisecond)
+		require.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+
+		taken, err := cache.AcquireLock(ctx, "expiring", time.Minute)
+		require.NoError(t, err)
+		assert.Greater(t, taken.Token, lock.Token)
+
+		assert.ErrorIs(t, cache.RenewLock(ctx, lock, time.Minute), ErrLockLost)
+		assert.ErrorIs(t, cache.ReleaseLock(ctx, lock), ErrLockLost)
+		require.NoError(t, cache.RenewLock(ctx, taken, time.Minute))
+	})
+}
+
+func TestMemoryCache_Locks(t *testing.T) {
+	testLocks(t, newMemoryCache(&MockLogger{}))
+}
+
+func TestAppCache_Locks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(ctx)
+
+	testLocks(t, &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+	})
+}
@@ -0,0 +1,984 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryCache implements ICache in process, for single-node deployments and tests. It follows
+// the Redis driver's semantics, including returning redis.Nil for missing keys and members, but
+// nothing is shared between instances or kept across restarts. Operations never block, so ctx
+// only bounds Subscribe.
+type memoryCache struct {
+	logger     logger.ILogger
+	codecs     streamCodecs
+	policy     streamPolicy
+	retentions streamRetentions
+	valueCodec Codec
+
+	mu     sync.Mutex
+	values map[string]memoryValue
+	boards map[string]*skipList
+	// epochs holds the decay epoch of each decaying board
+	epochs  map[string]time.Time
+	streams map[string]*memoryStream
+	locks   map[string]memoryLock
+	// fences holds the last fencing token issued for each lock
+	fences map[string]int64
+
+	invalidateMu       sync.RWMutex
+	invalidateHandlers []func(key string)
+
+	// stop is closed by Close to end sweeping
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type memoryValue struct {
+	data      []byte
+	expiresAt time.Time // zero when the value does not expire
+}
+
+func (v memoryValue) expired(now time.Time) bool {
+	return !v.expiresAt.IsZero() && !now.Before(v.expiresAt)
+}
+
+type memoryStream struct {
+	messages []redis.XMessage
+	// addedAt holds when each message was published, for age-based retention
+	addedAt []time.Time
+	// lastID numbers messages, as trimming shrinks messages
+	lastID int
+	groups map[string]*memoryGroup
+	// wake is closed and replaced whenever a message is published
+	wake chan struct{}
+}
+
+type memoryGroup struct {
+	// next is the position in the stream of the next message to deliver
+	next    int
+	pending map[string]*memoryPending
+}
+
+type memoryPending struct {
+	position    int
+	message     redis.XMessage
+	consumer    string
+	deliveredAt time.Time
+}
+
+func NewMemoryCache(config *config.AppConfig, logger logger.ILogger) (ICache, error) {
+	codecs, err := newStreamCodecs(config)
+	if err != nil {
+		return nil, err
+	}
+	retentions, err := newStreamRetentions(config)
+	if err != nil {
+		return nil, err
+	}
+	valueCodec, err := newValueCodec(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newMemoryCache(logger)
+	c.codecs = codecs
+	c.policy = newStreamPolicy(config)
+	c.retentions = retentions
+	c.valueCodec = valueCodec
+
+	if config.Cache.CleanupIntervalHour > 0 {
+		go c.sweep(time.Duration(config.Cache.CleanupIntervalHour) * time.Hour)
+	}
+
+	logger.Info("Using in-memory cache")
+	return c, nil
+}
+
+func newMemoryCache(logger logger.ILogger) *memoryCache {
+	return &memoryCache{
+		logger:     logger,
+		valueCodec: jsonCodec{},
+		values:     make(map[string]memoryValue),
+		boards:     make(map[string]*skipList),
+		epochs:     make(map[string]time.Time),
+		streams:    make(map[string]*memoryStream),
+		locks:      make(map[string]memoryLock),
+		fences:     make(map[string]int64),
+		stop:       make(chan struct{}),
+	}
+}
+
+// sweep drops expired values every interval until the cache is closed. Values are also dropped
+// when read after expiring, so sweeping only bounds the memory held by values nobody reads again.
+func (c *memoryCache) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for key, value := range c.values {
+				if value.expired(now) {
+					delete(c.values, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// Close stops sweeping expired values. The cache stays usable.
+func (c *memoryCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+// =============================
+// 🔹 Basic Cache Operations
+// =============================
+
+func (c *memoryCache) Set(ctx context.Context, key string, value any, expireTime *time.Duration) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case int:
+		data = strconv.AppendInt(nil, int64(v), 10)
+	case int64:
+		data = strconv.AppendInt(nil, v, 10)
+	case float64:
+		data = strconv.AppendFloat(nil, v, 'f', -1, 64)
+	case bool:
+		// Stored like Redis stores booleans
+		data = []byte("0")
+		if v {
+			data = []byte("1")
+		}
+	default:
+		encoded, err := c.valueCodec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+		data = encoded
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = memoryValue{data: data, expiresAt: expiresAt(*expireTime)}
+	return nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, data any) error {
+	val, err := c.load(key)
+	if err != nil {
+		return err
+	}
+	return c.valueCodec.Unmarshal(val, data)
+}
+
+func (c *memoryCache) SetVersioned(ctx context.Context, key string, value any, version int64, expireTime *time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	envelope, err := json.Marshal(versionedEnvelope{Version: version, Data: data})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.lookup(key); ok {
+		var decoded versionedEnvelope
+		if json.Unmarshal(current, &decoded) == nil && decoded.Version >= version {
+			return false, nil
+		}
+	}
+
+	c.values[key] = memoryValue{data: envelope, expiresAt: expiresAt(*expireTime)}
+	return true, nil
+}
+
+func (c *memoryCache) GetVersioned(ctx context.Context, key string, data any) (int64, error) {
+	val, err := c.load(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var envelope versionedEnvelope
+	if err := json.Unmarshal(val, &envelope); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return 0, err
+	}
+
+	return envelope.Version, nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	delete(c.boards, key)
+	delete(c.epochs, key)
+	delete(c.streams, key)
+	return nil
+}
+
+// Incr increments a counter and returns its new value. The expiration is set when the
+// counter is created, so fixed-window counters reset on their own.
+func (c *memoryCache) Incr(ctx context.Context, key string, expireTime *time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, ok := c.lookup(key)
+	if !ok {
+		c.values[key] = memoryValue{data: []byte("1"), expiresAt: expiresAt(*expireTime)}
+		return 1, nil
+	}
+
+	value, err := strconv.ParseInt(string(current), 10, 64)
+	if err != nil {
+		return 0, errors.New("ERR value is not an integer or out of range")
+	}
+	value++
+
+	entry := c.values[key]
+	entry.data = strconv.AppendInt(nil, value, 10)
+	c.values[key] = entry
+	return value, nil
+}
+
+func (c *memoryCache) Clear(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values = make(map[string]memoryValue)
+	c.boards = make(map[string]*skipList)
+	c.epochs = make(map[string]time.Time)
+	c.streams = make(map[string]*memoryStream)
+	c.locks = make(map[string]memoryLock)
+	c.fences = make(map[string]int64)
+	return nil
+}
+
+func (c *memoryCache) ClearWithPrefix(ctx context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.values {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.values, key)
+		}
+	}
+	for key := range c.boards {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.boards, key)
+			delete(c.epochs, key)
+		}
+	}
+	for key := range c.streams {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.streams, key)
+		}
+	}
+	return nil
+}
+
+// load returns a copy of the value stored under key, or redis.Nil.
+func (c *memoryCache) load(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, ok := c.lookup(key)
+	if !ok {
+		return nil, redis.Nil
+	}
+	return bytes.Clone(val), nil
+}
+
+// lookup returns the value stored under key, dropping it if it has expired. c.mu must be held.
+func (c *memoryCache) lookup(key string) ([]byte, bool) {
+	value, ok := c.values[key]
+	if !ok {
+		return nil, false
+	}
+	if value.expired(time.Now()) {
+		delete(c.values, key)
+		return nil, false
+	}
+	return value.data, true
+}
+
+// expiresAt returns when a value stored now with ttl expires; a ttl of 0 never expires.
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// =============================
+// 🔹 Leaderboard (Sorted Set)
+// =============================
+
+// Sharded board keys are stored in a single sorted set, as sharding only spreads Redis load.
+
+func (c *memoryCache) AddScore(ctx context.Context, boardKey, member string, score float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.board(boardKey).Set(member, score)
+	return nil
+}
+
+func (c *memoryCache) AddScores(ctx context.Context, boardKey string, scores map[string]float64) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board := c.board(boardKey)
+	for member, score := range scores {
+		board.Set(member, score)
+	}
+	return nil
+}
+
+func (c *memoryCache) UpdateScore(ctx context.Context, boardKey string, update ScoreUpdate) (*ScoreStanding, error) {
+	if _, shards := parseBoardKey(boardKey); shards > 1 && update.Decay != nil {
+		return nil, errShardedDecay
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	growth := 1.0
+	if update.Decay != nil {
+		growth = c.decayGrowth(boardKey, update.Decay, time.Now())
+		update.Score *= growth
+	}
+
+	board := c.board(boardKey)
+	ranked := update.Ranked || update.Passed > 0
+	standing := &ScoreStanding{}
+
+	prevRank, prevScore, ok := c.revRank(boardKey, update.Member)
+	if !ok && update.MaxMembers > 0 && int64(board.Len()) >= update.MaxMembers {
+		return nil, ErrBoardFull
+	}
+	standing.New = !ok
+	if ok {
+		standing.PrevScore = prevScore
+		prevRank++
+	} else {
+		prevRank = int64(board.Len()) + 1
+	}
+
+	score := update.Score
+	switch {
+	case update.Incr:
+		score += standing.PrevScore
+	case update.Best && ok && prevScore >= score:
+		score = prevScore
+	}
+	board.Set(update.Member, score)
+	standing.Score = score
+
+	if ranked {
+		rank, _, _ := c.revRank(boardKey, update.Member)
+		standing.PrevRank, standing.Rank = prevRank, rank+1
+		if update.Passed > 0 && standing.Rank < prevRank {
+			standing.Passed = revRange(board, standing.Rank, min(prevRank-1, standing.Rank+update.Passed-1))
+		}
+	}
+
+	standing.PrevScore /= growth
+	standing.Score /= growth
+	for i := range standing.Passed {
+		standing.Passed[i].Score /= growth
+	}
+	return standing, nil
+}
+
+// decayGrowth returns the growth of a decaying board's scores since its epoch at now, rebasing
+// the board onto now first when the epoch is too old. c.mu must be held.
+func (c *memoryCache) decayGrowth(boardKey string, decay *Decay, now time.Time) float64 {
+	epoch, ok := c.epochs[boardKey]
+	if !ok {
+		epoch = decay.Epoch
+		c.epochs[boardKey] = epoch
+	}
+
+	exponent := now.Sub(epoch).Seconds() / decay.HalfLife.Seconds()
+	if exponent > decayRebaseExponent {
+		if board, ok := c.boards[boardKey]; ok {
+			factor := math.Exp2(-exponent)
+			rebased := newSkipList()
+			board.Range(0, board.Len()-1, func(member string, score float64) {
+				rebased.Set(member, score*factor)
+			})
+			c.boards[boardKey] = rebased
+		}
+		c.epochs[boardKey] = now
+		return 1
+	}
+	return math.Exp2(exponent)
+}
+
+func (c *memoryCache) GetDecayEpoch(ctx context.Context, boardKey string, fallback time.Time) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if epoch, ok := c.epochs[boardKey]; ok {
+		return epoch, nil
+	}
+	return fallback, nil
+}
+
+func (c *memoryCache) Count(ctx context.Context, boardKey string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if board, ok := c.boards[boardKey]; ok {
+		return int64(board.Len()), nil
+	}
+	return 0, nil
+}
+
+func (c *memoryCache) GetTopN(ctx context.Context, boardKey string, n int64) ([]LeaderboardEntry, error) {
+	return c.GetRange(ctx, boardKey, 0, n-1)
+}
+
+func (c *memoryCache) GetRange(ctx context.Context, boardKey string, start, stop int64) ([]LeaderboardEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board, ok := c.boards[boardKey]
+	if !ok {
+		return []LeaderboardEntry{}, nil
+	}
+	return revRange(board, start, stop), nil
+}
+
+func (c *memoryCache) GetRangeAfter(ctx context.Context, boardKey string, after *LeaderboardEntry, count int64) ([]LeaderboardEntry, error) {
+	if after == nil {
+		return c.GetRange(ctx, boardKey, 0, count-1)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board, ok := c.boards[boardKey]
+	if !ok {
+		return []LeaderboardEntry{}, nil
+	}
+	// Members ranked below after sort before it in ascending order
+	start := int64(board.Len() - board.CountBefore(after.Score, fmt.Sprint(after.Member)))
+	return revRange(board, start, start+count-1), nil
+}
+
+func (c *memoryCache) GetRank(ctx context.Context, boardKey, member string) (rank int64, score float64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rank, score, ok := c.revRank(boardKey, member)
+	if !ok {
+		return 0, 0, redis.Nil
+	}
+	return rank + 1, score, nil
+}
+
+func (c *memoryCache) RemoveMember(ctx context.Context, boardKey, member string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if board, ok := c.boards[boardKey]; ok {
+		board.Remove(member)
+		if board.Len() == 0 {
+			delete(c.boards, boardKey)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) GetAroundMember(ctx context.Context, boardKey, member string, radius int64) ([]LeaderboardEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rank, _, ok := c.revRank(boardKey, member)
+	if !ok {
+		return nil, redis.Nil
+	}
+
+	start := rank - radius
+	if start < 0 {
+		start = 0
+	}
+	return revRange(c.boards[boardKey], start, rank+radius), nil
+}
+
+// AddScoresTracked sets members' scores like AddScores. Ranks are exact in memory, so no
+// histogram is kept.
+func (c *memoryCache) AddScoresTracked(ctx context.Context, boardKey string, scores map[string]float64) error {
+	return c.AddScores(ctx, boardKey, scores)
+}
+
+// EstimateRank returns a member's exact rank, its score and the number of members on the board.
+func (c *memoryCache) EstimateRank(ctx context.Context, boardKey, member string) (rank int64, score float64, total int64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rank, score, ok := c.revRank(boardKey, member)
+	if !ok {
+		return 0, 0, 0, redis.Nil
+	}
+	return rank + 1, score, int64(c.boards[boardKey].Len()), nil
+}
+
+// board returns the sorted set stored under boardKey, creating it if needed. c.mu must be held.
+func (c *memoryCache) board(boardKey string) *skipList {
+	board, ok := c.boards[boardKey]
+	if !ok {
+		board = newSkipList()
+		c.boards[boardKey] = board
+	}
+	return board
+}
+
+// revRank returns the 0-based descending rank and score of a member. c.mu must be held.
+func (c *memoryCache) revRank(boardKey, member string) (int64, float64, bool) {
+	board, ok := c.boards[boardKey]
+	if !ok {
+		return 0, 0, false
+	}
+	rank, ok := board.Rank(member)
+	if !ok {
+		return 0, 0, false
+	}
+	score, _ := board.Score(member)
+	return int64(board.Len() - 1 - rank), score, true
+}
+
+// revRange returns the members between the 0-based descending positions start and stop
+// (inclusive), counting negative positions from the bottom like ZREVRANGE.
+func revRange(board *skipList, start, stop int64) []LeaderboardEntry {
+	length := int64(board.Len())
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return []LeaderboardEntry{}
+	}
+
+	entries := make([]LeaderboardEntry, stop-start+1)
+	i := len(entries) - 1
+	board.Range(int(length-1-stop), int(length-1-start), func(member string, score float64) {
+		entries[i] = LeaderboardEntry{Member: member, Score: score}
+		i--
+	})
+	return entries
+}
+
+// =============================
+// 🔹 Stream Operations
+// =============================
+
+func (c *memoryCache) Publish(ctx context.Context, stream string, message any) error {
+	entry, err := encodeStreamEntry(c.codecs.forStream(stream), message)
+	if err != nil {
+		return err
+	}
+
+	// Fields are kept as strings, as they are read back from Redis
+	values := make(map[string]any, len(entry))
+	for field, value := range entry {
+		switch v := value.(type) {
+		case []byte:
+			values[field] = string(v)
+		case int:
+			values[field] = strconv.Itoa(v)
+		default:
+			values[field] = fmt.Sprint(v)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.append(stream, values)
+	c.trim(stream)
+	return nil
+}
+
+// trim applies the stream's retention exactly, never dropping messages a consumer group still
+// needs: pending ones and those not delivered yet. c.mu must be held.
+func (c *memoryCache) trim(stream string) {
+	retention := c.retentions.forStream(stream)
+	strm, ok := c.streams[stream]
+	if retention.keepsAll() || !ok {
+		return
+	}
+
+	n := 0
+	if retention.maxLen > 0 {
+		n = max(len(strm.messages)-int(retention.maxLen), 0)
+	}
+	if retention.maxAge > 0 {
+		cutoff := time.Now().Add(-retention.maxAge)
+		for n < len(strm.addedAt) && strm.addedAt[n].Before(cutoff) {
+			n++
+		}
+	}
+	for name, grp := range strm.groups {
+		needed := grp.next
+		for _, pending := range grp.pending {
+			needed = min(needed, pending.position)
+		}
+		if n > needed {
+			c.logger.Debug("Stream retention held back by messages a consumer group still needs", "stream", stream, "group", name)
+			n = needed
+		}
+	}
+	if n == 0 {
+		return
+	}
+
+	strm.messages = strm.messages[n:]
+	strm.addedAt = strm.addedAt[n:]
+	for _, grp := range strm.groups {
+		grp.next -= n
+		for _, pending := range grp.pending {
+			pending.position -= n
+		}
+	}
+}
+
+// append adds an entry to a stream and wakes its subscribers. c.mu must be held.
+func (c *memoryCache) append(stream string, values map[string]any) {
+	strm := c.stream(stream)
+	strm.lastID++
+	strm.messages = append(strm.messages, redis.XMessage{
+		ID:     fmt.Sprintf("%d-0", strm.lastID),
+		Values: values,
+	})
+	strm.addedAt = append(strm.addedAt, time.Now())
+	close(strm.wake)
+	strm.wake = make(chan struct{})
+}
+
+func (c *memoryCache) EnsureGroup(ctx context.Context, stream, group string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	strm := c.stream(stream)
+	if _, ok := strm.groups[group]; !ok {
+		// New groups start at the end of the stream
+		strm.groups[group] = &memoryGroup{
+			next:    len(strm.messages),
+			pending: make(map[string]*memoryPending),
+		}
+	}
+	return nil
+}
+
+// Subscribe consumes new messages of a stream like the Redis driver does, including claiming
+// idle pending messages first, batching and moving failed messages to "<stream>:dead".
+func (c *memoryCache) Subscribe(ctx context.Context, stream string, group string, handler ConsumerHandler) error {
+	c.mu.Lock()
+	strm, ok := c.streams[stream]
+	if ok {
+		_, ok = strm.groups[group]
+	}
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("NOGROUP no consumer group %s on stream %s", group, stream)
+	}
+
+	for _, worker := range handler.workers() {
+		go c.work(ctx, stream, group, worker)
+	}
+
+	return nil
+}
+
+// work runs one consumer of a subscription until ctx is done.
+func (c *memoryCache) work(ctx context.Context, stream, group string, handler ConsumerHandler) {
+	size := handler.batchSize()
+	c.consumePending(ctx, stream, group, handler)
+	// Messages left pending, such as those failing with ErrRetryLater, are claimed again once
+	// idle for the claim interval
+	reclaim := time.NewTicker(c.policy.idle())
+	defer reclaim.Stop()
+
+	var batch []redis.XMessage
+	var flush <-chan time.Time
+	var timer *time.Timer
+	for ctx.Err() == nil {
+		messages, wake := c.claimNext(stream, group, handler.Consumer, size-len(batch))
+		if len(messages) > 0 && len(batch) == 0 {
+			timer = time.NewTimer(handler.flushInterval())
+			flush = timer.C
+		}
+		batch = append(batch, messages...)
+
+		if len(batch) < size {
+			select {
+			case <-wake:
+				continue
+			case <-reclaim.C:
+				if len(batch) == 0 {
+					c.consumePending(ctx, stream, group, handler)
+				}
+				continue
+			case <-flush:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		timer.Stop()
+		flush = nil
+		c.consume(ctx, stream, group, handler, batch)
+		batch = nil
+	}
+}
+
+// consumePending claims the group's messages pending for longer than the claim interval and
+// consumes them in batches.
+func (c *memoryCache) consumePending(ctx context.Context, stream, group string, handler ConsumerHandler) {
+	size := handler.batchSize()
+	claimed := c.claimPending(stream, group, handler.Consumer)
+	for len(claimed) > 0 && ctx.Err() == nil {
+		n := min(size, len(claimed))
+		c.consume(ctx, stream, group, handler, claimed[:n])
+		claimed = claimed[n:]
+	}
+}
+
+// claimPending hands the group's messages pending for longer than the claim interval over to
+// consumer and returns them in stream order.
+func (c *memoryCache) claimPending(stream, group, consumer string) []redis.XMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	grp, ok := c.stream(stream).groups[group]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	claimed := make([]*memoryPending, 0)
+	for _, pending := range grp.pending {
+		if now.Sub(pending.deliveredAt) >= c.policy.idle() {
+			pending.consumer = consumer
+			pending.deliveredAt = now
+			claimed = append(claimed, pending)
+		}
+	}
+	slices.SortFunc(claimed, func(a, b *memoryPending) int {
+		return a.position - b.position
+	})
+
+	messages := make([]redis.XMessage, len(claimed))
+	for i, pending := range claimed {
+		messages[i] = pending.message
+	}
+	return messages
+}
+
+// claimNext moves up to n of the group's next messages to consumer's pending messages and
+// returns them, along with a channel closed on the next publish.
+func (c *memoryCache) claimNext(stream, group, consumer string, n int) ([]redis.XMessage, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	strm := c.stream(stream)
+	grp, ok := strm.groups[group]
+	if !ok {
+		// The stream was cleared
+		return nil, strm.wake
+	}
+
+	var messages []redis.XMessage
+	for ; len(messages) < n && grp.next < len(strm.messages); grp.next++ {
+		message := strm.messages[grp.next]
+		grp.pending[message.ID] = &memoryPending{
+			position:    grp.next,
+			message:     message,
+			consumer:    consumer,
+			deliveredAt: time.Now(),
+		}
+		messages = append(messages, message)
+	}
+	return messages, strm.wake
+}
+
+// consume handles a batch of messages and then acknowledges them. A batch that cannot be
+// handled is consumed again one message at a time, and a single message that cannot be handled
+// is moved to the dead-letter stream, like the messages the handler rejects. Messages failing
+// with ErrRetryLater are left pending.
+func (c *memoryCache) consume(ctx context.Context, stream, group string, handler ConsumerHandler, messages []redis.XMessage) {
+	handleErr := c.policy.deliver(ctx, handler, parseStreamMessages(messages))
+	if handleErr != nil && ctx.Err() != nil {
+		// Left pending to be claimed again
+		return
+	}
+	if errors.Is(handleErr, ErrRetryLater) {
+		c.logger.Warn("Leaving failed stream messages pending", "stream", stream, "count", len(messages), "error", handleErr)
+		return
+	}
+	var rejected RejectedMessages
+	if handleErr != nil && !errors.As(handleErr, &rejected) && len(messages) > 1 {
+		c.logger.Warn("Retrying failed stream batch one message at a time", "stream", stream, "count", len(messages), "error", handleErr)
+		for _, message := range messages {
+			c.consume(ctx, stream, group, handler, []redis.XMessage{message})
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dead := deadLetters(messages, handleErr)
+	for _, message := range messages {
+		if deadErr, ok := dead[message.ID]; ok {
+			c.logger.Error("Moving stream message to dead-letter stream", "stream", stream, "id", message.ID, "error", deadErr)
+			c.append(stream+deadLetterSuffix, deadLetterValues(message.ID, message.Values, deadErr))
+		}
+	}
+	if strm, ok := c.streams[stream]; ok {
+		if grp, ok := strm.groups[group]; ok {
+			for _, id := range settledIDs(messages, handleErr) {
+				delete(grp.pending, id)
+			}
+		}
+	}
+}
+
+// stream returns the stream stored under key, creating it if needed. c.mu must be held.
+func (c *memoryCache) stream(key string) *memoryStream {
+	strm, ok := c.streams[key]
+	if !ok {
+		strm = &memoryStream{
+			groups: make(map[string]*memoryGroup),
+			wake:   make(chan struct{}),
+		}
+		c.streams[key] = strm
+	}
+	return strm
+}
+
+// =============================
+// 🔹 Distributed Locks
+// =============================
+
+type memoryLock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+func (c *memoryCache) AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.locks[key]; ok && time.Now().Before(held.expiresAt) {
+		return nil, ErrLockHeld
+	}
+	c.locks[key] = memoryLock{owner: owner, expiresAt: time.Now().Add(ttl)}
+	c.fences[key]++
+	return &Lock{Key: key, Token: c.fences[key], owner: owner}, nil
+}
+
+func (c *memoryCache) RenewLock(ctx context.Context, lock *Lock, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.holds(lock) {
+		return ErrLockLost
+	}
+	c.locks[lock.Key] = memoryLock{owner: lock.owner, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *memoryCache) ReleaseLock(ctx context.Context, lock *Lock) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.holds(lock) {
+		return ErrLockLost
+	}
+	delete(c.locks, lock.Key)
+	return nil
+}
+
+// holds reports whether lock is still held by its owner. c.mu must be held.
+func (c *memoryCache) holds(lock *Lock) bool {
+	held, ok := c.locks[lock.Key]
+	return ok && held.owner == lock.owner && time.Now().Before(held.expiresAt)
+}
+
+// =============================
+// 🔹 Invalidation
+// =============================
+
+// PublishInvalidation runs the registered handlers right away, as no other instance shares the
+// cache.
+func (c *memoryCache) PublishInvalidation(ctx context.Context, keys ...string) error {
+	c.invalidateMu.RLock()
+	defer c.invalidateMu.RUnlock()
+
+	for _, key := range keys {
+		for _, handler := range c.invalidateHandlers {
+			handler(key)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) OnInvalidate(ctx context.Context, handler func(key string)) error {
+	c.invalidateMu.Lock()
+	defer c.invalidateMu.Unlock()
+
+	c.invalidateHandlers = append(c.invalidateHandlers, handler)
+	return nil
+}
@@ -0,0 +1,524 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_Values(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache(&MockLogger{})
+	ttl := time.Hour
+
+	t.Run("Set and Get", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "struct", map[string]string{"name": "test"}, &ttl))
+
+		var got map[string]string
+		require.NoError(t, cache.Get(ctx, "struct", &got))
+		assert.Equal(t, "test", got["name"])
+	})
+
+	t.Run("Get of a missing key", func(t *testing.T) {
+		var got string
+		assert.True(t, IsNil(cache.Get(ctx, "missing", &got)))
+	})
+
+	t.Run("expired values are gone", func(t *testing.T) {
+		short := time.Millisecond
+		require.NoError(t, cache.Set(ctx, "short", 1, &short))
+		time.Sleep(5 * time.Millisecond)
+
+		var got int
+		assert.True(t, IsNil(cache.Get(ctx, "short", &got)))
+	})
+
+	t.Run("SetVersioned keeps the newest version", func(t *testing.T) {
+		stored, err := cache.SetVersioned(ctx, "versioned", "v2", 2, &ttl)
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		stored, err = cache.SetVersioned(ctx, "versioned", "v1", 1, &ttl)
+		require.NoError(t, err)
+		assert.False(t, stored)
+
+		var got string
+		version, err := cache.GetVersioned(ctx, "versioned", &got)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), version)
+		assert.Equal(t, "v2", got)
+	})
+
+	t.Run("Incr counts up", func(t *testing.T) {
+		for i := int64(1); i <= 3; i++ {
+			value, err := cache.Incr(ctx, "counter", &ttl)
+			require.NoError(t, err)
+			assert.Equal(t, i, value)
+		}
+	})
+
+	t.Run("ClearWithPrefix", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "tenant:1", "a", &ttl))
+		require.NoError(t, cache.AddScore(ctx, "tenant:board", "p", 1))
+		require.NoError(t, cache.ClearWithPrefix(ctx, "tenant:"))
+
+		var got string
+		assert.True(t, IsNil(cache.Get(ctx, "tenant:1", &got)))
+		count, _ := cache.Count(ctx, "tenant:board")
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("PublishInvalidation runs handlers", func(t *testing.T) {
+		var invalidated []string
+		require.NoError(t, cache.OnInvalidate(ctx, func(key string) {
+			invalidated = append(invalidated, key)
+		}))
+		require.NoError(t, cache.PublishInvalidation(ctx, "a", "b"))
+		assert.Equal(t, []string{"a", "b"}, invalidated)
+	})
+}
+
+func TestMemoryCache_Leaderboard(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache(&MockLogger{})
+
+	require.NoError(t, cache.AddScores(ctx, "board", map[string]float64{
+		"player1": 100, "player2": 200, "player3": 150, "player4": 150,
+	}))
+
+	t.Run("GetTopN in descending order", func(t *testing.T) {
+		entries, err := cache.GetTopN(ctx, "board", 3)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		// Ties are ordered by descending member, as ZREVRANGE does
+		assert.Equal(t, "player2", entries[0].Member)
+		assert.Equal(t, "player4", entries[1].Member)
+		assert.Equal(t, "player3", entries[2].Member)
+	})
+
+	t.Run("GetRank", func(t *testing.T) {
+		rank, score, err := cache.GetRank(ctx, "board", "player1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), rank)
+		assert.Equal(t, float64(100), score)
+
+		_, _, err = cache.GetRank(ctx, "board", "nobody")
+		assert.True(t, IsNil(err))
+	})
+
+	t.Run("GetAroundMember", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, "board", ScoreUpdate{Member: "player1", Score: 60, Incr: true})
+		require.NoError(t, err)
+		assert.Equal(t, float64(160), standing.Score)
+
+		entries, err := cache.GetAroundMember(ctx, "board", "player1", 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "player2", entries[0].Member)
+		assert.Equal(t, "player1", entries[1].Member)
+		assert.Equal(t, "player4", entries[2].Member)
+	})
+
+	t.Run("EstimateRank is exact", func(t *testing.T) {
+		rank, _, total, err := cache.EstimateRank(ctx, "board", "player3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), rank)
+		assert.Equal(t, int64(4), total)
+	})
+
+	t.Run("RemoveMember and Delete", func(t *testing.T) {
+		require.NoError(t, cache.RemoveMember(ctx, "board", "player2"))
+		count, _ := cache.Count(ctx, "board")
+		assert.Equal(t, int64(3), count)
+
+		require.NoError(t, cache.Delete(ctx, "board"))
+		count, _ = cache.Count(ctx, "board")
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestMemoryCache_Streams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newMemoryCache(&MockLogger{})
+
+	t.Run("Subscribe needs a group", func(t *testing.T) {
+		err := cache.Subscribe(ctx, "events", "nogroup", ConsumerHandler{Handler: func(StreamMessage) error { return nil }})
+		assert.Error(t, err)
+	})
+
+	// Messages published before the group exists are not delivered
+	require.NoError(t, cache.Publish(ctx, "events", "before"))
+	require.NoError(t, cache.EnsureGroup(ctx, "events", "group"))
+	require.NoError(t, cache.EnsureGroup(ctx, "events", "group"))
+
+	received := make(chan StreamMessage, 10)
+	require.NoError(t, cache.Subscribe(ctx, "events", "group", ConsumerHandler{
+		Consumer: "consumer",
+		Handler: func(message StreamMessage) error {
+			received <- message
+			return nil
+		},
+	}))
+
+	require.NoError(t, cache.Publish(ctx, "events", "first"))
+	require.NoError(t, cache.Publish(ctx, "events", "second"))
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case message := <-received:
+			assert.Equal(t, CodecJSON, message.Codec)
+			assert.Equal(t, DefaultSchemaVersion, message.SchemaVersion)
+
+			var got string
+			require.NoError(t, message.Decode(&got))
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("message %q not delivered", want)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return len(cache.streams["events"].groups["group"].pending) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryCache_ReliableConsumption(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newMemoryCache(&MockLogger{})
+	cache.policy = streamPolicy{maxRetries: 2, retryBackoff: time.Millisecond, claimIdle: time.Millisecond}
+
+	require.NoError(t, cache.EnsureGroup(ctx, "events", "group"))
+
+	t.Run("failed messages are retried, then dead-lettered", func(t *testing.T) {
+		var attempts atomic.Int32
+		subCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		require.NoError(t, cache.Subscribe(subCtx, "events", "group", ConsumerHandler{
+			Consumer: "failing",
+			Handler: func(StreamMessage) error {
+				attempts.Add(1)
+				return errors.New("boom")
+			},
+		}))
+		require.NoError(t, cache.Publish(ctx, "events", "poison"))
+
+		assert.Eventually(t, func() bool {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			dead, ok := cache.streams["events:dead"]
+			return ok && len(dead.messages) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(3), attempts.Load())
+
+		cache.mu.Lock()
+		dead := cache.streams["events:dead"].messages[0].Values
+		pending := len(cache.streams["events"].groups["group"].pending)
+		cache.mu.Unlock()
+		assert.Equal(t, "boom", dead["error"])
+		assert.Equal(t, "1-0", dead["source_id"])
+		assert.Equal(t, 0, pending)
+	})
+
+	t.Run("pending messages of a stopped consumer are claimed", func(t *testing.T) {
+		// A consumer stops while handling a message, leaving it pending
+		subCtx, stop := context.WithCancel(ctx)
+		handling := make(chan struct{})
+		require.NoError(t, cache.Subscribe(subCtx, "events", "group", ConsumerHandler{
+			Consumer: "stopped",
+			Handler: func(StreamMessage) error {
+				close(handling)
+				<-subCtx.Done()
+				return subCtx.Err()
+			},
+		}))
+		require.NoError(t, cache.Publish(ctx, "events", "unfinished"))
+		<-handling
+		stop()
+		// Claimed once idle for the claim interval
+		time.Sleep(5 * time.Millisecond)
+
+		received := make(chan string, 1)
+		require.NoError(t, cache.Subscribe(ctx, "events", "group", ConsumerHandler{
+			Consumer: "recovering",
+			Handler: func(message StreamMessage) error {
+				var got string
+				require.NoError(t, message.Decode(&got))
+				received <- got
+				return nil
+			},
+		}))
+
+		select {
+		case got := <-received:
+			assert.Equal(t, "unfinished", got)
+		case <-time.After(time.Second):
+			t.Fatal("pending message not claimed")
+		}
+	})
+}
+
+func TestMemoryCache_BatchConsumption(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newMemoryCache(&MockLogger{})
+	cache.policy = streamPolicy{maxRetries: 1, retryBackoff: time.Millisecond}
+
+	decode := func(messages []StreamMessage) []string {
+		values := make([]string, len(messages))
+		for i, message := range messages {
+			require.NoError(t, message.Decode(&values[i]))
+		}
+		return values
+	}
+
+	t.Run("batches are flushed when full or due", func(t *testing.T) {
+		require.NoError(t, cache.EnsureGroup(ctx, "batched", "group"))
+		batches := make(chan []string, 4)
+		require.NoError(t, cache.Subscribe(ctx, "batched", "group", ConsumerHandler{
+			Consumer: "batcher",
+			BatchHandler: func(messages []StreamMessage) error {
+				batches <- decode(messages)
+				return nil
+			},
+			BatchSize:     3,
+			FlushInterval: 50 * time.Millisecond,
+		}))
+		for _, value := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, cache.Publish(ctx, "batched", value))
+		}
+
+		assert.Equal(t, []string{"a", "b", "c"}, <-batches)
+		assert.Equal(t, []string{"d"}, <-batches)
+	})
+
+	t.Run("a failing batch is retried one message at a time", func(t *testing.T) {
+		require.NoError(t, cache.EnsureGroup(ctx, "isolated", "group"))
+		for _, value := range []string{"a", "poison", "c"} {
+			require.NoError(t, cache.Publish(ctx, "isolated", value))
+		}
+
+		var handled []string
+		var mu sync.Mutex
+		require.NoError(t, cache.Subscribe(ctx, "isolated", "group", ConsumerHandler{
+			Consumer: "batcher",
+			BatchHandler: func(messages []StreamMessage) error {
+				values := decode(messages)
+				if slices.Contains(values, "poison") {
+					return errors.New("boom")
+				}
+				mu.Lock()
+				handled = append(handled, values...)
+				mu.Unlock()
+				return nil
+			},
+			BatchSize:     3,
+			FlushInterval: 10 * time.Millisecond,
+		}))
+
+		assert.Eventually(t, func() bool {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			dead, ok := cache.streams["isolated:dead"]
+			return ok && len(dead.messages) == 1 && len(cache.streams["isolated"].groups["group"].pending) == 0
+		}, time.Second, 5*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []string{"a", "c"}, handled)
+	})
+
+	t.Run("rejected messages are dead-lettered at once", func(t *testing.T) {
+		require.NoError(t, cache.EnsureGroup(ctx, "rejecting", "group"))
+		for _, value := range []string{"a", "poison", "c"} {
+			require.NoError(t, cache.Publish(ctx, "rejecting", value))
+		}
+
+		var calls atomic.Int32
+		require.NoError(t, cache.Subscribe(ctx, "rejecting", "group", ConsumerHandler{
+			Consumer: "batcher",
+			BatchHandler: func(messages []StreamMessage) error {
+				calls.Add(1)
+				rejected := RejectedMessages{}
+				for _, message := range messages {
+					var value string
+					require.NoError(t, message.Decode(&value))
+					if value == "poison" {
+						rejected[message.ID] = errors.New("boom")
+					}
+				}
+				return rejected
+			},
+			BatchSize:     3,
+			FlushInterval: 10 * time.Millisecond,
+		}))
+
+		assert.Eventually(t, func() bool {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			dead, ok := cache.streams["rejecting:dead"]
+			return ok && len(dead.messages) == 1 && len(cache.streams["rejecting"].groups["group"].pending) == 0
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(1), calls.Load(), "rejections are not retried")
+
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		assert.Equal(t, "2-0", cache.streams["rejecting:dead"].messages[0].Values["source_id"])
+	})
+
+	t.Run("messages rejected with ErrRetryLater stay pending", func(t *testing.T) {
+		require.NoError(t, cache.EnsureGroup(ctx, "deferring", "group"))
+		for _, value := range []string{"a", "newer", "c"} {
+			require.NoError(t, cache.Publish(ctx, "deferring", value))
+		}
+
+		require.NoError(t, cache.Subscribe(ctx, "deferring", "group", ConsumerHandler{
+			Consumer: "batcher",
+			BatchHandler: func(messages []StreamMessage) error {
+				rejected := RejectedMessages{}
+				for _, message := range messages {
+					var value string
+					require.NoError(t, message.Decode(&value))
+					if value == "newer" {
+						rejected[message.ID] = fmt.Errorf("%w: newer schema version", ErrRetryLater)
+					}
+				}
+				return rejected
+			},
+			BatchSize:     3,
+			FlushInterval: 10 * time.Millisecond,
+		}))
+
+		assert.Eventually(t, func() bool {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			_, ok := cache.streams["deferring"].groups["group"].pending["2-0"]
+			return ok && len(cache.streams["deferring"].groups["group"].pending) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		_, deadLettered := cache.streams["deferring:dead"]
+		assert.False(t, deadLettered)
+	})
+
+	t.Run("messages failing with ErrRetryLater stay pending until claimed again", func(t *testing.T) {
+		cache.policy.claimIdle = 20 * time.Millisecond
+		defer func() { cache.policy.claimIdle = 0 }()
+		require.NoError(t, cache.EnsureGroup(ctx, "unavailable", "group"))
+		require.NoError(t, cache.Publish(ctx, "unavailable", "a"))
+
+		var down atomic.Bool
+		down.Store(true)
+		recorded := make(chan []string, 1)
+		require.NoError(t, cache.Subscribe(ctx, "unavailable", "group", ConsumerHandler{
+			Consumer: "batcher",
+			BatchHandler: func(messages []StreamMessage) error {
+				if down.Load() {
+					return fmt.Errorf("%w: store unavailable", ErrRetryLater)
+				}
+				recorded <- decode(messages)
+				return nil
+			},
+			FlushInterval: time.Millisecond,
+		}))
+
+		pending := func() int {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+			return len(cache.streams["unavailable"].groups["group"].pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, 1, pending())
+		cache.mu.Lock()
+		_, deadLettered := cache.streams["unavailable:dead"]
+		cache.mu.Unlock()
+		assert.False(t, deadLettered)
+
+		down.Store(false)
+		select {
+		case values := <-recorded:
+			assert.Equal(t, []string{"a"}, values)
+		case <-time.After(time.Second):
+			t.Fatal("pending message not claimed again")
+		}
+		assert.Eventually(t, func() bool { return pending() == 0 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestMemoryCache_Retention(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache(&MockLogger{})
+	cache.retentions = streamRetentions{fallback: streamRetention{maxLen: 3}}
+
+	length := func(stream string) int {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return len(cache.streams[stream].messages)
+	}
+
+	t.Run("streams are trimmed to their max length", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			require.NoError(t, cache.Publish(ctx, "capped", i))
+		}
+		assert.Equal(t, 3, length("capped"))
+	})
+
+	t.Run("messages a group still needs are kept", func(t *testing.T) {
+		require.NoError(t, cache.EnsureGroup(ctx, "needed", "group"))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, cache.Publish(ctx, "needed", i))
+		}
+		assert.Equal(t, 5, length("needed"))
+
+		// Delivered but pending messages are kept too; acknowledged ones are trimmed
+		messages, _ := cache.claimNext("needed", "group", "consumer", 4)
+		require.Len(t, messages, 4)
+		cache.consume(ctx, "needed", "group", ConsumerHandler{Handler: func(StreamMessage) error { return nil }}, messages[:2])
+		require.NoError(t, cache.Publish(ctx, "needed", 5))
+		assert.Equal(t, 4, length("needed"))
+
+		// Positions follow the trimmed stream
+		rest, _ := cache.claimNext("needed", "group", "consumer", 10)
+		require.Len(t, rest, 2)
+		assert.Equal(t, "5-0", rest[0].ID)
+	})
+}
+
+func TestMemoryCache_Workers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newMemoryCache(&MockLogger{})
+	require.NoError(t, cache.EnsureGroup(ctx, "pooled", "group"))
+
+	// Each worker holds a message until both have one, so both must be running
+	var started sync.WaitGroup
+	started.Add(2)
+	require.NoError(t, cache.Subscribe(ctx, "pooled", "group", ConsumerHandler{
+		Consumer: "instance",
+		Handler: func(StreamMessage) error {
+			started.Done()
+			started.Wait()
+			return nil
+		},
+		Workers: 2,
+	}))
+	require.NoError(t, cache.Publish(ctx, "pooled", "a"))
+	require.NoError(t, cache.Publish(ctx, "pooled", "b"))
+
+	assert.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return cache.streams["pooled"].groups["group"].next == 2 && len(cache.streams["pooled"].groups["group"].pending) == 0
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// =============================
+// 🔹 Stream Partitions
+// =============================
+
+// PartitionStream returns the partition of stream that messages with key are published to,
+// "<stream>:<n>", so messages sharing a key stay in order on one partition. With fewer than two
+// partitions it returns stream itself.
+func PartitionStream(stream, key string, partitions int) string {
+	if partitions <= 1 {
+		return stream
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return partitionName(stream, int(hash.Sum32()%uint32(partitions)))
+}
+
+// StreamPartitions returns the partitions of a stream split into partitions, or the stream
+// itself when it is not split.
+func StreamPartitions(stream string, partitions int) []string {
+	if partitions <= 1 {
+		return []string{stream}
+	}
+	names := make([]string, partitions)
+	for i := range names {
+		names[i] = partitionName(stream, i)
+	}
+	return names
+}
+
+func partitionName(stream string, partition int) string {
+	return fmt.Sprintf("%s:%d", stream, partition)
+}
+
+// BaseStream returns the stream a partition belongs to, or stream itself when it is not a
+// partition. Partitions share the settings of their stream.
+func BaseStream(stream string) string {
+	i := strings.LastIndex(stream, ":")
+	if i < 0 {
+		return stream
+	}
+	if _, err := strconv.Atoi(stream[i+1:]); err != nil {
+		return stream
+	}
+	return stream[:i]
+}
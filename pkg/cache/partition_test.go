@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionStream(t *testing.T) {
+	assert.Equal(t, "updates", PartitionStream("updates", "board", 0))
+	assert.Equal(t, "updates", PartitionStream("updates", "board", 1))
+
+	partition := PartitionStream("updates", "board", 8)
+	assert.Equal(t, partition, PartitionStream("updates", "board", 8), "a key always maps to the same partition")
+	assert.Contains(t, StreamPartitions("updates", 8), partition)
+
+	assert.Equal(t, []string{"updates"}, StreamPartitions("updates", 1))
+	assert.Equal(t, []string{"updates:0", "updates:1"}, StreamPartitions("updates", 2))
+}
+
+func TestBaseStream(t *testing.T) {
+	assert.Equal(t, "updates", BaseStream("updates:3"))
+	assert.Equal(t, "updates", BaseStream("updates"))
+	assert.Equal(t, "updates:dead", BaseStream("updates:dead"))
+
+	cfg := &config.AppConfig{}
+	cfg.Stream.Codecs = "updates=msgpack"
+	codecs, err := newStreamCodecs(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, CodecMsgPack, codecs.forStream("updates:3").Name(), "partitions share the codec of their stream")
+}
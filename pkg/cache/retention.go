@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+)
+
+// =============================
+// 🔹 Stream Retention
+// =============================
+
+const (
+	// streamTrimInterval throttles trimming of a Redis stream, which is checked on publish.
+	streamTrimInterval = time.Duration(1 * time.Second)
+	// streamTrimBatch bounds the entries checked against consumer groups, and so trimmed, at once.
+	streamTrimBatch = 1000
+)
+
+// streamRetention bounds a stream by entry count or by entry age. Its zero value keeps every
+// entry.
+type streamRetention struct {
+	maxLen int64
+	maxAge time.Duration
+}
+
+// parseStreamRetention reads a retention written as a maximum length, such as "100000", or as
+// a maximum age, such as "24h". An empty value or "0" keeps every entry.
+func parseStreamRetention(value string) (streamRetention, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return streamRetention{}, nil
+	}
+	if maxLen, err := strconv.ParseInt(value, 10, 64); err == nil && maxLen >= 0 {
+		return streamRetention{maxLen: maxLen}, nil
+	}
+	if maxAge, err := time.ParseDuration(value); err == nil && maxAge >= 0 {
+		return streamRetention{maxAge: maxAge}, nil
+	}
+	return streamRetention{}, fmt.Errorf("invalid stream retention %q, expected a length or a duration", value)
+}
+
+func (r streamRetention) keepsAll() bool {
+	return r.maxLen == 0 && r.maxAge == 0
+}
+
+// streamRetentions selects the retention of each stream.
+type streamRetentions struct {
+	byStream map[string]streamRetention
+	fallback streamRetention
+}
+
+func newStreamRetentions(config *config.AppConfig) (streamRetentions, error) {
+	retentions := streamRetentions{byStream: make(map[string]streamRetention)}
+
+	fallback, err := parseStreamRetention(config.Stream.Retention)
+	if err != nil {
+		return retentions, err
+	}
+	retentions.fallback = fallback
+
+	for _, pair := range strings.Split(config.Stream.Retentions, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		stream, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return retentions, fmt.Errorf("invalid stream retention %q, expected stream=retention", pair)
+		}
+		retention, err := parseStreamRetention(value)
+		if err != nil {
+			return retentions, err
+		}
+		retentions.byStream[strings.TrimSpace(stream)] = retention
+	}
+
+	return retentions, nil
+}
+
+// forStream returns the retention of a stream, keeping every entry unless configured otherwise.
+func (s streamRetentions) forStream(stream string) streamRetention {
+	if retention, ok := s.byStream[stream]; ok {
+		return retention
+	}
+	if retention, ok := s.byStream[BaseStream(stream)]; ok {
+		return retention
+	}
+	return s.fallback
+}
+
+// parseStreamID splits a stream entry ID into its milliseconds and sequence parts. A missing
+// sequence reads as 0.
+func parseStreamID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	if ms, err = strconv.ParseUint(msPart, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid stream ID %q", id)
+	}
+	if seqPart != "" {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid stream ID %q", id)
+		}
+	}
+	return ms, seq, nil
+}
+
+// compareStreamIDs orders stream entry IDs like Redis does. IDs that cannot be parsed compare
+// as equal.
+func compareStreamIDs(a, b string) int {
+	aMs, aSeq, errA := parseStreamID(a)
+	bMs, bSeq, errB := parseStreamID(b)
+	switch {
+	case errA != nil || errB != nil:
+		return 0
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// nextStreamID returns the smallest entry ID after id.
+func nextStreamID(id string) (string, error) {
+	ms, seq, err := parseStreamID(id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", ms, seq+1), nil
+}
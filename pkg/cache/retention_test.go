@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStreamRetentions(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Stream.Retention = "24h"
+	cfg.Stream.Retentions = "updates=1000, audit = 0"
+
+	retentions, err := newStreamRetentions(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, streamRetention{maxLen: 1000}, retentions.forStream("updates"))
+	assert.True(t, retentions.forStream("audit").keepsAll())
+	assert.Equal(t, streamRetention{maxAge: 24 * time.Hour}, retentions.forStream("other"))
+
+	assert.True(t, streamRetentions{}.forStream("other").keepsAll())
+
+	cfg.Stream.Retentions = "updates=forever"
+	_, err = newStreamRetentions(cfg)
+	assert.Error(t, err)
+}
+
+func TestStreamIDs(t *testing.T) {
+	assert.Equal(t, -1, compareStreamIDs("1-5", "2-0"))
+	assert.Equal(t, -1, compareStreamIDs("2-1", "2-10"))
+	assert.Equal(t, 1, compareStreamIDs("3", "2-9"))
+	assert.Equal(t, 0, compareStreamIDs("2-0", "2"))
+
+	next, err := nextStreamID("5-9")
+	require.NoError(t, err)
+	assert.Equal(t, "5-10", next)
+
+	_, err = nextStreamID("invalid")
+	assert.Error(t, err)
+}
+
+func TestAppCache_Retention(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(ctx)
+
+	cache := &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+		retentions:  streamRetentions{fallback: streamRetention{maxLen: 10}},
+	}
+
+	t.Run("streams are trimmed to their max length", func(t *testing.T) {
+		stream := "test-retention-len"
+		for i := 0; i < 300; i++ {
+			require.NoError(t, redisClient.XAdd(ctx, &redis.XAddArgs{Stream: cache.taggedKey(stream), Values: map[string]any{"i": i}}).Err())
+		}
+		require.NoError(t, cache.Publish(ctx, stream, "last"))
+
+		length, err := redisClient.XLen(ctx, cache.taggedKey(stream)).Result()
+		require.NoError(t, err)
+		assert.Less(t, length, int64(301))
+		assert.GreaterOrEqual(t, length, int64(10))
+	})
+
+	t.Run("entries a group still needs are kept", func(t *testing.T) {
+		stream, group := "test-retention-pending", "group"
+		rKey := cache.taggedKey(stream)
+		require.NoError(t, cache.EnsureGroup(ctx, stream, group))
+		for i := 0; i < 300; i++ {
+			require.NoError(t, redisClient.XAdd(ctx, &redis.XAddArgs{Stream: rKey, Values: map[string]any{"i": i}}).Err())
+		}
+		// A consumer reads the first entries without acknowledging them
+		read, err := redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: "slow",
+			Streams:  []string{rKey, ">"},
+			Count:    5,
+		}).Result()
+		require.NoError(t, err)
+		oldest := read[0].Messages[0].ID
+
+		require.NoError(t, cache.Publish(ctx, stream, "last"))
+
+		entries, err := redisClient.XRangeN(ctx, rKey, "-", "+", 1).Result()
+		require.NoError(t, err)
+		assert.Equal(t, oldest, entries[0].ID)
+	})
+}
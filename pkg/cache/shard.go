@@ -0,0 +1,335 @@
+package cache
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// =============================
+// 🔹 Sharded Leaderboard (Sorted Sets)
+// =============================
+
+// shardSeparator separates a board key from its shard count in a sharded board key.
+const shardSeparator = "#"
+
+// ShardedBoardKey names a leaderboard whose members are hash-partitioned over shards sorted
+// sets. The leaderboard methods of ICache accept it like any other board key; boards with a
+// single shard are stored in one sorted set.
+func ShardedBoardKey(boardKey string, shards int) string {
+	if shards <= 1 {
+		return boardKey
+	}
+	return boardKey + shardSeparator + strconv.Itoa(shards)
+}
+
+// parseBoardKey splits a board key into its base key and shard count.
+func parseBoardKey(boardKey string) (string, int) {
+	i := strings.LastIndex(boardKey, shardSeparator)
+	if i < 0 {
+		return boardKey, 1
+	}
+	shards, err := strconv.Atoi(boardKey[i+1:])
+	if err != nil || shards <= 1 {
+		return boardKey, 1
+	}
+	return boardKey[:i], shards
+}
+
+// shardOf returns the shard a member is stored in.
+func shardOf(member string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(member))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// shardKeys returns the Redis keys of a board's shards. Each shard has its own hash tag so the
+// shards of one board spread over cluster slots instead of piling onto one node.
+func (c *appCache) shardKeys(baseKey string, shards int) []string {
+	keys := make([]string, shards)
+	for i := range keys {
+		keys[i] = c.prefixedKey(fmt.Sprintf("%s:{%s:%d}", baseKey, baseKey, i))
+	}
+	return keys
+}
+
+func (c *appCache) shardedAddScores(ctx context.Context, baseKey string, shards int, scores map[string]float64) error {
+	keys := c.shardKeys(baseKey, shards)
+	grouped := make(map[int][]redis.Z)
+	for member, score := range scores {
+		shard := shardOf(member, shards)
+		grouped[shard] = append(grouped[shard], redis.Z{Score: score, Member: member})
+	}
+
+	// Shards are separate keys, so members of different shards are not added atomically
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for shard, members := range grouped {
+			pipe.ZAdd(ctx, keys[shard], members...)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *appCache) shardedCount(ctx context.Context, baseKey string, shards int) (int64, error) {
+	cmds := make([]*redis.IntCmd, shards)
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range c.shardKeys(baseKey, shards) {
+			cmds[i] = pipe.ZCard(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, cmd := range cmds {
+		total += cmd.Val()
+	}
+	return total, nil
+}
+
+// shardedRange returns the members between the 0-based start and stop positions (inclusive) of
+// a sharded board. Every shard is read from its top down to stop, so deep pages cost more.
+func (c *appCache) shardedRange(ctx context.Context, baseKey string, shards int, start, stop int64) ([]LeaderboardEntry, error) {
+	if start < 0 {
+		start = 0
+	}
+	if stop < start {
+		return []LeaderboardEntry{}, nil
+	}
+
+	cmds := make([]*redis.ZSliceCmd, shards)
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range c.shardKeys(baseKey, shards) {
+			cmds[i] = pipe.ZRevRangeWithScores(ctx, key, 0, stop)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([][]LeaderboardEntry, shards)
+	for i, cmd := range cmds {
+		lists[i] = toEntries(cmd.Val())
+	}
+
+	merged := mergeShardEntries(lists, stop+1)
+	if start >= int64(len(merged)) {
+		return []LeaderboardEntry{}, nil
+	}
+	return merged[start:], nil
+}
+
+// rangeBelow returns up to count members ranked right below a member holding score, down to
+// minScore, from a board stored in keys, the member's own shard being own. Each shard is read
+// from the member's position on it rather than from its top: the shards before the member's
+// rank its ties above it, and its own shard is read from its rank there. When the member left
+// the board or, on a sharded board, no longer holds score, it is placed below its ties on its
+// own shard.
+func (c *appCache) rangeBelow(ctx context.Context, keys []string, own int, member string, score, minScore float64, count int64) ([]LeaderboardEntry, error) {
+	if count <= 0 {
+		return []LeaderboardEntry{}, nil
+	}
+	scoreArg := strconv.FormatFloat(score, 'g', -1, 64)
+	minArg := "-inf"
+	if !math.IsInf(minScore, -1) {
+		minArg = strconv.FormatFloat(minScore, 'g', -1, 64)
+	}
+
+	var current *redis.FloatCmd
+	var rank *redis.IntCmd
+	_, err := c.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		current = pipe.ZScore(ctx, keys[own], member)
+		rank = pipe.ZRevRank(ctx, keys[own], member)
+		return nil
+	})
+	if err != nil && !IsNil(err) {
+		return nil, err
+	}
+	// A board of one sorted set is read by the member's rank alone, which a rebase of decaying
+	// scores leaves in place
+	ranked := current.Err() == nil && (len(keys) == 1 || current.Val() == score)
+
+	cmds := make([]*redis.ZSliceCmd, len(keys))
+	_, err = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			switch {
+			case i == own && ranked:
+				cmds[i] = pipe.ZRevRangeWithScores(ctx, key, rank.Val()+1, rank.Val()+count)
+			case i <= own:
+				cmds[i] = pipe.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Max: "(" + scoreArg, Min: minArg, Count: count})
+			default:
+				cmds[i] = pipe.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Max: scoreArg, Min: minArg, Count: count})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([][]LeaderboardEntry, len(keys))
+	for i, cmd := range cmds {
+		lists[i] = toEntries(cmd.Val())
+		if i == own && ranked {
+			// The own shard is read by rank, which does not stop at minScore
+			for j, entry := range lists[i] {
+				if entry.Score < minScore {
+					lists[i] = lists[i][:j]
+					break
+				}
+			}
+		}
+	}
+	return mergeShardEntries(lists, count), nil
+}
+
+// shardedRank returns the exact 1-based rank and score of a member by summing, over every
+// shard, the members ranked above it.
+func (c *appCache) shardedRank(ctx context.Context, baseKey string, shards int, member string) (int64, float64, error) {
+	keys := c.shardKeys(baseKey, shards)
+	score, err := c.redisClient.ZScore(ctx, keys[shardOf(member, shards)], member).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	above, err := c.shardedRankAbove(ctx, baseKey, shards, score, member)
+	if err != nil {
+		return 0, 0, err
+	}
+	return above + 1, score, nil
+}
+
+// shardedRankAbove counts the members of a sharded board ranked above a score and member with
+// read-only commands. Members sharing a score are ranked by shard, lower shards first, so the
+// other shards only count scores, and the member's own shard resolves its ties with ZREVRANK.
+// When the member no longer holds the score, it is ranked below its ties on its own shard.
+func (c *appCache) shardedRankAbove(ctx context.Context, baseKey string, shards int, score float64, member string) (int64, error) {
+	scoreArg := strconv.FormatFloat(score, 'g', -1, 64)
+	own := shardOf(member, shards)
+
+	counts := make([]*redis.IntCmd, shards)
+	var current *redis.FloatCmd
+	var rank *redis.IntCmd
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range c.shardKeys(baseKey, shards) {
+			switch {
+			case i < own:
+				counts[i] = pipe.ZCount(ctx, key, scoreArg, "+inf")
+			case i == own:
+				current = pipe.ZScore(ctx, key, member)
+				rank = pipe.ZRevRank(ctx, key, member)
+				counts[i] = pipe.ZCount(ctx, key, "("+scoreArg, "+inf")
+			default:
+				counts[i] = pipe.ZCount(ctx, key, "("+scoreArg, "+inf")
+			}
+		}
+		return nil
+	})
+	// A member missing from its shard is not an error here
+	if err != nil && !IsNil(err) {
+		return 0, err
+	}
+
+	var above int64
+	for i, cmd := range counts {
+		if i != own {
+			above += cmd.Val()
+		}
+	}
+
+	memberScore, err := current.Result()
+	switch {
+	case err == nil && memberScore == score:
+		above += rank.Val()
+	case err == nil && memberScore > score:
+		// The member itself is counted among the higher scores
+		above += counts[own].Val() - 1
+	default:
+		above += counts[own].Val()
+	}
+	return above, nil
+}
+
+func (c *appCache) shardedDelete(ctx context.Context, baseKey string, shards int) error {
+	// Shards live in different slots, so they are deleted one key at a time
+	_, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range c.shardKeys(baseKey, shards) {
+			pipe.Del(ctx, key, histogramKey(key))
+		}
+		return nil
+	})
+	return err
+}
+
+// mergeShardEntries merges per-shard lists sorted in descending order into the first limit
+// entries of the whole board. Ties are ordered by shard, lower shards first, as shardedRankAbove
+// ranks them.
+func mergeShardEntries(lists [][]LeaderboardEntry, limit int64) []LeaderboardEntry {
+	h := &shardHeap{}
+	for i, list := range lists {
+		if len(list) > 0 {
+			*h = append(*h, shardCursor{list: i, entry: list[0]})
+		}
+	}
+	heap.Init(h)
+
+	positions := make([]int, len(lists))
+	merged := make([]LeaderboardEntry, 0)
+	for h.Len() > 0 && int64(len(merged)) < limit {
+		top := heap.Pop(h).(shardCursor)
+		merged = append(merged, top.entry)
+
+		positions[top.list]++
+		if next := positions[top.list]; next < len(lists[top.list]) {
+			heap.Push(h, shardCursor{list: top.list, entry: lists[top.list][next]})
+		}
+	}
+
+	return merged
+}
+
+type shardCursor struct {
+	list  int
+	entry LeaderboardEntry
+}
+
+// shardHeap pops the highest ranked entry first.
+type shardHeap []shardCursor
+
+func (h shardHeap) Len() int { return len(h) }
+func (h shardHeap) Less(i, j int) bool {
+	if h[i].entry.Score != h[j].entry.Score {
+		return h[i].entry.Score > h[j].entry.Score
+	}
+	return h[i].list < h[j].list
+}
+func (h shardHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *shardHeap) Push(x any)   { *h = append(*h, x.(shardCursor)) }
+func (h *shardHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+func toEntries(zResult []redis.Z) []LeaderboardEntry {
+	entries := make([]LeaderboardEntry, len(zResult))
+	for i, z := range zResult {
+		entries[i] = LeaderboardEntry{
+			Member: z.Member,
+			Score:  z.Score,
+		}
+	}
+	return entries
+}
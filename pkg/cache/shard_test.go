@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedBoardKey(t *testing.T) {
+	t.Run("single shard keeps the board key", func(t *testing.T) {
+		assert.Equal(t, "board", ShardedBoardKey("board", 0))
+		assert.Equal(t, "board", ShardedBoardKey("board", 1))
+	})
+
+	t.Run("parseBoardKey reverses ShardedBoardKey", func(t *testing.T) {
+		baseKey, shards := parseBoardKey(ShardedBoardKey("board", 16))
+		assert.Equal(t, "board", baseKey)
+		assert.Equal(t, 16, shards)
+	})
+
+	t.Run("parseBoardKey ignores keys without a shard count", func(t *testing.T) {
+		baseKey, shards := parseBoardKey("board#abc")
+		assert.Equal(t, "board#abc", baseKey)
+		assert.Equal(t, 1, shards)
+	})
+}
+
+func TestMergeShardEntries(t *testing.T) {
+	lists := [][]LeaderboardEntry{
+		{{Member: "a", Score: 90}, {Member: "d", Score: 50}},
+		{},
+		{{Member: "b", Score: 80}, {Member: "e", Score: 50}, {Member: "c", Score: 10}},
+	}
+
+	t.Run("merges shards in rank order", func(t *testing.T) {
+		merged := mergeShardEntries(lists, 10)
+		members := make([]any, len(merged))
+		for i, entry := range merged {
+			members[i] = entry.Member
+		}
+		// Ties across shards are ordered by shard
+		assert.Equal(t, []any{"a", "b", "d", "e", "c"}, members)
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		assert.Len(t, mergeShardEntries(lists, 2), 2)
+	})
+}
+
+func TestAppCache_ShardedLeaderboard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(ctx)
+
+	cache := &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+	}
+
+	boardKey := ShardedBoardKey("test-sharded", 4)
+	scores := make(map[string]float64)
+	for i := 0; i < 20; i++ {
+		scores[fmt.Sprintf("player%02d", i)] = float64(i % 10)
+	}
+	require.NoError(t, cache.AddScores(ctx, boardKey, scores))
+
+	t.Run("Count sums every shard", func(t *testing.T) {
+		count, err := cache.Count(ctx, boardKey)
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), count)
+	})
+
+	t.Run("GetTopN merges shards", func(t *testing.T) {
+		entries, err := cache.GetTopN(ctx, boardKey, 3)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.ElementsMatch(t, []any{"player09", "player19"}, []any{entries[0].Member, entries[1].Member})
+		assert.Equal(t, float64(9), entries[1].Score)
+		assert.Equal(t, float64(8), entries[2].Score)
+	})
+
+	t.Run("GetRank matches the merged order", func(t *testing.T) {
+		entries, err := cache.GetRange(ctx, boardKey, 0, 19)
+		require.NoError(t, err)
+		require.Len(t, entries, 20)
+
+		for i, entry := range entries {
+			rank, score, err := cache.GetRank(ctx, boardKey, entry.Member.(string))
+			require.NoError(t, err)
+			assert.Equal(t, int64(i+1), rank)
+			assert.Equal(t, entry.Score, score)
+		}
+	})
+
+	t.Run("Delete removes every shard", func(t *testing.T) {
+		require.NoError(t, cache.Delete(ctx, boardKey))
+
+		count, err := cache.Count(ctx, boardKey)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+// testRangePaging runs the GetRangeAfter contract against a cache driver.
+func testRangePaging(t *testing.T, cache ICache, boardKey string) {
+	ctx := context.Background()
+	scores := make(map[string]float64)
+	for i := 0; i < 25; i++ {
+		scores[fmt.Sprintf("player%02d", i)] = float64(i % 7)
+	}
+	require.NoError(t, cache.AddScores(ctx, boardKey, scores))
+
+	all, err := cache.GetRange(ctx, boardKey, 0, 24)
+	require.NoError(t, err)
+	require.Len(t, all, 25)
+
+	t.Run("pages follow the board order", func(t *testing.T) {
+		var paged []LeaderboardEntry
+		var after *LeaderboardEntry
+		for {
+			page, err := cache.GetRangeAfter(ctx, boardKey, after, 4)
+			require.NoError(t, err)
+			paged = append(paged, page...)
+			if len(page) < 4 {
+				break
+			}
+			after = &page[len(page)-1]
+		}
+		assert.Equal(t, all, paged)
+	})
+
+	t.Run("a member that left is paged past by its score", func(t *testing.T) {
+		after := all[10]
+		require.NoError(t, cache.RemoveMember(ctx, boardKey, after.Member.(string)))
+
+		page, err := cache.GetRangeAfter(ctx, boardKey, &after, 3)
+		require.NoError(t, err)
+		require.Len(t, page, 3)
+		for _, entry := range page {
+			assert.NotEqual(t, after.Member, entry.Member)
+			assert.LessOrEqual(t, entry.Score, after.Score)
+		}
+	})
+}
+
+func TestMemoryCache_RangePaging(t *testing.T) {
+	testRangePaging(t, newMemoryCache(&MockLogger{}), "board")
+}
+
+func TestAppCache_RangePaging(t *testing.T) {
+	cache := newMiniredisCache(t)
+	for _, boardKey := range []string{"paged", ShardedBoardKey("sharded-paged", 4)} {
+		t.Run(boardKey, func(t *testing.T) {
+			testRangePaging(t, cache, boardKey)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package cache
+
+import "math/rand/v2"
+
+const (
+	skipListMaxLevel = 32
+	skipListP        = 0.25
+)
+
+// skipList is a sorted set ordered like a Redis sorted set: by ascending score, then ascending
+// member. Every link records how many elements it skips, so ranks are found in O(log n).
+type skipList struct {
+	head   *skipListNode
+	level  int
+	length int
+	scores map[string]float64
+}
+
+type skipListNode struct {
+	member string
+	score  float64
+	levels []skipListLevel
+}
+
+type skipListLevel struct {
+	next *skipListNode
+	span int
+}
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:   &skipListNode{levels: make([]skipListLevel, skipListMaxLevel)},
+		level:  1,
+		scores: make(map[string]float64),
+	}
+}
+
+func randomSkipListLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// before reports whether a node with the given score and member sorts before score and member.
+func (n *skipListNode) before(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+// Set adds a member or moves it to a new score.
+func (l *skipList) Set(member string, score float64) {
+	if current, ok := l.scores[member]; ok {
+		if current == score {
+			return
+		}
+		l.remove(member, current)
+	}
+	l.insert(member, score)
+	l.scores[member] = score
+}
+
+// Score returns a member's score.
+func (l *skipList) Score(member string) (float64, bool) {
+	score, ok := l.scores[member]
+	return score, ok
+}
+
+// Remove deletes a member and reports whether it was present.
+func (l *skipList) Remove(member string) bool {
+	score, ok := l.scores[member]
+	if !ok {
+		return false
+	}
+	l.remove(member, score)
+	delete(l.scores, member)
+	return true
+}
+
+// Len returns the number of members.
+func (l *skipList) Len() int {
+	return l.length
+}
+
+// Rank returns the 0-based ascending rank of a member.
+func (l *skipList) Rank(member string) (int, bool) {
+	score, ok := l.scores[member]
+	if !ok {
+		return 0, false
+	}
+
+	rank := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for next := x.levels[i].next; next != nil && (next.before(score, member) || next.member == member); next = x.levels[i].next {
+			rank += x.levels[i].span
+			x = next
+			if x.member == member {
+				return rank - 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// CountBefore returns the number of members sorting before score and member, whether or not the
+// member is in the list.
+func (l *skipList) CountBefore(score float64, member string) int {
+	count := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for next := x.levels[i].next; next != nil && next.before(score, member); next = x.levels[i].next {
+			count += x.levels[i].span
+			x = next
+		}
+	}
+	return count
+}
+
+// Range calls fn for the members between the 0-based ascending ranks start and stop (inclusive),
+// in ascending order.
+func (l *skipList) Range(start, stop int, fn func(member string, score float64)) {
+	if start < 0 {
+		start = 0
+	}
+	if stop >= l.length {
+		stop = l.length - 1
+	}
+	if start > stop {
+		return
+	}
+
+	// Walk down to the node at rank start, then follow the bottom level
+	traversed := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && traversed+x.levels[i].span <= start+1 {
+			traversed += x.levels[i].span
+			x = x.levels[i].next
+		}
+	}
+	for rank := start; rank <= stop && x != nil; rank++ {
+		fn(x.member, x.score)
+		x = x.levels[0].next
+	}
+}
+
+func (l *skipList) insert(member string, score float64) {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].next != nil && x.levels[i].next.before(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].next
+		}
+		update[i] = x
+	}
+
+	level := randomSkipListLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].levels[i].span = l.length
+		}
+		l.level = level
+	}
+
+	node := &skipListNode{member: member, score: score, levels: make([]skipListLevel, level)}
+	for i := 0; i < level; i++ {
+		node.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = node
+		node.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = (rank[0] - rank[i]) + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].levels[i].span++
+	}
+	l.length++
+}
+
+func (l *skipList) remove(member string, score float64) {
+	var update [skipListMaxLevel]*skipListNode
+
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && x.levels[i].next.before(score, member) {
+			x = x.levels[i].next
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].next
+	if x == nil || x.member != member {
+		return
+	}
+
+	for i := 0; i < l.level; i++ {
+		if update[i].levels[i].next == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].next = x.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	for l.level > 1 && l.head.levels[l.level-1].next == nil {
+		l.level--
+	}
+	l.length--
+}
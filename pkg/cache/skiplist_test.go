@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipList(t *testing.T) {
+	t.Run("orders by score then member", func(t *testing.T) {
+		l := newSkipList()
+		l.Set("b", 10)
+		l.Set("a", 10)
+		l.Set("c", 5)
+
+		var members []string
+		l.Range(0, l.Len()-1, func(member string, _ float64) {
+			members = append(members, member)
+		})
+		assert.Equal(t, []string{"c", "a", "b"}, members)
+	})
+
+	t.Run("moving and removing members keeps ranks", func(t *testing.T) {
+		l := newSkipList()
+		l.Set("a", 1)
+		l.Set("b", 2)
+		l.Set("c", 3)
+
+		l.Set("a", 4)
+		rank, ok := l.Rank("a")
+		require.True(t, ok)
+		assert.Equal(t, 2, rank)
+
+		assert.True(t, l.Remove("b"))
+		assert.False(t, l.Remove("b"))
+		rank, _ = l.Rank("a")
+		assert.Equal(t, 1, rank)
+		assert.Equal(t, 2, l.Len())
+
+		_, ok = l.Rank("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("matches a sorted slice under random updates", func(t *testing.T) {
+		l := newSkipList()
+		scores := make(map[string]float64)
+		for i := 0; i < 2000; i++ {
+			member := fmt.Sprintf("m%03d", rand.IntN(300))
+			if rand.IntN(4) == 0 {
+				l.Remove(member)
+				delete(scores, member)
+				continue
+			}
+			score := float64(rand.IntN(50))
+			l.Set(member, score)
+			scores[member] = score
+		}
+
+		expected := make([]string, 0, len(scores))
+		for member := range scores {
+			expected = append(expected, member)
+		}
+		sort.Slice(expected, func(i, j int) bool {
+			if scores[expected[i]] != scores[expected[j]] {
+				return scores[expected[i]] < scores[expected[j]]
+			}
+			return expected[i] < expected[j]
+		})
+
+		require.Equal(t, len(expected), l.Len())
+		for i, member := range expected {
+			rank, ok := l.Rank(member)
+			require.True(t, ok)
+			assert.Equal(t, i, rank)
+		}
+
+		var window []string
+		l.Range(10, 19, func(member string, _ float64) {
+			window = append(window, member)
+		})
+		assert.Equal(t, expected[10:20], window)
+	})
+}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================
+// 🔹 Score Updates with Standing
+// =============================
+
+// ErrBoardFull is returned when a new member would grow a board beyond ScoreUpdate.MaxMembers.
+var ErrBoardFull = errors.New("board is full")
+
+// boardFullReply is the error updateScoreScript replies with for a full board.
+const boardFullReply = "BOARDFULL"
+
+// joinLockTTL bounds how long a member joining a sharded board with a member limit holds the
+// board's join lock, and joinRetryInterval spaces attempts to take it.
+const (
+	joinLockTTL       = 5 * time.Second
+	joinRetryInterval = 10 * time.Millisecond
+)
+
+// updateScoreScript writes one member's score and reads its standing around the write in the
+// same step, so concurrent updates never see each other half done. A member new to the board
+// stands one below the last place before the write.
+//
+// On decaying boards the script also reads the epoch, rebases the board onto now when the
+// epoch is too old and weights the score by the growth since the epoch, all in that same step,
+// so every score of the board stays on one scale. Scores are returned in current points.
+//
+// KEYS are the sorted set, its histogram and its decay epoch. ARGV holds the member, the score,
+// whether to increment instead of set, whether to keep the histogram, whether to rank the
+// member, how many passed members to return, the decay arguments from decayArgs, the member
+// limit and whether to keep a higher score already stored.
+var updateScoreScript = histogramScript(`
+local member, value = ARGV[1], ARGV[2]
+local ranked, passedLimit = ARGV[5] == "1", tonumber(ARGV[6])
+local halfLife, maxMembers = tonumber(ARGV[7]), tonumber(ARGV[11])
+
+if maxMembers > 0 and not redis.call("ZSCORE", KEYS[1], member) and redis.call("ZCARD", KEYS[1]) >= maxMembers then
+	return redis.error_reply("` + boardFullReply + ` board is full")
+end
+
+local growth = 1
+if halfLife > 0 then
+	local epoch = redis.call("GET", KEYS[3])
+	if not epoch then
+		epoch = ARGV[9]
+		redis.call("SET", KEYS[3], epoch)
+	end
+	local exponent = (tonumber(ARGV[8]) - tonumber(epoch)) / halfLife
+	if exponent > tonumber(ARGV[10]) then
+		redis.call("ZUNIONSTORE", KEYS[1], 1, KEYS[1], "WEIGHTS", string.format("%.17g", 2 ^ -exponent))
+		redis.call("SET", KEYS[3], ARGV[8])
+		exponent = 0
+	end
+	growth = 2 ^ exponent
+	value = string.format("%.17g", tonumber(value) * growth)
+end
+local function current(score)
+	if growth == 1 then
+		return score
+	end
+	return string.format("%.17g", tonumber(score) / growth)
+end
+
+local old = redis.call("ZSCORE", KEYS[1], member)
+local prevRank = 0
+if ranked then
+	if old then
+		prevRank = redis.call("ZREVRANK", KEYS[1], member) + 1
+	else
+		prevRank = redis.call("ZCARD", KEYS[1]) + 1
+	end
+end
+
+local score = value
+if ARGV[3] == "1" then
+	score = redis.call("ZINCRBY", KEYS[1], value, member)
+elseif ARGV[12] == "1" and old and tonumber(old) >= tonumber(value) then
+	score = old
+else
+	redis.call("ZADD", KEYS[1], value, member)
+end
+if ARGV[4] == "1" then
+	track(KEYS[2], old, score)
+end
+
+local rank, passed = 0, {}
+if ranked then
+	rank = redis.call("ZREVRANK", KEYS[1], member) + 1
+	-- Passed members now sit right below the member, at 0-based positions rank..prevRank-1
+	if passedLimit > 0 and rank < prevRank then
+		passed = redis.call("ZREVRANGE", KEYS[1], rank, math.min(prevRank - 1, rank + passedLimit - 1), "WITHSCORES")
+		for i = 2, #passed, 2 do
+			passed[i] = current(passed[i])
+		end
+	end
+end
+
+return {old and 0 or 1, prevRank, current(old or "0"), rank, current(score), passed}
+`)
+
+// UpdateScore writes one member's score and returns its standing right before and after the
+// write. On a single sorted set the standing is read in the same step as the write. Sharded
+// boards rank the member across shards once it is written, so concurrent updates of a sharded
+// board may skew the reported standing, and they cannot decay.
+func (c *appCache) UpdateScore(ctx context.Context, boardKey string, update ScoreUpdate) (*ScoreStanding, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	baseKey, shards := parseBoardKey(boardKey)
+	rKey := c.taggedKey(boardKey)
+	maxMembers := update.MaxMembers
+	if shards > 1 {
+		if update.Decay != nil {
+			return nil, errShardedDecay
+		}
+		rKey = c.shardKeys(baseKey, shards)[shardOf(update.Member, shards)]
+
+		// A shard only counts its own members, so the limit is checked across shards instead
+		if maxMembers > 0 {
+			release, err := c.admitShardedMember(ctx, baseKey, shards, rKey, update.Member, maxMembers)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			maxMembers = 0
+		}
+	}
+
+	// A shard only knows its own members, so sharded boards are ranked afterwards
+	ranked := (update.Ranked || update.Passed > 0) && shards <= 1
+	args := append([]any{update.Member, strconv.FormatFloat(update.Score, 'g', -1, 64), flag(update.Incr),
+		flag(update.Tracked), flag(ranked), update.Passed}, decayArgs(update.Decay, time.Now())...)
+	args = append(args, maxMembers, flag(update.Best))
+	result, err := updateScoreScript.Run(ctx, c.redisClient, []string{rKey, histogramKey(rKey), decayEpochKey(rKey)}, args...).Slice()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), boardFullReply) {
+			return nil, ErrBoardFull
+		}
+		return nil, err
+	}
+	standing, err := parseStanding(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if shards > 1 && (update.Ranked || update.Passed > 0) {
+		if err := c.shardedStanding(ctx, baseKey, shards, update, standing); err != nil {
+			return nil, err
+		}
+	}
+	return standing, nil
+}
+
+// admitShardedMember checks a member about to be written to a sharded board against the board's
+// member limit. New members join one at a time under a lock on the board, so concurrent joins
+// cannot overshoot the limit; the returned release must be called once the member is written.
+func (c *appCache) admitShardedMember(ctx context.Context, baseKey string, shards int, shardKey string, member string, maxMembers int64) (func(), error) {
+	if err := c.redisClient.ZScore(ctx, shardKey, member).Err(); !IsNil(err) {
+		// Members already on the board do not count against the limit again
+		return func() {}, err
+	}
+
+	var lock *Lock
+	for {
+		var err error
+		lock, err = c.AcquireLock(ctx, baseKey+":join", joinLockTTL)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrLockHeld) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(joinRetryInterval):
+		}
+	}
+	release := func() {
+		if err := c.ReleaseLock(context.WithoutCancel(ctx), lock); err != nil {
+			c.logger.Error("Failed to release board join lock", "board", baseKey, "error", err)
+		}
+	}
+
+	total, err := c.shardedCount(ctx, baseKey, shards)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if total >= maxMembers {
+		release()
+		return nil, ErrBoardFull
+	}
+	return release, nil
+}
+
+// shardedStanding ranks a member just written to a sharded board before and after the write.
+// Passed members hold scores between the member's previous and new ones, so every shard is only
+// read over that score range.
+func (c *appCache) shardedStanding(ctx context.Context, baseKey string, shards int, update ScoreUpdate, standing *ScoreStanding) error {
+	rank, _, err := c.shardedRank(ctx, baseKey, shards, update.Member)
+	if err != nil {
+		return err
+	}
+	standing.Rank = rank
+
+	if standing.New {
+		total, err := c.shardedCount(ctx, baseKey, shards)
+		if err != nil {
+			return err
+		}
+		standing.PrevRank = total
+	} else {
+		above, err := c.shardedRankAbove(ctx, baseKey, shards, standing.PrevScore, update.Member)
+		if err != nil {
+			return err
+		}
+		standing.PrevRank = above + 1
+	}
+
+	if update.Passed > 0 && standing.Rank < standing.PrevRank {
+		// A new member stood below every member, whatever their scores
+		minScore := standing.PrevScore
+		if standing.New {
+			minScore = math.Inf(-1)
+		}
+		keys := c.shardKeys(baseKey, shards)
+		passed, err := c.rangeBelow(ctx, keys, shardOf(update.Member, shards), update.Member, standing.Score, minScore,
+			min(update.Passed, standing.PrevRank-standing.Rank))
+		if err != nil {
+			return err
+		}
+		standing.Passed = passed
+	}
+	return nil
+}
+
+// parseStanding reads the standing returned by updateScoreScript.
+func parseStanding(result []any) (*ScoreStanding, error) {
+	if len(result) != 6 {
+		return nil, fmt.Errorf("unexpected score update result %v", result)
+	}
+
+	standing := &ScoreStanding{New: result[0] == int64(1)}
+	standing.PrevRank, _ = result[1].(int64)
+	standing.Rank, _ = result[3].(int64)
+
+	var err error
+	if standing.PrevScore, err = parseScore(result[2]); err != nil {
+		return nil, err
+	}
+	if standing.Score, err = parseScore(result[4]); err != nil {
+		return nil, err
+	}
+
+	passed, _ := result[5].([]any)
+	standing.Passed = make([]LeaderboardEntry, 0, len(passed)/2)
+	for i := 0; i+1 < len(passed); i += 2 {
+		score, err := parseScore(passed[i+1])
+		if err != nil {
+			return nil, err
+		}
+		standing.Passed = append(standing.Passed, LeaderboardEntry{Member: passed[i], Score: score})
+	}
+	return standing, nil
+}
+
+func parseScore(value any) (float64, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected score %v", value)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func flag(set bool) string {
+	if set {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScoreUpdates runs the UpdateScore contract against a cache driver.
+func testScoreUpdates(t *testing.T, cache ICache, boardKey string) {
+	ctx := context.Background()
+	require.NoError(t, cache.AddScores(ctx, boardKey, map[string]float64{
+		"player1": 100, "player2": 200, "player3": 300,
+	}))
+
+	t.Run("a new member stands below the last place", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player4", Score: 250, Passed: 10})
+		require.NoError(t, err)
+		assert.True(t, standing.New)
+		assert.Equal(t, int64(4), standing.PrevRank)
+		assert.Equal(t, int64(2), standing.Rank)
+		require.Len(t, standing.Passed, 2)
+		assert.Equal(t, "player2", standing.Passed[0].Member)
+		assert.Equal(t, "player1", standing.Passed[1].Member)
+	})
+
+	t.Run("an increment reports the members passed", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player1", Score: 175, Incr: true, Passed: 1})
+		require.NoError(t, err)
+		assert.False(t, standing.New)
+		assert.Equal(t, int64(4), standing.PrevRank)
+		assert.Equal(t, float64(100), standing.PrevScore)
+		assert.Equal(t, int64(2), standing.Rank)
+		assert.Equal(t, float64(275), standing.Score)
+		require.Len(t, standing.Passed, 1, "passed members are bounded")
+		assert.Equal(t, "player4", standing.Passed[0].Member)
+	})
+
+	t.Run("moving down passes nobody", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player3", Score: 50, Ranked: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), standing.PrevRank)
+		assert.Equal(t, int64(4), standing.Rank)
+		assert.Empty(t, standing.Passed)
+	})
+
+	t.Run("best keeps a higher stored score", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player2", Score: 150, Best: true, Ranked: true})
+		require.NoError(t, err)
+		assert.Equal(t, float64(200), standing.Score)
+		assert.Equal(t, standing.PrevRank, standing.Rank)
+
+		standing, err = cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player2", Score: 260, Best: true, Ranked: true})
+		require.NoError(t, err)
+		assert.Equal(t, float64(260), standing.Score)
+		assert.Equal(t, int64(2), standing.Rank)
+	})
+
+	t.Run("a full board only takes its own members", func(t *testing.T) {
+		_, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player5", Score: 1, MaxMembers: 4})
+		assert.ErrorIs(t, err, ErrBoardFull)
+
+		_, err = cache.UpdateScore(ctx, boardKey, ScoreUpdate{Member: "player1", Score: 1, Incr: true, MaxMembers: 4})
+		assert.NoError(t, err)
+	})
+}
+
+func TestMemoryCache_ScoreUpdates(t *testing.T) {
+	testScoreUpdates(t, newMemoryCache(&MockLogger{}), "board")
+}
+
+func TestAppCache_ScoreUpdates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     "localhost:6379",
+		Password: "",
+		DB:       1,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+	defer redisClient.FlushDB(ctx)
+
+	cache := &appCache{
+		serviceName: "test-service",
+		logger:      &MockLogger{},
+		redisClient: redisClient,
+	}
+	for _, boardKey := range []string{"test-standing", ShardedBoardKey("test-sharded-standing", 4)} {
+		t.Run(boardKey, func(t *testing.T) {
+			testScoreUpdates(t, cache, boardKey)
+		})
+	}
+	t.Run("decaying", func(t *testing.T) {
+		testDecayingScores(t, cache, "test-decaying")
+	})
+}
+
+// testDecayingScores runs the decaying UpdateScore contract against a cache driver.
+func testDecayingScores(t *testing.T, cache ICache, boardKey string) {
+	ctx := context.Background()
+	halfLife := time.Hour
+	seed := time.Now().Add(-2 * halfLife)
+
+	t.Run("scores are stored against the seeded epoch", func(t *testing.T) {
+		standing, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{
+			Member: "player1", Score: 10, Incr: true,
+			Decay: &Decay{HalfLife: halfLife, Epoch: seed},
+		})
+		require.NoError(t, err)
+		assert.InDelta(t, 10, standing.Score, 1e-6)
+
+		epoch, err := cache.GetDecayEpoch(ctx, boardKey, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, seed.UnixMicro(), epoch.UnixMicro())
+
+		_, stored, err := cache.GetRank(ctx, boardKey, "player1")
+		require.NoError(t, err)
+		assert.InDelta(t, 40, stored, 1e-3, "two half-lives quadruple the stored score")
+	})
+
+	t.Run("an old epoch is rebased in the write", func(t *testing.T) {
+		old := time.Now().Add(-(decayRebaseExponent + 1) * halfLife)
+		require.NoError(t, cache.Delete(ctx, boardKey))
+		require.NoError(t, cache.AddScores(ctx, boardKey, map[string]float64{"player1": 1}))
+		_, err := cache.UpdateScore(ctx, boardKey, ScoreUpdate{
+			Member: "player2", Score: 5, Incr: true,
+			Decay: &Decay{HalfLife: halfLife, Epoch: old},
+		})
+		require.NoError(t, err)
+
+		epoch, err := cache.GetDecayEpoch(ctx, boardKey, time.Time{})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), epoch, time.Minute)
+
+		_, stored, err := cache.GetRank(ctx, boardKey, "player2")
+		require.NoError(t, err)
+		assert.InDelta(t, 5, stored, 1e-3, "scores are stored against the new epoch")
+	})
+
+	t.Run("sharded boards are rejected", func(t *testing.T) {
+		_, err := cache.UpdateScore(ctx, ShardedBoardKey(boardKey, 4), ScoreUpdate{
+			Member: "player1", Score: 1, Decay: &Decay{HalfLife: halfLife, Epoch: seed},
+		})
+		assert.ErrorIs(t, err, errShardedDecay)
+	})
+}
+
+func TestMemoryCache_DecayingScores(t *testing.T) {
+	testDecayingScores(t, newMemoryCache(&MockLogger{}), "decaying")
+}
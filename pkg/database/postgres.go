@@ -77,10 +77,38 @@ func autoMigration(db *gorm.DB, logger logger.ILogger) error {
 
 	if err := db.AutoMigrate(
 		&model.Leaderboard{},
+		&model.LeaderboardSlugAlias{},
+		&model.LeaderboardTemplate{},
+		&model.NotificationPreference{},
+		&model.Milestone{},
+		&model.EntryRating{},
+		&model.Tenant{},
+		&model.TenantAPIKey{},
+		&model.OutboxEvent{},
+		&model.OutboxFence{},
 	); err != nil {
 		logger.Error("Failed to auto migrate database", "error", err)
 		return err
 	}
 
+	// Metadata lives on the shared base model, so its JSONB containment index is created here
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_leaderboards_metadata ON leaderboards USING gin (metadata)").Error; err != nil {
+		logger.Error("Failed to create leaderboard metadata index", "error", err)
+		return err
+	}
+
+	// Unique indexes that became tenant-scoped; their replacements are created by AutoMigrate
+	for _, index := range []string{
+		"idx_leaderboards_slug",
+		"idx_leaderboard_slug_aliases_slug",
+		"idx_leaderboard_templates_name",
+		"idx_notification_preferences_user_leaderboard",
+	} {
+		if err := db.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+			logger.Error("Failed to drop replaced index", "index", index, "error", err)
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,18 @@
+// Package loggertest provides loggers for tests.
+package loggertest
+
+import (
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// NopLogger is a logger.ILogger that discards everything.
+type NopLogger struct{}
+
+func (NopLogger) Debug(msg string, fields ...any)     {}
+func (NopLogger) Info(msg string, fields ...any)      {}
+func (NopLogger) Warn(msg string, fields ...any)      {}
+func (NopLogger) Error(msg string, fields ...any)     {}
+func (NopLogger) Fatal(msg string, fields ...any)     {}
+func (n NopLogger) With(fields ...any) logger.ILogger { return n }
+func (NopLogger) GetZapLogger() *zap.Logger           { return nil }
@@ -0,0 +1,24 @@
+package rating
+
+import "math"
+
+// ExpectedScore returns the expected Elo score of a player rated a against a player rated b.
+func ExpectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Elo returns the player's state after the outcomes. In multi-player games the K-factor is
+// split across the opponents so a single match moves a rating as much as a duel would.
+func Elo(p Player, outcomes []Outcome, k float64) Player {
+	if len(outcomes) == 0 {
+		return p
+	}
+
+	var delta float64
+	for _, o := range outcomes {
+		delta += o.Score - ExpectedScore(p.Rating, o.Opponent.Rating)
+	}
+
+	p.Rating += k / float64(len(outcomes)) * delta
+	return p
+}
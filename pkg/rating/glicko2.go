@@ -0,0 +1,90 @@
+package rating
+
+import "math"
+
+const (
+	// glicko2Scale converts between the Glicko and Glicko-2 rating scales.
+	glicko2Scale = 173.7178
+
+	// glicko2Epsilon is the convergence tolerance of the volatility iteration.
+	glicko2Epsilon = 0.000001
+)
+
+// Glicko2 returns the player's state after a rating period containing the outcomes,
+// following Glickman's "Example of the Glicko-2 system". tau constrains volatility changes.
+func Glicko2(p Player, outcomes []Outcome, tau float64) Player {
+	mu := (p.Rating - DefaultRating) / glicko2Scale
+	phi := p.Deviation / glicko2Scale
+	sigma := p.Volatility
+
+	if len(outcomes) == 0 {
+		// Only the deviation grows for a player who did not compete
+		p.Deviation = math.Sqrt(phi*phi+sigma*sigma) * glicko2Scale
+		return p
+	}
+
+	var vInv, sum float64
+	for _, o := range outcomes {
+		muJ := (o.Opponent.Rating - DefaultRating) / glicko2Scale
+		phiJ := o.Opponent.Deviation / glicko2Scale
+
+		g := glicko2G(phiJ)
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		vInv += g * g * e * (1 - e)
+		sum += g * (o.Score - e)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	sigma = glicko2Volatility(phi, sigma, v, delta, tau)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phiNew := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	muNew := mu + phiNew*phiNew*sum
+
+	return Player{
+		Rating:     muNew*glicko2Scale + DefaultRating,
+		Deviation:  phiNew * glicko2Scale,
+		Volatility: sigma,
+	}
+}
+
+func glicko2G(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// glicko2Volatility computes the new volatility using the Illinois algorithm.
+func glicko2Volatility(phi, sigma, v, delta, tau float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glicko2Epsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,53 @@
+package rating
+
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+	DefaultEloK       = 32.0
+	DefaultTau        = 0.5
+)
+
+// Player is the rating state of a participant. Deviation and Volatility are only used by Glicko-2.
+type Player struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+// NewPlayer returns a player with the default starting rating state.
+func NewPlayer() Player {
+	return Player{
+		Rating:     DefaultRating,
+		Deviation:  DefaultDeviation,
+		Volatility: DefaultVolatility,
+	}
+}
+
+// Outcome is the result of a game against one opponent: 1 for a win, 0.5 for a draw and 0 for a loss.
+type Outcome struct {
+	Opponent Player
+	Score    float64
+}
+
+// OutcomesFromPlaces expands a multi-player result into pairwise outcomes for every player.
+// places holds the 1-based finishing place of each player; equal places are draws.
+func OutcomesFromPlaces(players []Player, places []int) [][]Outcome {
+	outcomes := make([][]Outcome, len(players))
+	for i := range players {
+		for j := range players {
+			if i == j {
+				continue
+			}
+
+			score := 0.5
+			if places[i] < places[j] {
+				score = 1
+			} else if places[i] > places[j] {
+				score = 0
+			}
+			outcomes[i] = append(outcomes[i], Outcome{Opponent: players[j], Score: score})
+		}
+	}
+	return outcomes
+}
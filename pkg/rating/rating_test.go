@@ -0,0 +1,76 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedScore(t *testing.T) {
+	assert.InDelta(t, 0.5, ExpectedScore(1500, 1500), 1e-9)
+	assert.InDelta(t, 0.9091, ExpectedScore(1800, 1400), 1e-4)
+	assert.InDelta(t, 1, ExpectedScore(1600, 1400)+ExpectedScore(1400, 1600), 1e-9)
+}
+
+func TestElo(t *testing.T) {
+	t.Run("win between equals", func(t *testing.T) {
+		winner := Elo(Player{Rating: 1500}, []Outcome{{Opponent: Player{Rating: 1500}, Score: 1}}, DefaultEloK)
+		loser := Elo(Player{Rating: 1500}, []Outcome{{Opponent: Player{Rating: 1500}, Score: 0}}, DefaultEloK)
+
+		assert.InDelta(t, 1516, winner.Rating, 1e-9)
+		assert.InDelta(t, 1484, loser.Rating, 1e-9)
+	})
+
+	t.Run("draw against stronger player gains rating", func(t *testing.T) {
+		p := Elo(Player{Rating: 1400}, []Outcome{{Opponent: Player{Rating: 1600}, Score: 0.5}}, DefaultEloK)
+		assert.Greater(t, p.Rating, 1400.0)
+	})
+
+	t.Run("no outcomes", func(t *testing.T) {
+		p := Elo(Player{Rating: 1500}, nil, DefaultEloK)
+		assert.Equal(t, 1500.0, p.Rating)
+	})
+}
+
+func TestGlicko2(t *testing.T) {
+	t.Run("example from Glickman's paper", func(t *testing.T) {
+		p := Player{Rating: 1500, Deviation: 200, Volatility: 0.06}
+		outcomes := []Outcome{
+			{Opponent: Player{Rating: 1400, Deviation: 30}, Score: 1},
+			{Opponent: Player{Rating: 1550, Deviation: 100}, Score: 0},
+			{Opponent: Player{Rating: 1700, Deviation: 300}, Score: 0},
+		}
+
+		got := Glicko2(p, outcomes, DefaultTau)
+
+		assert.InDelta(t, 1464.06, got.Rating, 0.01)
+		assert.InDelta(t, 151.52, got.Deviation, 0.01)
+		assert.InDelta(t, 0.05999, got.Volatility, 0.00001)
+	})
+
+	t.Run("inactive player deviation grows", func(t *testing.T) {
+		p := Player{Rating: 1500, Deviation: 50, Volatility: 0.06}
+		got := Glicko2(p, nil, DefaultTau)
+
+		assert.Equal(t, 1500.0, got.Rating)
+		assert.Greater(t, got.Deviation, 50.0)
+	})
+}
+
+func TestOutcomesFromPlaces(t *testing.T) {
+	players := []Player{NewPlayer(), NewPlayer(), NewPlayer()}
+	outcomes := OutcomesFromPlaces(players, []int{1, 2, 2})
+
+	assert.Len(t, outcomes, 3)
+	assert.Equal(t, []float64{1, 1}, scores(outcomes[0]))
+	assert.Equal(t, []float64{0, 0.5}, scores(outcomes[1]))
+	assert.Equal(t, []float64{0, 0.5}, scores(outcomes[2]))
+}
+
+func scores(outcomes []Outcome) []float64 {
+	result := make([]float64, len(outcomes))
+	for i, o := range outcomes {
+		result[i] = o.Score
+	}
+	return result
+}
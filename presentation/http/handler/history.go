@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type HistoryHandler struct {
+	historySvc     service.IHistorySvc
+	leaderboardSvc service.ILeaderboardSvc
+	logger         logger.ILogger
+}
+
+func NewHistoryHandler(historySvc service.IHistorySvc, leaderboardSvc service.ILeaderboardSvc, logger logger.ILogger) *HistoryHandler {
+	return &HistoryHandler{
+		historySvc:     historySvc,
+		leaderboardSvc: leaderboardSvc,
+		logger:         logger,
+	}
+}
+
+func (h *HistoryHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/:id/histories", h.HandleListHistories)
+}
+
+func (h *HistoryHandler) HandleListHistories(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var req dto.ListHistoriesReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+
+	// Histories are recorded under the leaderboard ID, so slugs are resolved first
+	ref, err := h.leaderboardSvc.ResolveLeaderboard(reqCtx, c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to resolve leaderboard", "error", err)
+		return HandleError(c, err)
+	}
+	req.LeaderboardID = ref.ID
+
+	histories, err := h.historySvc.List(reqCtx, &req)
+	if err != nil {
+		h.logger.Error("Failed to list histories", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, histories)
+}
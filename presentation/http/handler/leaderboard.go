@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"net/http"
+	"net/url"
+	"strings"
+
 	"github.com/hiamthach108/simplerank/internal/dto"
 	"github.com/hiamthach108/simplerank/internal/errorx"
 	"github.com/hiamthach108/simplerank/internal/service"
@@ -22,7 +26,10 @@ func NewLeaderboardHandler(leaderboardSvc service.ILeaderboardSvc, logger logger
 
 func (h *LeaderboardHandler) RegisterRoutes(g *echo.Group) {
 	g.GET("/:id", h.HandleGetLeaderboard)
+	g.GET("/:id/entries/:entryId/rank", h.HandleGetEntryRank)
 	g.POST("/:id/score", h.HandleSubmitScore)
+	g.POST("/:id/matches", h.HandleSubmitMatchResult)
+	g.POST("/:id/clone", h.HandleCloneLeaderboard)
 	g.GET("", h.HandleGetAllLeaderboards)
 	g.POST("", h.HandleCreateLeaderboard)
 	g.PUT("", h.HandleUpdateLeaderboard)
@@ -41,6 +48,43 @@ func (h *LeaderboardHandler) HandleGetLeaderboard(c echo.Context) error {
 	return HandleSuccess(c, leaderboard)
 }
 
+// RedirectAliases redirects every request addressing a leaderboard by a previous slug to the same
+// route addressed by its canonical identifier, so old slugs behave alike on every route. It must
+// be used on the routes of a leaderboard before they are registered.
+func (h *LeaderboardHandler) RedirectAliases(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identifier := c.Param("id")
+		if identifier == "" {
+			return next(c)
+		}
+
+		ref, err := h.leaderboardSvc.ResolveLeaderboard(c.Request().Context(), identifier)
+		if err != nil {
+			h.logger.Error("Failed to resolve leaderboard", "error", err)
+			return HandleError(c, err)
+		}
+		if ref.IsAlias {
+			return redirectToCanonical(c, ref.Canonical())
+		}
+		return next(c)
+	}
+}
+
+func (h *LeaderboardHandler) HandleGetEntryRank(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	leaderboardID := c.Param("id")
+	entryID := c.Param("entryId")
+
+	rank, err := h.leaderboardSvc.GetEntryRank(reqCtx, leaderboardID, entryID)
+	if err != nil {
+		h.logger.Error("Failed to get entry rank", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, rank)
+}
+
 func (h *LeaderboardHandler) HandleSubmitScore(c echo.Context) error {
 	reqCtx := c.Request().Context()
 
@@ -51,7 +95,7 @@ func (h *LeaderboardHandler) HandleSubmitScore(c echo.Context) error {
 		h.logger.Error("Failed to bind request", "error", err)
 		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
 	}
-	if err := h.leaderboardSvc.UpdateEntryScore(reqCtx, leaderboardID, req.EntryID, req.Score); err != nil {
+	if err := h.leaderboardSvc.UpdateEntryScore(reqCtx, leaderboardID, req); err != nil {
 		h.logger.Error("Failed to submit score", "error", err)
 		return HandleError(c, err)
 	}
@@ -59,10 +103,54 @@ func (h *LeaderboardHandler) HandleSubmitScore(c echo.Context) error {
 	return HandleSuccess(c, "Score submitted successfully")
 }
 
+func (h *LeaderboardHandler) HandleSubmitMatchResult(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	leaderboardID := c.Param("id")
+
+	var req dto.SubmitMatchResultReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	changes, err := h.leaderboardSvc.SubmitMatchResult(reqCtx, leaderboardID, req)
+	if err != nil {
+		h.logger.Error("Failed to submit match result", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, changes)
+}
+
+func (h *LeaderboardHandler) HandleCloneLeaderboard(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	leaderboardID := c.Param("id")
+
+	var req dto.CloneLeaderboardReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	leaderboard, err := h.leaderboardSvc.CloneLeaderboard(reqCtx, leaderboardID, req)
+	if err != nil {
+		h.logger.Error("Failed to clone leaderboard", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, leaderboard)
+}
+
 func (h *LeaderboardHandler) HandleGetAllLeaderboards(c echo.Context) error {
 	reqCtx := c.Request().Context()
 
-	leaderboards, err := h.leaderboardSvc.GetListLeaderboards(reqCtx)
+	var req dto.ListLeaderboardsReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+
+	leaderboards, err := h.leaderboardSvc.GetListLeaderboards(reqCtx, req)
 	if err != nil {
 		h.logger.Error("Failed to get leaderboards", "error", err)
 		return HandleError(c, err)
@@ -103,3 +191,24 @@ func (h *LeaderboardHandler) HandleUpdateLeaderboard(c echo.Context) error {
 
 	return HandleSuccess(c, "Leaderboard updated successfully")
 }
+
+// redirectToCanonical permanently redirects a request addressed by a previous slug to the same
+// path addressed by the canonical identifier, keeping the query string. Requests other than
+// reads are redirected with 308 so clients repeat them with the same method and body.
+func redirectToCanonical(c echo.Context, canonical string) error {
+	segments := strings.Split(c.Request().URL.EscapedPath(), "/")
+	for i, segment := range strings.Split(c.Path(), "/") {
+		if segment == ":id" && i < len(segments) {
+			segments[i] = url.PathEscape(canonical)
+		}
+	}
+
+	location := strings.Join(segments, "/")
+	if query := c.Request().URL.RawQuery; query != "" {
+		location += "?" + query
+	}
+	if method := c.Request().Method; method == http.MethodGet || method == http.MethodHead {
+		return c.Redirect(http.StatusMovedPermanently, location)
+	}
+	return c.Redirect(http.StatusPermanentRedirect, location)
+}
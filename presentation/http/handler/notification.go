@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	notificationSvc service.INotificationSvc
+	logger          logger.ILogger
+}
+
+func NewNotificationHandler(notificationSvc service.INotificationSvc, logger logger.ILogger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationSvc: notificationSvc,
+		logger:          logger,
+	}
+}
+
+func (h *NotificationHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/:userId/notification-preferences", h.HandleGetPreferences)
+	g.PUT("/:userId/notification-preferences", h.HandleUpdatePreference)
+}
+
+func (h *NotificationHandler) HandleGetPreferences(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	prefs, err := h.notificationSvc.GetPreferences(reqCtx, c.Param("userId"))
+	if err != nil {
+		h.logger.Error("Failed to get notification preferences", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, prefs)
+}
+
+func (h *NotificationHandler) HandleUpdatePreference(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var req dto.UpdateNotificationPreferenceReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	pref, err := h.notificationSvc.UpdatePreference(reqCtx, c.Param("userId"), req)
+	if err != nil {
+		h.logger.Error("Failed to update notification preference", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, pref)
+}
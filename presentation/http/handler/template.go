@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type TemplateHandler struct {
+	leaderboardSvc service.ILeaderboardSvc
+	logger         logger.ILogger
+}
+
+func NewTemplateHandler(leaderboardSvc service.ILeaderboardSvc, logger logger.ILogger) *TemplateHandler {
+	return &TemplateHandler{
+		leaderboardSvc: leaderboardSvc,
+		logger:         logger,
+	}
+}
+
+func (h *TemplateHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/:id", h.HandleGetTemplate)
+	g.POST("/:id/instantiate", h.HandleInstantiateTemplate)
+	g.GET("", h.HandleGetAllTemplates)
+	g.POST("", h.HandleCreateTemplate)
+}
+
+func (h *TemplateHandler) HandleGetTemplate(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	template, err := h.leaderboardSvc.GetTemplate(reqCtx, c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to get template", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, template)
+}
+
+func (h *TemplateHandler) HandleInstantiateTemplate(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var req dto.InstantiateTemplateReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	leaderboards, err := h.leaderboardSvc.InstantiateTemplate(reqCtx, c.Param("id"), req)
+	if err != nil {
+		h.logger.Error("Failed to instantiate template", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, leaderboards)
+}
+
+func (h *TemplateHandler) HandleGetAllTemplates(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var req dto.ListTemplatesReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+
+	templates, err := h.leaderboardSvc.GetListTemplates(reqCtx, req)
+	if err != nil {
+		h.logger.Error("Failed to get templates", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, templates)
+}
+
+func (h *TemplateHandler) HandleCreateTemplate(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var req dto.CreateTemplateReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	template, err := h.leaderboardSvc.CreateTemplate(reqCtx, req)
+	if err != nil {
+		h.logger.Error("Failed to create template", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, template)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"github.com/hiamthach108/simplerank/internal/dto"
+	"github.com/hiamthach108/simplerank/internal/errorx"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/labstack/echo/v4"
+)
+
+type TenantHandler struct {
+	tenantSvc service.ITenantSvc
+	logger    logger.ILogger
+}
+
+func NewTenantHandler(tenantSvc service.ITenantSvc, logger logger.ILogger) *TenantHandler {
+	return &TenantHandler{
+		tenantSvc: tenantSvc,
+		logger:    logger,
+	}
+}
+
+func (h *TenantHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/:id", h.HandleGetTenant)
+	g.PUT("/:id", h.HandleUpdateTenant)
+	g.POST("/:id/api-keys", h.HandleIssueAPIKey)
+	g.DELETE("/:id/api-keys/:keyId", h.HandleRevokeAPIKey)
+}
+
+func (h *TenantHandler) HandleGetTenant(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	tenantID := c.Param("id")
+	if !tenant.Valid(tenantID) {
+		return HandleError(c, errorx.New(errorx.ErrBadRequest, "invalid tenant"))
+	}
+
+	resp, err := h.tenantSvc.GetTenant(reqCtx, tenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, resp)
+}
+
+func (h *TenantHandler) HandleUpdateTenant(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	tenantID := c.Param("id")
+	if !tenant.Valid(tenantID) {
+		return HandleError(c, errorx.New(errorx.ErrBadRequest, "invalid tenant"))
+	}
+
+	var req dto.UpdateTenantReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", "error", err)
+		return HandleError(c, errorx.Wrap(errorx.ErrBadRequest, err))
+	}
+	resp, err := h.tenantSvc.UpdateTenant(reqCtx, tenantID, req)
+	if err != nil {
+		h.logger.Error("Failed to update tenant", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, resp)
+}
+
+func (h *TenantHandler) HandleIssueAPIKey(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	tenantID := c.Param("id")
+	if !tenant.Valid(tenantID) {
+		return HandleError(c, errorx.New(errorx.ErrBadRequest, "invalid tenant"))
+	}
+
+	resp, err := h.tenantSvc.IssueAPIKey(reqCtx, tenantID)
+	if err != nil {
+		h.logger.Error("Failed to issue api key", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, resp)
+}
+
+func (h *TenantHandler) HandleRevokeAPIKey(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	tenantID := c.Param("id")
+	if !tenant.Valid(tenantID) {
+		return HandleError(c, errorx.New(errorx.ErrBadRequest, "invalid tenant"))
+	}
+
+	if err := h.tenantSvc.RevokeAPIKey(reqCtx, tenantID, c.Param("keyId")); err != nil {
+		h.logger.Error("Failed to revoke api key", "error", err)
+		return HandleError(c, err)
+	}
+
+	return HandleSuccess(c, "API key revoked successfully")
+}
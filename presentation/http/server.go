@@ -2,11 +2,16 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/errorx"
 	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
+	"github.com/hiamthach108/simplerank/pkg/cache"
 	"github.com/hiamthach108/simplerank/pkg/logger"
 	"github.com/hiamthach108/simplerank/presentation/http/handler"
 	"github.com/hiamthach108/simplerank/presentation/socket"
@@ -25,6 +30,11 @@ func NewHttpServer(
 	config *config.AppConfig,
 	logger logger.ILogger,
 	leaderboardSvc service.ILeaderboardSvc,
+	historySvc service.IHistorySvc,
+	notificationSvc service.INotificationSvc,
+	tenantSvc service.ITenantSvc,
+	outboxSvc service.IOutboxSvc,
+	localCache cache.ILocalCache,
 	wsHub *socket.Hub,
 ) *HttpServer {
 	e := echo.New()
@@ -57,6 +67,8 @@ func NewHttpServer(
 			echo.HeaderCacheControl,
 			echo.HeaderContentLength,
 			echo.HeaderUpgrade,
+			tenant.Header,
+			tenant.APIKeyHeader,
 		},
 		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 	}))
@@ -72,14 +84,49 @@ func NewHttpServer(
 		return c.String(http.StatusOK, "pong")
 	})
 
+	// Monitoring routes expose cross-tenant internals, so only admins may read them
+	stats := e.Group("/stats", requireAdmin(config))
+
+	// Local cache hit/miss counters for monitoring
+	stats.GET("/local-cache", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, localCache.Stats())
+	})
+
+	// Outbox depth and age of the oldest waiting event for monitoring
+	stats.GET("/outbox", func(c echo.Context) error {
+		stats, err := outboxSvc.Stats(c.Request().Context())
+		if err != nil {
+			return handler.HandleError(c, errorx.Wrap(errorx.ErrInternal, err))
+		}
+		return c.JSON(http.StatusOK, stats)
+	})
+
 	// WebSocket endpoint
-	e.GET("/ws", socket.HandleWebSocket(wsHub))
+	wsHub.SetTopicResolver(leaderboardTopicResolver(leaderboardSvc))
+	e.GET("/ws", socket.HandleWebSocket(wsHub), authenticateTenant(tenantSvc))
 
 	v1 := e.Group("/api/v1")
+	tenantScoped := authenticateTenant(tenantSvc)
 
 	// Register leaderboard routes
 	leaderboardHandler := handler.NewLeaderboardHandler(leaderboardSvc, logger)
-	leaderboardHandler.RegisterRoutes(v1.Group("/leaderboards"))
+	historyHandler := handler.NewHistoryHandler(historySvc, leaderboardSvc, logger)
+	leaderboardsGroup := v1.Group("/leaderboards", tenantScoped)
+	leaderboardsGroup.Use(leaderboardHandler.RedirectAliases)
+	leaderboardHandler.RegisterRoutes(leaderboardsGroup)
+	historyHandler.RegisterRoutes(leaderboardsGroup)
+
+	// Register leaderboard template routes
+	templateHandler := handler.NewTemplateHandler(leaderboardSvc, logger)
+	templateHandler.RegisterRoutes(v1.Group("/leaderboard-templates", tenantScoped))
+
+	// Register user routes
+	notificationHandler := handler.NewNotificationHandler(notificationSvc, logger)
+	notificationHandler.RegisterRoutes(v1.Group("/users", tenantScoped))
+
+	// Register tenant routes
+	tenantHandler := handler.NewTenantHandler(tenantSvc, logger)
+	tenantHandler.RegisterRoutes(v1.Group("/tenants", requireAdmin(config)))
 
 	return &HttpServer{
 		config: *config,
@@ -108,3 +155,75 @@ func RegisterHooks(lc fx.Lifecycle, server *HttpServer) {
 		},
 	})
 }
+
+// authenticateTenant scopes a request to the tenant its API key was issued to, or to the default
+// tenant when it carries none. A tenant named in the header must match the key's. Browsers
+// cannot set headers on websocket handshakes, so the key and tenant may also be sent as the
+// apiKey and tenantId query parameters.
+func authenticateTenant(tenantSvc service.ITenantSvc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey := c.Request().Header.Get(tenant.APIKeyHeader)
+			if apiKey == "" {
+				apiKey = c.QueryParam("apiKey")
+			}
+			named := c.Request().Header.Get(tenant.Header)
+			if named == "" {
+				named = c.QueryParam("tenantId")
+			}
+
+			tenantID := tenant.Default
+			if apiKey != "" {
+				authenticated, err := tenantSvc.Authenticate(c.Request().Context(), apiKey)
+				if err != nil {
+					return handler.HandleError(c, err)
+				}
+				tenantID = authenticated
+			}
+			if named != "" && named != tenantID {
+				if apiKey == "" {
+					return handler.HandleError(c, errorx.New(errorx.ErrUnauthorized, "an api key is required to act as a tenant"))
+				}
+				return handler.HandleError(c, errorx.New(errorx.ErrForbidden, "tenant does not match the api key"))
+			}
+
+			c.SetRequest(c.Request().WithContext(tenant.WithTenant(c.Request().Context(), tenantID)))
+			return next(c)
+		}
+	}
+}
+
+// requireAdmin lets through requests carrying the configured admin key, and rejects every
+// request when none is configured. It guards tenant administration and monitoring routes.
+func requireAdmin(config *config.AppConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			adminKey := config.Auth.AdminAPIKey
+			if adminKey == "" {
+				return handler.HandleError(c, errorx.New(errorx.ErrForbidden, "administration is disabled"))
+			}
+			apiKey := c.Request().Header.Get(tenant.APIKeyHeader)
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(adminKey)) != 1 {
+				return handler.HandleError(c, errorx.New(errorx.ErrUnauthorized, "invalid admin key"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// leaderboardTopicResolver canonicalizes leaderboard topics addressed by ID or slug to the
+// tenant-scoped topic events are broadcast on, rejecting leaderboards of other tenants.
+func leaderboardTopicResolver(leaderboardSvc service.ILeaderboardSvc) socket.TopicResolver {
+	return func(tenantID string, topic string) (string, error) {
+		identifier, ok := strings.CutPrefix(topic, socket.TopicLeaderboard)
+		if !ok {
+			return topic, nil
+		}
+		ctx := tenant.WithTenant(context.Background(), tenantID)
+		ref, err := leaderboardSvc.ResolveLeaderboard(ctx, identifier)
+		if err != nil {
+			return "", err
+		}
+		return socket.LeaderboardTopic(tenantID, ref.ID), nil
+	}
+}
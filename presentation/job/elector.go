@@ -0,0 +1,182 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+)
+
+const (
+	// DefaultLeaderLockTTL bounds how long the jobs stay without a leader after it dies.
+	DefaultLeaderLockTTL = time.Duration(15 * time.Second)
+
+	// leaderLockKey is the lock held by the leader.
+	leaderLockKey = "leader"
+)
+
+// Elector elects one instance among those sharing the cache as the leader. The leader holds a
+// lock and renews it a few times per TTL; when it dies the lock expires and another instance
+// takes over.
+type Elector struct {
+	config *config.AppConfig
+	logger logger.ILogger
+	cache  cache.ICache
+	// key names the lock the leader holds.
+	key string
+	ttl time.Duration
+
+	mu   sync.RWMutex
+	lock *cache.Lock
+	// leading is done once the leadership held with lock is lost.
+	leading  context.Context
+	stepDown context.CancelFunc
+	// renewedAt is when lock was last known to be held, so a leader that cannot reach the cache
+	// steps down before its lock may expire.
+	renewedAt time.Time
+
+	// cancel stops the campaign started by Start, and done is closed once it stopped.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewElector(config *config.AppConfig, logger logger.ILogger, cache cache.ICache) *Elector {
+	return NewKeyElector(config, logger, cache, leaderLockKey)
+}
+
+// NewKeyElector returns an elector of its own, electing the holder of the lock named key, such
+// as the single consumer of a stream partition.
+func NewKeyElector(config *config.AppConfig, logger logger.ILogger, cache cache.ICache, key string) *Elector {
+	ttl := time.Duration(config.Leader.LockTTLMs) * time.Millisecond
+	if ttl <= 0 {
+		ttl = DefaultLeaderLockTTL
+	}
+
+	return &Elector{
+		config: config,
+		logger: logger,
+		cache:  cache,
+		key:    key,
+		ttl:    ttl,
+	}
+}
+
+// Leadership returns a context that is done once this instance stops leading, along with the
+// fencing token of its leadership. It reports false when the instance does not lead.
+func (e *Elector) Leadership() (context.Context, int64, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.lock == nil {
+		return nil, 0, false
+	}
+	return e.leading, e.lock.Token, true
+}
+
+// Start campaigns for leadership in the background until Stop
+func (e *Elector) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			e.campaign(runCtx)
+			select {
+			case <-ticker.C:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	e.logger.Info("Leader election started", "key", e.key, "ttl", e.ttl)
+	return nil
+}
+
+// campaign renews the lock of a leader, or tries to take it otherwise.
+func (e *Elector) campaign(ctx context.Context) {
+	e.mu.RLock()
+	lock := e.lock
+	e.mu.RUnlock()
+
+	if lock != nil {
+		err := e.cache.RenewLock(ctx, lock, e.ttl)
+		switch {
+		case err == nil:
+			e.renewedAt = time.Now()
+		case errors.Is(err, cache.ErrLockLost):
+			e.logger.Warn("Lost leadership", "key", e.key, "token", lock.Token)
+			e.resign()
+		case ctx.Err() != nil:
+		default:
+			e.logger.Error("Failed to renew leadership", "key", e.key, "token", lock.Token, "error", err)
+			// Step down while the lock is surely still held, before another leader may be elected
+			if time.Since(e.renewedAt) >= e.ttl-e.ttl/3 {
+				e.logger.Warn("Stepping down as leader", "key", e.key, "token", lock.Token)
+				e.resign()
+			}
+		}
+		return
+	}
+
+	lock, err := e.cache.AcquireLock(ctx, e.key, e.ttl)
+	if err != nil {
+		if !errors.Is(err, cache.ErrLockHeld) && ctx.Err() == nil {
+			e.logger.Error("Failed to campaign for leadership", "key", e.key, "error", err)
+		}
+		return
+	}
+
+	e.mu.Lock()
+	e.lock = lock
+	e.leading, e.stepDown = context.WithCancel(context.Background())
+	e.renewedAt = time.Now()
+	e.mu.Unlock()
+	e.logger.Info("Became leader", "key", e.key, "token", lock.Token)
+}
+
+// resign gives up leadership, ending the leadership context, and returns the lock it held.
+func (e *Elector) resign() *cache.Lock {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lock := e.lock
+	if lock != nil {
+		e.stepDown()
+		e.lock = nil
+	}
+	return lock
+}
+
+// Stop stops campaigning and releases the leadership, so another instance takes over without
+// waiting for the lock to expire.
+func (e *Elector) Stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if lock := e.resign(); lock != nil {
+		if err := e.cache.ReleaseLock(ctx, lock); err != nil && !errors.Is(err, cache.ErrLockLost) {
+			e.logger.Error("Failed to release leadership", "key", e.key, "token", lock.Token, "error", err)
+			return err
+		}
+		e.logger.Info("Released leadership", "key", e.key, "token", lock.Token)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/pkg/logger/loggertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockTTL = 60 * time.Millisecond
+
+// renewFailer fails the lock renewals of one instance with renewErr while it is set, sharing the
+// locks of the other instances otherwise.
+type renewFailer struct {
+	cache.ICache
+	renewErr atomic.Pointer[error]
+}
+
+func (c *renewFailer) RenewLock(ctx context.Context, lock *cache.Lock, ttl time.Duration) error {
+	if err := c.renewErr.Load(); err != nil {
+		return *err
+	}
+	return c.ICache.RenewLock(ctx, lock, ttl)
+}
+
+func (c *renewFailer) failRenewals(err error) {
+	c.renewErr.Store(&err)
+}
+
+func newTestElectors(t *testing.T) (*Elector, *Elector, *renewFailer) {
+	cfg := &config.AppConfig{}
+	cfg.Leader.LockTTLMs = int(testLockTTL / time.Millisecond)
+	shared, err := cache.NewMemoryCache(cfg, loggertest.NopLogger{})
+	require.NoError(t, err)
+
+	failing := &renewFailer{ICache: shared}
+	first := NewElector(cfg, loggertest.NopLogger{}, failing)
+	second := NewElector(cfg, loggertest.NopLogger{}, shared)
+	t.Cleanup(func() {
+		_ = first.Stop(context.Background())
+		_ = second.Stop(context.Background())
+		_ = shared.Close()
+	})
+	return first, second, failing
+}
+
+// awaitLeadership waits until elector leads and returns its leadership.
+func awaitLeadership(t *testing.T, elector *Elector) (context.Context, int64) {
+	t.Helper()
+	var leading context.Context
+	var token int64
+	require.Eventually(t, func() bool {
+		var ok bool
+		leading, token, ok = elector.Leadership()
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	return leading, token
+}
+
+func TestElector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a single instance acquires the leadership", func(t *testing.T) {
+		first, second, _ := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		_, token := awaitLeadership(t, first)
+		assert.Equal(t, int64(1), token)
+
+		require.NoError(t, second.Start(ctx))
+		time.Sleep(2 * testLockTTL)
+		_, _, ok := second.Leadership()
+		assert.False(t, ok, "the leadership is renewed, so it never expires")
+		_, _, ok = first.Leadership()
+		assert.True(t, ok)
+	})
+
+	t.Run("a lost lock ends the leadership and another instance takes over", func(t *testing.T) {
+		first, second, failing := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		leading, token := awaitLeadership(t, first)
+		require.NoError(t, second.Start(ctx))
+
+		failing.failRenewals(cache.ErrLockLost)
+		select {
+		case <-leading.Done():
+		case <-time.After(time.Second):
+			t.Fatal("leadership not ended")
+		}
+		_, _, ok := first.Leadership()
+		assert.False(t, ok)
+
+		_, next := awaitLeadership(t, second)
+		assert.Greater(t, next, token)
+	})
+
+	t.Run("a leader that cannot renew steps down before its lock expires", func(t *testing.T) {
+		first, _, failing := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		leading, _ := awaitLeadership(t, first)
+
+		failing.failRenewals(errors.New("cache unavailable"))
+		failedAt := time.Now()
+		select {
+		case <-leading.Done():
+			assert.Less(t, time.Since(failedAt), testLockTTL)
+		case <-time.After(time.Second):
+			t.Fatal("leader did not step down")
+		}
+	})
+
+	t.Run("stopping hands the leadership over at once", func(t *testing.T) {
+		first, second, _ := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		leading, token := awaitLeadership(t, first)
+		require.NoError(t, second.Start(ctx))
+
+		require.NoError(t, first.Stop(ctx))
+		assert.Error(t, leading.Err())
+
+		// The released lock is taken on the next campaign, well before it would have expired
+		stoppedAt := time.Now()
+		_, next := awaitLeadership(t, second)
+		assert.Less(t, time.Since(stoppedAt), testLockTTL)
+		assert.Greater(t, next, token)
+	})
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("jobs run on the leader only, with its fencing token", func(t *testing.T) {
+		first, second, _ := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		_, token := awaitLeadership(t, first)
+		require.NoError(t, second.Start(ctx))
+
+		tokens := make(chan int64, 100)
+		var followerRuns atomic.Int32
+		leader := &Runner{logger: loggertest.NopLogger{}, elector: first, jobs: []Job{{
+			Name: "leader", Interval: 5 * time.Millisecond,
+			Run: func(_ context.Context, token int64) error {
+				tokens <- token
+				return nil
+			},
+		}}}
+		follower := &Runner{logger: loggertest.NopLogger{}, elector: second, jobs: []Job{{
+			Name: "follower", Interval: 5 * time.Millisecond,
+			Run: func(context.Context, int64) error {
+				followerRuns.Add(1)
+				return nil
+			},
+		}}}
+		require.NoError(t, leader.Start(ctx))
+		require.NoError(t, follower.Start(ctx))
+		defer follower.Stop(ctx)
+		defer leader.Stop(ctx)
+
+		select {
+		case got := <-tokens:
+			assert.Equal(t, token, got)
+		case <-time.After(time.Second):
+			t.Fatal("job did not run on the leader")
+		}
+		time.Sleep(20 * time.Millisecond)
+		assert.Zero(t, followerRuns.Load())
+	})
+
+	t.Run("a running job is cancelled when the leadership is lost", func(t *testing.T) {
+		first, _, failing := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		awaitLeadership(t, first)
+
+		started := make(chan struct{}, 1)
+		cancelled := make(chan struct{})
+		runner := &Runner{logger: loggertest.NopLogger{}, elector: first, jobs: []Job{{
+			Name: "long", Interval: 5 * time.Millisecond,
+			Run: func(ctx context.Context, _ int64) error {
+				select {
+				case started <- struct{}{}:
+				default:
+					return nil
+				}
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			},
+		}}}
+		require.NoError(t, runner.Start(ctx))
+		defer runner.Stop(ctx)
+
+		<-started
+		failing.failRenewals(cache.ErrLockLost)
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("job not cancelled")
+		}
+	})
+
+	t.Run("Stop waits for running jobs", func(t *testing.T) {
+		first, _, _ := newTestElectors(t)
+		require.NoError(t, first.Start(ctx))
+		awaitLeadership(t, first)
+
+		started := make(chan struct{})
+		var finished atomic.Bool
+		var once atomic.Bool
+		runner := &Runner{logger: loggertest.NopLogger{}, elector: first, jobs: []Job{{
+			Name: "slow", Interval: 5 * time.Millisecond,
+			Run: func(context.Context, int64) error {
+				if once.Swap(true) {
+					return nil
+				}
+				close(started)
+				time.Sleep(30 * time.Millisecond)
+				finished.Store(true)
+				return nil
+			},
+		}}}
+		require.NoError(t, runner.Start(ctx))
+
+		<-started
+		require.NoError(t, runner.Stop(ctx))
+		assert.True(t, finished.Load())
+	})
+}
@@ -0,0 +1,72 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+)
+
+// DefaultOutboxRelayInterval is how often the outbox is relayed unless configured.
+const DefaultOutboxRelayInterval = time.Duration(200 * time.Millisecond)
+
+const (
+	outboxMonitorInterval = time.Duration(30 * time.Second)
+	// outboxAlertAge is how long events may wait in the outbox before the monitor warns.
+	outboxAlertAge = time.Duration(1 * time.Minute)
+)
+
+// newOutboxRelayJob publishes the stream events waiting in the outbox. It runs on the leader so
+// events are relayed by one instance, in order, and hands the relay the fencing token of the
+// leadership so a leader that lost it without noticing cannot relay anymore.
+func newOutboxRelayJob(config *config.AppConfig, logger logger.ILogger, outboxSvc service.IOutboxSvc) Job {
+	interval := time.Duration(config.Outbox.RelayIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = DefaultOutboxRelayInterval
+	}
+	batchSize := config.Outbox.BatchSize
+	if batchSize <= 0 {
+		batchSize = service.DefaultOutboxBatchSize
+	}
+
+	return Job{
+		Name:     "outbox-relay",
+		Interval: interval,
+		Run: func(ctx context.Context, token int64) error {
+			// Relays until the outbox is drained or an event fails to publish
+			for ctx.Err() == nil {
+				// A relay in progress finishes when stopping, so published events leave the outbox
+				relayed, err := outboxSvc.Relay(context.WithoutCancel(ctx), token)
+				if err != nil {
+					return err
+				}
+				if relayed < batchSize {
+					return nil
+				}
+				logger.Info("Relayed outbox events", "count", relayed)
+			}
+			return nil
+		},
+	}
+}
+
+// newOutboxMonitorJob warns when events wait in the outbox for long, which means streams have
+// been failing. It runs on the leader so the warning is logged once for all instances.
+func newOutboxMonitorJob(logger logger.ILogger, outboxSvc service.IOutboxSvc) Job {
+	return Job{
+		Name:     "outbox-monitor",
+		Interval: outboxMonitorInterval,
+		Run: func(ctx context.Context, token int64) error {
+			stats, err := outboxSvc.Stats(ctx)
+			if err != nil {
+				return err
+			}
+			if stats.OldestAgeSeconds >= outboxAlertAge.Seconds() {
+				logger.Warn("Events are waiting in the outbox", "depth", stats.Depth, "oldestAgeSeconds", stats.OldestAgeSeconds)
+			}
+			return nil
+		},
+	}
+}
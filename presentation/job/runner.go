@@ -0,0 +1,126 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hiamthach108/simplerank/config"
+	"github.com/hiamthach108/simplerank/internal/service"
+	"github.com/hiamthach108/simplerank/pkg/logger"
+	"go.uber.org/fx"
+)
+
+// Job is a periodic task that runs on the leader only. Run gets a context that is done once the
+// leadership is lost, and the fencing token of the leadership to hand to the resources it writes.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, token int64) error
+}
+
+// Runner runs the jobs every interval while this instance leads.
+type Runner struct {
+	logger  logger.ILogger
+	elector *Elector
+	jobs    []Job
+	// cancel stops the jobs started by Start, and running tracks them so Stop can wait for them.
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewRunner(config *config.AppConfig, logger logger.ILogger, elector *Elector, outboxSvc service.IOutboxSvc) *Runner {
+	return &Runner{
+		logger:  logger,
+		elector: elector,
+		jobs: []Job{
+			newOutboxRelayJob(config, logger, outboxSvc),
+			newOutboxMonitorJob(logger, outboxSvc),
+		},
+	}
+}
+
+// Start schedules every job in the background until Stop
+func (r *Runner) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.cancel = cancel
+
+	for _, job := range r.jobs {
+		r.running.Add(1)
+		go func() {
+			defer r.running.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					r.run(runCtx, job)
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	r.logger.Info("Job runner started", "jobs", len(r.jobs))
+	return nil
+}
+
+// run runs a job once if this instance leads, until it is done or the leadership is lost.
+func (r *Runner) run(ctx context.Context, job Job) {
+	leading, token, ok := r.elector.Leadership()
+	if !ok {
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(leading)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	if err := job.Run(jobCtx, token); err != nil {
+		r.logger.Error("Job failed", "job", job.Name, "token", token, "error", err)
+	}
+}
+
+// Stop stops scheduling jobs and waits until ctx is done for those running.
+func (r *Runner) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.logger.Info("Job runner stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterHooks registers the leader election and job runner lifecycle hooks with fx
+func RegisterHooks(lc fx.Lifecycle, elector *Elector, runner *Runner) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := elector.Start(ctx); err != nil {
+				return err
+			}
+			return runner.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			// Jobs stop before the leadership is released, so they never run on two instances
+			if err := runner.Stop(ctx); err != nil {
+				return err
+			}
+			return elector.Stop(ctx)
+		},
+	})
+}
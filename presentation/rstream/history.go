@@ -2,52 +2,177 @@ package rstream
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/hiamthach108/simplerank/internal/dto"
 	"github.com/hiamthach108/simplerank/internal/shared/constants"
 	"github.com/hiamthach108/simplerank/pkg/cache"
+	"github.com/hiamthach108/simplerank/presentation/job"
 )
 
-// subscribeToLeaderboardUpdates subscribes to leaderboard updates stream
+const (
+	// partitionLeasePrefix names the lease held by the consumer of a partition.
+	partitionLeasePrefix = "stream-partition:"
+	// partitionLeasePoll is how often an instance checks whether it took a partition's lease over.
+	partitionLeasePoll = time.Duration(1 * time.Second)
+)
+
+// historyDecoders decode every schema version of the leaderboard updates into the current
+// CreateHistoryReq. Bumping dto.HistorySchemaVersion needs a decoder for the new version here,
+// and the one for the previous version upgrading what it decodes.
+var historyDecoders = messageDecoders[dto.CreateHistoryReq]{
+	0:                        decodeHistoryV0,
+	dto.HistorySchemaVersion: decodeAs[dto.CreateHistoryReq],
+}
+
+// historyReqV0 is the shape of the updates gob-encoded before streams carried a codec header.
+type historyReqV0 struct {
+	LeaderboardID string
+	EntryID       string
+	Score         float64
+	Metadata      any
+}
+
+// decodeHistoryV0 decodes an update of schema version 0, which predates OccurredAt, so it is
+// recorded when consumed.
+func decodeHistoryV0(message cache.StreamMessage) (*dto.CreateHistoryReq, error) {
+	var legacy historyReqV0
+	if err := message.Decode(&legacy); err != nil {
+		return nil, err
+	}
+	return &dto.CreateHistoryReq{
+		LeaderboardID: legacy.LeaderboardID,
+		EntryID:       legacy.EntryID,
+		Score:         legacy.Score,
+		Metadata:      legacy.Metadata,
+	}, nil
+}
+
+// subscribeToLeaderboardUpdates subscribes to leaderboard updates stream, and to the partitions
+// of it this instance consumes when it is partitioned
 func (s *Subscriber) subscribeToLeaderboardUpdates(ctx context.Context) error {
-	stream := constants.STREAM_LEADERBOARD_UPDATE
+	// The stream itself is always consumed, draining updates published before partitioning
+	if err := s.subscribeToUpdateStream(ctx, constants.STREAM_LEADERBOARD_UPDATE, s.config.Stream.Workers); err != nil {
+		return err
+	}
+
+	partitions := s.config.Stream.UpdatePartitions
+	if partitions <= 1 {
+		return nil
+	}
+	if count, index := s.config.Stream.InstanceCount, s.config.Stream.InstanceIndex; count > 0 && (index < 0 || index >= count) {
+		return fmt.Errorf("stream instance index %d is out of range for %d instances", index, count)
+	}
+	for i, stream := range cache.StreamPartitions(constants.STREAM_LEADERBOARD_UPDATE, partitions) {
+		if !s.ownsPartition(i) {
+			continue
+		}
+		if err := s.consumePartition(ctx, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consumePartition consumes a partition of the leaderboard updates while this instance holds
+// its lease, with a single worker, so the updates of a leaderboard are recorded in order by one
+// consumer at a time. Instances not holding the lease stand by to take the partition over.
+func (s *Subscriber) consumePartition(ctx context.Context, stream string) error {
+	elector := job.NewKeyElector(s.config, s.logger, s.cache, partitionLeasePrefix+stream)
+	if err := elector.Start(ctx); err != nil {
+		return err
+	}
+	s.electors = append(s.electors, elector)
+
+	s.partitions.Add(1)
+	go func() {
+		defer s.partitions.Done()
+		ticker := time.NewTicker(partitionLeasePoll)
+		defer ticker.Stop()
+
+		for {
+			if leading, token, ok := elector.Leadership(); ok {
+				s.logger.Info("[STREAM] Consuming partition", "stream", stream, "token", token)
+				leaseCtx, cancel := context.WithCancel(leading)
+				stop := context.AfterFunc(ctx, cancel)
+				if err := s.subscribeToUpdateStream(leaseCtx, stream, 1); err == nil {
+					<-leaseCtx.Done()
+				}
+				stop()
+				cancel()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// subscribeToUpdateStream records the leaderboard updates of a stream with workers consumers
+func (s *Subscriber) subscribeToUpdateStream(ctx context.Context, stream string, workers int) error {
 	group := constants.STREAM_LEADERBOARD_GROUP
 
 	// Ensure consumer group exists
-	if err := s.cache.EnsureGroup(stream, group); err != nil {
+	if err := s.cache.EnsureGroup(ctx, stream, group); err != nil {
 		s.logger.Error("Failed to ensure consumer group", "stream", stream, "group", group, "error", err)
 		return err
 	}
 
-	// Create handler
+	// Create handler; records are buffered and inserted into ClickHouse in batches, and the
+	// batch is only acknowledged once inserted
 	handler := cache.ConsumerHandler{
-		Consumer: constants.STREAM_HISTORY_CONSUMER_GROUP,
-		Handler: func(message any) {
-			// Process leaderboard update message
-			s.logger.Info("[STREAM] Received leaderboard update", "stream", stream)
-
-			// Decode message using generic helper
-			req, err := decodeMessage[dto.CreateHistoryReq](message)
-			if err != nil {
-				s.logger.Error("[STREAM] Failed to decode message", "error", err)
-				return
+		Consumer: constants.STREAM_HISTORY_CONSUMER + "-" + s.instance,
+		BatchHandler: s.track(func(messages []cache.StreamMessage) error {
+			// Process leaderboard update messages
+			s.logger.Info("[STREAM] Received leaderboard updates", "stream", stream, "count", len(messages))
+
+			// Messages that fail to decode never will, so they are dead-lettered without holding
+			// back the rest of the batch; those of a newer schema version stay pending instead
+			reqs := make([]*dto.CreateHistoryReq, 0, len(messages))
+			rejected := cache.RejectedMessages{}
+			for _, message := range messages {
+				req, err := historyDecoders.decode(message)
+				if err != nil {
+					s.logger.Error("[STREAM] Failed to decode message", "id", message.ID, "codec", message.Codec, "schemaVersion", message.SchemaVersion, "error", err)
+					rejected[message.ID] = err
+					continue
+				}
+				reqs = append(reqs, req)
 			}
 
-			history, err := s.historySvc.Record(ctx, req)
-			if err != nil {
-				s.logger.Error("[STREAM] Failed to record history", "error", err)
-				return
+			if len(reqs) > 0 {
+				// A batch being recorded is finished even when the subscriber is stopping
+				histories, err := s.historySvc.RecordBatch(context.WithoutCancel(ctx), reqs)
+				if err != nil {
+					// The store failing is not the messages' fault, so they stay pending to be
+					// recorded once it recovers
+					s.logger.Error("[STREAM] Failed to record history batch", "count", len(reqs), "error", err)
+					return fmt.Errorf("%w: %w", cache.ErrRetryLater, err)
+				}
+				s.logger.Info("[STREAM] Recorded history batch successfully", "count", len(histories))
+			}
+
+			if len(rejected) > 0 {
+				return rejected
 			}
-			s.logger.Info("[STREAM] Recorded history successfully", "history", history)
-		},
+			return nil
+		}),
+		BatchSize:     s.config.History.BatchSize,
+		FlushInterval: time.Duration(s.config.History.FlushIntervalMs) * time.Millisecond,
+		Workers:       workers,
 	}
 
 	// Subscribe to stream
-	if err := s.cache.Subscribe(stream, group, handler); err != nil {
+	if err := s.cache.Subscribe(ctx, stream, group, handler); err != nil {
 		s.logger.Error("[STREAM] Failed to subscribe to stream", "stream", stream, "error", err)
 		return err
 	}
 
-	s.logger.Info("[STREAM] Subscribed to stream", "stream", stream, "group", group, "consumer", handler.Consumer)
+	s.logger.Info("[STREAM] Subscribed to stream", "stream", stream, "group", group, "consumer", handler.Consumer, "workers", workers)
 	return nil
 }
@@ -1,15 +1,18 @@
 package rstream
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
 
 	"github.com/hiamthach108/simplerank/config"
 	"github.com/hiamthach108/simplerank/internal/service"
 	"github.com/hiamthach108/simplerank/pkg/cache"
 	"github.com/hiamthach108/simplerank/pkg/logger"
+	"github.com/hiamthach108/simplerank/presentation/job"
 	"go.uber.org/fx"
 )
 
@@ -18,8 +21,26 @@ type Subscriber struct {
 	cache      cache.ICache
 	logger     logger.ILogger
 	historySvc service.IHistorySvc
+	// instance names this instance's consumers, so instances never share pending entries.
+	instance string
+	// cancel stops the subscriptions started by Start.
+	cancel context.CancelFunc
+	// electors hold the leases of the partitions this instance may consume, and partitions
+	// tracks the goroutines consuming them while leased.
+	electors   []*job.Elector
+	partitions sync.WaitGroup
+
+	// handlers tracks handler calls in progress so Stop can wait for them; once stopping, new
+	// calls are refused and their messages stay pending.
+	mu       sync.Mutex
+	stopping bool
+	handlers sync.WaitGroup
 }
 
+// errStopping is returned for messages delivered while the subscriber is stopping, which stay
+// pending.
+var errStopping = fmt.Errorf("subscriber is stopping: %w", cache.ErrRetryLater)
+
 func NewSubscriber(
 	config *config.AppConfig,
 	cache cache.ICache,
@@ -31,15 +52,41 @@ func NewSubscriber(
 		cache:      cache,
 		logger:     logger,
 		historySvc: historySvc,
+		instance:   instanceName(config),
+	}
+}
+
+// instanceName identifies this instance among the consumers of a group: STREAM_CONSUMER_NAME,
+// or else the host name and process ID.
+func instanceName(config *config.AppConfig) string {
+	if config.Stream.ConsumerName != "" {
+		return config.Stream.ConsumerName
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
 	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// ownsPartition reports whether this instance competes for the lease of a partition. Without an
+// instance count, every instance competes for every partition.
+func (s *Subscriber) ownsPartition(partition int) bool {
+	count := s.config.Stream.InstanceCount
+	return count <= 0 || partition%count == s.config.Stream.InstanceIndex
 }
 
 // Start initializes and starts all stream subscriptions
 func (s *Subscriber) Start(ctx context.Context) error {
 	s.logger.Info("Starting Redis Stream Subscriber...")
 
+	// Subscriptions outlive the start context and run until Stop
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.cancel = cancel
+
 	// Subscribe here
-	if err := s.subscribeToLeaderboardUpdates(ctx); err != nil {
+	if err := s.subscribeToLeaderboardUpdates(runCtx); err != nil {
+		cancel()
 		return err
 	}
 
@@ -47,11 +94,56 @@ func (s *Subscriber) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully shuts down the subscriber
+// Stop gracefully shuts down the subscriber, waiting until ctx is done for the messages being
+// handled, and then releases the partitions it leased. Messages not handled by then are left
+// pending to be claimed again, and partitions not released are taken over once their leases expire.
 func (s *Subscriber) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping Redis Stream Subscriber...")
-	// Add any cleanup logic here if needed
-	return nil
+
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.partitions.Wait()
+		s.handlers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// Partitions are released once their handlers are done, so they never have two consumers
+		for _, elector := range s.electors {
+			if err := elector.Stop(ctx); err != nil {
+				return err
+			}
+		}
+		s.logger.Info("Redis Stream Subscriber stopped")
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("Redis Stream Subscriber stopped before its handlers finished", "error", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// track wraps a batch handler so Stop can wait for its calls in progress.
+func (s *Subscriber) track(handler func(messages []cache.StreamMessage) error) func(messages []cache.StreamMessage) error {
+	return func(messages []cache.StreamMessage) error {
+		s.mu.Lock()
+		if s.stopping {
+			s.mu.Unlock()
+			return errStopping
+		}
+		s.handlers.Add(1)
+		s.mu.Unlock()
+		defer s.handlers.Done()
+
+		return handler(messages)
+	}
 }
 
 // RegisterHooks registers the subscriber lifecycle hooks with fx
@@ -66,26 +158,30 @@ func RegisterHooks(lc fx.Lifecycle, subscriber *Subscriber) {
 	})
 }
 
-// decodeMessage is a generic helper function to decode gob-encoded messages
-func decodeMessage[T any](message any) (*T, error) {
-	// Extract binary data from message
-	messageMap, ok := message.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("failed to cast message to map")
-	}
+// messageDecoders decode each schema version of a message still found in its stream into the
+// current T, so a shape change only needs a decoder for the versions it left behind.
+type messageDecoders[T any] map[int]func(message cache.StreamMessage) (*T, error)
 
-	data, ok := messageMap["data"].(string)
+// decode decodes a message with the decoder of its schema version. Versions newer than every
+// decoder come from a producer already upgraded during a rolling deploy, so they fail with
+// cache.ErrRetryLater to wait for an upgraded consumer; other versions without a decoder are
+// rejected.
+func (d messageDecoders[T]) decode(message cache.StreamMessage) (*T, error) {
+	decode, ok := d[message.SchemaVersion]
 	if !ok {
-		return nil, fmt.Errorf("failed to extract data field from message")
+		if message.SchemaVersion > slices.Max(slices.Collect(maps.Keys(d))) {
+			return nil, fmt.Errorf("%w: schema version %d of message %s is newer than this consumer", cache.ErrRetryLater, message.SchemaVersion, message.ID)
+		}
+		return nil, fmt.Errorf("unsupported schema version %d of message %s", message.SchemaVersion, message.ID)
 	}
+	return decode(message)
+}
 
-	// Decode using gob
+// decodeAs decodes a message written in the shape of T with the codec it was written with.
+func decodeAs[T any](message cache.StreamMessage) (*T, error) {
 	var result T
-	buf := bytes.NewBufferString(data)
-	decoder := gob.NewDecoder(buf)
-	if err := decoder.Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode message: %w", err)
+	if err := message.Decode(&result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hiamthach108/simplerank/internal/shared/tenant"
 	"github.com/hiamthach108/simplerank/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
@@ -25,13 +26,23 @@ const (
 
 // Topic types for different broadcast channels
 const (
-	TopicLeaderboard  = "leaderboard:"  // leaderboard:id
+	TopicLeaderboard  = "leaderboard:"  // leaderboard:tenantId:id
 	TopicUser         = "user:"         // user:id
 	TopicGlobal       = "global"        // broadcast to all
 	TopicNotification = "notification:" // notification:userId
 	TopicChat         = "chat:"         // chat:roomId
 )
 
+// LeaderboardTopic returns the topic updates of a tenant's leaderboard are broadcast on.
+func LeaderboardTopic(tenantID string, leaderboardID string) string {
+	return TopicLeaderboard + tenantID + ":" + leaderboardID
+}
+
+// userKey identifies a user's connections; user IDs are only unique within a tenant.
+func userKey(tenantID string, userID string) string {
+	return tenantID + ":" + userID
+}
+
 // MessageType represents the type of WebSocket message
 type MessageType string
 
@@ -43,6 +54,8 @@ const (
 	MessageTypePing              MessageType = "ping"
 	MessageTypePong              MessageType = "pong"
 	MessageTypeNotification      MessageType = "notification"
+	MessageTypeOvertaken         MessageType = "overtaken"
+	MessageTypeMilestone         MessageType = "milestone"
 	MessageTypeUserUpdate        MessageType = "user_update"
 	MessageTypeSystemMessage     MessageType = "system_message"
 	MessageTypeChatMessage       MessageType = "chat_message"
@@ -64,6 +77,7 @@ type Client struct {
 	send           chan []byte
 	subscriptions  map[string]bool // subscribed topics
 	userID         string          // optional user identifier
+	tenantID       string          // tenant the connection belongs to
 	subscriptionMu sync.RWMutex
 }
 
@@ -77,9 +91,15 @@ type Hub struct {
 	broadcast   chan *BroadcastMessage
 	mu          sync.RWMutex
 
+	topicResolver TopicResolver
+
 	logger logger.ILogger
 }
 
+// TopicResolver maps a topic requested by a client of the tenant to the canonical topic
+// messages are broadcast on, or fails when the client may not subscribe to it.
+type TopicResolver func(tenantID string, topic string) (string, error)
+
 type clientRegistration struct {
 	client *Client
 	topic  string
@@ -89,7 +109,7 @@ type clientRegistration struct {
 type BroadcastMessage struct {
 	Topic   string // Topic to broadcast to (empty means broadcast to all)
 	Message []byte
-	UserID  string // Optional: for user-specific broadcasts
+	UserID  string // Optional: tenant-scoped user key for user-specific broadcasts
 }
 
 // NewHub creates a new WebSocket hub
@@ -105,6 +125,19 @@ func NewHub(logger logger.ILogger) *Hub {
 	}
 }
 
+// SetTopicResolver sets the resolver applied to topics clients subscribe to.
+// It must be set before the hub starts serving clients.
+func (h *Hub) SetTopicResolver(resolver TopicResolver) {
+	h.topicResolver = resolver
+}
+
+func (h *Hub) resolveTopic(tenantID string, topic string) (string, error) {
+	if h.topicResolver == nil || topic == "" {
+		return topic, nil
+	}
+	return h.topicResolver(tenantID, topic)
+}
+
 // Run starts the hub's main loop
 func (h *Hub) Run(ctx context.Context) {
 	h.logger.Info("Starting WebSocket Hub...")
@@ -129,10 +162,11 @@ func (h *Hub) Run(ctx context.Context) {
 
 			// Register to user-specific clients if userID is set
 			if registration.client.userID != "" {
-				if _, ok := h.userClients[registration.client.userID]; !ok {
-					h.userClients[registration.client.userID] = make(map[*Client]bool)
+				key := userKey(registration.client.tenantID, registration.client.userID)
+				if _, ok := h.userClients[key]; !ok {
+					h.userClients[key] = make(map[*Client]bool)
 				}
-				h.userClients[registration.client.userID][registration.client] = true
+				h.userClients[key][registration.client] = true
 			}
 
 			h.mu.Unlock()
@@ -149,10 +183,11 @@ func (h *Hub) Run(ctx context.Context) {
 
 			// Remove from user-specific clients
 			if client.userID != "" {
-				if clients, ok := h.userClients[client.userID]; ok {
+				key := userKey(client.tenantID, client.userID)
+				if clients, ok := h.userClients[key]; ok {
 					delete(clients, client)
 					if len(clients) == 0 {
-						delete(h.userClients, client.userID)
+						delete(h.userClients, key)
 					}
 				}
 			}
@@ -228,8 +263,8 @@ func (h *Hub) Broadcast(topic string, messageType MessageType, data any) {
 	}
 }
 
-// BroadcastToUser sends a message to a specific user across all their connections
-func (h *Hub) BroadcastToUser(userID string, messageType MessageType, data any) {
+// BroadcastToUser sends a message to a specific user of a tenant across all their connections
+func (h *Hub) BroadcastToUser(tenantID string, userID string, messageType MessageType, data any) {
 	message := Message{
 		Type:      messageType,
 		Topic:     TopicUser + userID,
@@ -244,7 +279,7 @@ func (h *Hub) BroadcastToUser(userID string, messageType MessageType, data any)
 	}
 
 	h.broadcast <- &BroadcastMessage{
-		UserID:  userID,
+		UserID:  userKey(tenantID, userID),
 		Message: jsonData,
 	}
 }
@@ -346,7 +381,16 @@ func (c *Client) writePump() {
 func (c *Client) handleMessage(msg *Message) {
 	switch msg.Type {
 	case MessageTypeSubscribe:
-		topic := msg.Topic
+		topic, err := c.hub.resolveTopic(c.tenantID, msg.Topic)
+		if err != nil {
+			c.sendMessage(Message{
+				Type:      MessageTypeError,
+				Topic:     msg.Topic,
+				Data:      map[string]string{"error": "topic not found"},
+				Timestamp: time.Now(),
+			})
+			return
+		}
 		if topic != "" {
 			c.subscriptionMu.Lock()
 			c.subscriptions[topic] = true
@@ -369,7 +413,11 @@ func (c *Client) handleMessage(msg *Message) {
 		}
 
 	case MessageTypeUnsubscribe:
-		topic := msg.Topic
+		// Canonical topics may be unsubscribed from directly
+		topic, err := c.hub.resolveTopic(c.tenantID, msg.Topic)
+		if err != nil {
+			topic = msg.Topic
+		}
 		if topic != "" {
 			c.subscriptionMu.Lock()
 			delete(c.subscriptions, topic)
@@ -415,6 +463,8 @@ func HandleWebSocket(hub *Hub) echo.HandlerFunc {
 			if userID == "" {
 				userID = c.Request().Header.Get("X-User-ID")
 			}
+			// The tenant was authenticated before the upgrade
+			tenantID := tenant.FromContext(c.Request().Context())
 
 			client := &Client{
 				hub:           hub,
@@ -422,6 +472,7 @@ func HandleWebSocket(hub *Hub) echo.HandlerFunc {
 				send:          make(chan []byte, 256),
 				subscriptions: make(map[string]bool),
 				userID:        userID,
+				tenantID:      tenantID,
 			}
 
 			// Start client goroutines